@@ -0,0 +1,104 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"expense-tracker/domain/attachment"
+	"expense-tracker/domain/audit"
+)
+
+// AttachmentService keeps files with expenses. Files are removed together
+// with their expense when it is purged from the trash.
+type AttachmentService struct {
+	repo     attachment.Repository
+	expenses *ExpenseService
+	auditLog *AuditService
+}
+
+func NewAttachmentService(repo attachment.Repository, expenses *ExpenseService, auditLog *AuditService) *AttachmentService {
+	return &AttachmentService{repo: repo, expenses: expenses, auditLog: auditLog}
+}
+
+// Upload attaches data to an expense. expense.ErrExpenseNotFound means there
+// is no such expense.
+func (s *AttachmentService) Upload(expenseID string, data []byte, filename, contentType string, actor audit.Actor) (*attachment.Attachment, error) {
+	if err := attachment.Validate(contentType, int64(len(data))); err != nil {
+		return nil, err
+	}
+	if _, err := s.expenses.GetExpense(expenseID); err != nil {
+		return nil, err
+	}
+
+	a := &attachment.Attachment{
+		ExpenseID:   expenseID,
+		Filename:    filename,
+		ContentType: contentType,
+		UploadedBy:  actor.Username,
+		UploadedAt:  time.Now(),
+	}
+	if err := s.repo.SaveAttachment(a, data); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[ATTACHMENT] %s attached %q (%s, %d bytes) to expense %s", actor.Username, filename, contentType, a.Size, expenseID)
+	s.auditLog.Record(actor, audit.ActionAttachmentUpload, a.ID, nil, attachmentAuditData(a))
+	return a, nil
+}
+
+// List returns an expense's attachments, oldest first
+func (s *AttachmentService) List(expenseID string) ([]attachment.Attachment, error) {
+	if _, err := s.expenses.GetExpense(expenseID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAttachments(expenseID)
+}
+
+// ByExpense groups every attachment by the ID of its expense
+func (s *AttachmentService) ByExpense() (map[string][]attachment.Attachment, error) {
+	all, err := s.repo.ListAllAttachments()
+	if err != nil {
+		return nil, err
+	}
+	grouped := make(map[string][]attachment.Attachment)
+	for _, a := range all {
+		grouped[a.ExpenseID] = append(grouped[a.ExpenseID], a)
+	}
+	return grouped, nil
+}
+
+// Download returns an attachment and its content
+func (s *AttachmentService) Download(id string) (*attachment.Attachment, []byte, error) {
+	a, err := s.repo.GetAttachment(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := s.repo.AttachmentData(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, data, nil
+}
+
+func (s *AttachmentService) Delete(id string, actor audit.Actor) error {
+	a, err := s.repo.GetAttachment(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteAttachment(id); err != nil {
+		return err
+	}
+
+	log.Printf("[ATTACHMENT] %s removed %q from expense %s", actor.Username, a.Filename, a.ExpenseID)
+	s.auditLog.Record(actor, audit.ActionAttachmentDelete, id, attachmentAuditData(a), nil)
+	return nil
+}
+
+func attachmentAuditData(a *attachment.Attachment) map[string]interface{} {
+	return map[string]interface{}{
+		"expenseId":   a.ExpenseID,
+		"filename":    a.Filename,
+		"contentType": a.ContentType,
+		"size":        a.Size,
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"expense-tracker/domain/audit"
+)
+
+type AuditService struct {
+	repo audit.Repository
+}
+
+func NewAuditService(repo audit.Repository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record appends an entry to the audit log. A failure is logged but never
+// returned, so auditing cannot break the change being audited.
+func (s *AuditService) Record(actor audit.Actor, action, targetID string, before, after map[string]interface{}) {
+	entry := audit.Entry{
+		Actor:     actor.Username,
+		Action:    action,
+		TargetID:  targetID,
+		Before:    before,
+		After:     after,
+		IP:        actor.IP,
+		Timestamp: time.Now(),
+	}
+	if err := s.repo.AppendAudit(entry); err != nil {
+		log.Printf("[AUDIT] Failed to record %s by %s on %s: %v", action, actor.Username, targetID, err)
+	}
+}
+
+func (s *AuditService) Search(filter audit.Filter) ([]audit.Entry, error) {
+	return s.repo.FindAudit(filter)
+}
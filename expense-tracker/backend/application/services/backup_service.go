@@ -0,0 +1,551 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"expense-tracker/domain/attachment"
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/backup"
+	"expense-tracker/domain/catalogue"
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/user"
+)
+
+// BackupStore is what a backup reads and a restore writes
+type BackupStore interface {
+	expense.Repository
+	backup.Repository
+	attachment.Repository
+	exchange.Repository
+	catalogue.Repository
+
+	CreateUser(username, password string) error
+	FindUser(username string) (*user.UserDTO, error)
+	ListUsers() ([]user.UserDTO, error)
+	SetUserRole(username string, role user.Role) error
+	SetUserDisabled(username string, disabled bool) error
+	GetRegistrationMode() (user.RegistrationMode, error)
+	SaveRegistrationMode(mode user.RegistrationMode) error
+}
+
+type BackupService struct {
+	store    BackupStore
+	source   string
+	dir      string
+	auditLog *AuditService
+}
+
+// NewBackupService writes archives from store; source names the storage
+// backend in the manifest. Before anything destructive a backup is written
+// to dir.
+func NewBackupService(store BackupStore, source, dir string, auditLog *AuditService) *BackupService {
+	return &BackupService{store: store, source: source, dir: dir, auditLog: auditLog}
+}
+
+// Snapshot collects every expense (deleted ones included) with its
+// attachments, every user without their password, the settings that are not
+// secrets, the exchange rates and the item catalogue
+func (s *BackupService) Snapshot(actor audit.Actor) (*backup.Archive, error) {
+	expenses, err := s.store.ExportExpenses()
+	if err != nil {
+		return nil, fmt.Errorf("export expenses: %w", err)
+	}
+	attachments, err := s.exportAttachments(expenses)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.store.ListUsers()
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	archivedUsers := make([]backup.User, 0, len(users))
+	for _, u := range users {
+		archivedUsers = append(archivedUsers, backup.User{
+			Username:  u.Username,
+			Role:      string(u.Role),
+			Disabled:  u.Disabled,
+			CreatedAt: u.CreatedAt,
+		})
+	}
+
+	mode, err := s.store.GetRegistrationMode()
+	if err != nil {
+		return nil, fmt.Errorf("read registration mode: %w", err)
+	}
+
+	rates, err := s.store.ListRates()
+	if err != nil {
+		return nil, fmt.Errorf("list rates: %w", err)
+	}
+	archivedRates := make([]backup.Rate, 0, len(rates))
+	for _, rate := range rates {
+		archivedRates = append(archivedRates, backup.Rate{
+			Currency:  string(rate.Currency),
+			Date:      rate.Date,
+			Rate:      rate.Rate,
+			UpdatedBy: rate.UpdatedBy,
+			UpdatedAt: rate.UpdatedAt,
+		})
+	}
+
+	items, err := s.store.ListItems()
+	if err != nil {
+		return nil, fmt.Errorf("list catalogue items: %w", err)
+	}
+	archivedItems := make([]backup.Item, 0, len(items))
+	for _, item := range items {
+		archivedItems = append(archivedItems, backup.Item{
+			Name:      item.Name,
+			Aliases:   item.Aliases,
+			Category:  item.Category,
+			Unit:      item.Unit,
+			UpdatedBy: item.UpdatedBy,
+			UpdatedAt: item.UpdatedAt,
+		})
+	}
+
+	return &backup.Archive{
+		Manifest: backup.Manifest{
+			CreatedAt: time.Now(),
+			CreatedBy: actor.Username,
+			Source:    s.source,
+		},
+		Expenses:    expenses,
+		Attachments: attachments,
+		Users:       archivedUsers,
+		Settings:    []backup.Setting{{Key: backup.SettingRegistrationMode, Value: string(mode)}},
+		Rates:       archivedRates,
+		Items:       archivedItems,
+	}, nil
+}
+
+// exportAttachments reads the files of the exported expenses. A file whose
+// expense was purged while the backup ran is left out.
+func (s *BackupService) exportAttachments(expenses []backup.Expense) ([]backup.Attachment, error) {
+	exported := make(map[string]bool, len(expenses))
+	for _, exp := range expenses {
+		exported[exp.ID] = true
+	}
+
+	stored, err := s.store.ListAllAttachments()
+	if err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+	attachments := make([]backup.Attachment, 0, len(stored))
+	for _, a := range stored {
+		if !exported[a.ExpenseID] {
+			continue
+		}
+		data, err := s.store.AttachmentData(a.ID)
+		if errors.Is(err, attachment.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read attachment %s: %w", a.ID, err)
+		}
+		attachments = append(attachments, backup.Attachment{
+			ID:          a.ID,
+			ExpenseID:   a.ExpenseID,
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			UploadedBy:  a.UploadedBy,
+			UploadedAt:  a.UploadedAt,
+			Data:        data,
+		})
+	}
+	return attachments, nil
+}
+
+// WriteBackup snapshots the store into w
+func (s *BackupService) WriteBackup(w io.Writer, actor audit.Actor) error {
+	archive, err := s.Snapshot(actor)
+	if err != nil {
+		return err
+	}
+	if err := backup.Write(w, archive); err != nil {
+		return err
+	}
+
+	log.Printf("[BACKUP] %s wrote a backup: %d expenses, %d attachments, %d users",
+		actor.Username, len(archive.Expenses), len(archive.Attachments), len(archive.Users))
+	s.auditLog.Record(actor, audit.ActionBackupCreate, "", nil, map[string]interface{}{
+		"expenses":    len(archive.Expenses),
+		"attachments": len(archive.Attachments),
+		"users":       len(archive.Users),
+	})
+	return nil
+}
+
+// writeSafetyBackup writes a backup to the backup directory ahead of a
+// destructive operation named by reason and returns its path
+func (s *BackupService) writeSafetyBackup(actor audit.Actor, reason string) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("expense-tracker-%s-before-%s.jsonl", time.Now().Format("20060102-150405"), reason)
+	path := filepath.Join(s.dir, name)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.WriteBackup(file, actor); err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// ClearAllExpenses permanently removes every expense, active and deleted.
+// A backup is written first and nothing is removed if that fails; its path
+// is returned.
+func (s *BackupService) ClearAllExpenses(actor audit.Actor) (string, error) {
+	path, err := s.writeSafetyBackup(actor, "clear-all")
+	if err != nil {
+		return "", fmt.Errorf("backup before clearing: %w", err)
+	}
+
+	current, err := s.store.FindAll()
+	if err != nil {
+		return path, err
+	}
+	if err := s.store.ClearAll(); err != nil {
+		return path, err
+	}
+
+	log.Printf("[BACKUP] %s cleared all %d expenses, backup at %s", actor.Username, len(current), path)
+	s.auditLog.Record(actor, audit.ActionExpenseClearAll, "", nil, map[string]interface{}{
+		"removed": len(current),
+		"backup":  filepath.Base(path),
+	})
+	return path, nil
+}
+
+// Restore applies archive in the given mode. With dryRun nothing is written
+// and the report says what would happen. A replace writes a backup of the
+// current data first.
+func (s *BackupService) Restore(archive *backup.Archive, mode backup.Mode, dryRun bool, actor audit.Actor) (*backup.Report, error) {
+	report := &backup.Report{
+		Mode:             mode,
+		DryRun:           dryRun,
+		ArchiveCreatedAt: archive.Manifest.CreatedAt.Format(time.RFC3339),
+		UsersAdded:       []string{},
+		UsersUpdated:     []string{},
+		UsersSkipped:     []string{},
+		SettingsUpdated:  []string{},
+	}
+
+	if mode == backup.ModeReplace && !dryRun {
+		path, err := s.writeSafetyBackup(actor, "restore")
+		if err != nil {
+			return report, fmt.Errorf("backup before replacing: %w", err)
+		}
+		report.SafetyBackup = path
+	}
+
+	if err := s.restoreExpenses(archive, mode, dryRun, report); err != nil {
+		return report, err
+	}
+	if err := s.restoreUsers(archive, mode, dryRun, report); err != nil {
+		return report, err
+	}
+	if err := s.restoreSettings(archive, mode, dryRun, report); err != nil {
+		return report, err
+	}
+	if err := s.restoreRates(archive, mode, dryRun, report); err != nil {
+		return report, err
+	}
+	if err := s.restoreItems(archive, mode, dryRun, report); err != nil {
+		return report, err
+	}
+
+	if !dryRun {
+		log.Printf("[BACKUP] %s restored a backup (%s): +%d/-%d expenses, %d users added",
+			actor.Username, mode, report.ExpensesAdded, report.ExpensesRemoved, len(report.UsersAdded))
+		s.auditLog.Record(actor, audit.ActionBackupRestore, "", nil, map[string]interface{}{
+			"mode":            string(mode),
+			"archiveCreated":  report.ArchiveCreatedAt,
+			"expensesRemoved": report.ExpensesRemoved,
+			"expensesAdded":   report.ExpensesAdded,
+			"expensesSkipped": report.ExpensesSkipped,
+			"attachments":     report.AttachmentsAdded,
+			"usersAdded":      report.UsersAdded,
+			"usersUpdated":    report.UsersUpdated,
+			"ratesSaved":      report.RatesSaved,
+			"itemsSaved":      report.ItemsSaved,
+		})
+	}
+	return report, nil
+}
+
+func (s *BackupService) restoreExpenses(archive *backup.Archive, mode backup.Mode, dryRun bool, report *backup.Report) error {
+	if mode == backup.ModeReplace {
+		current, err := s.store.FindAll()
+		if err != nil {
+			return err
+		}
+		report.ExpensesRemoved = len(current)
+		if !dryRun {
+			if err := s.store.ClearAll(); err != nil {
+				return fmt.Errorf("clear expenses: %w", err)
+			}
+		}
+	}
+
+	// imported maps the archived ID of each added expense to its new ID
+	imported := make(map[string]string)
+	for _, exp := range archive.Expenses {
+		if mode == backup.ModeMerge {
+			_, err := s.store.GetByID(exp.ID)
+			if err == nil {
+				report.ExpensesSkipped++
+				continue
+			}
+			if !errors.Is(err, expense.ErrExpenseNotFound) {
+				return err
+			}
+		}
+
+		report.ExpensesAdded++
+		if dryRun {
+			imported[exp.ID] = exp.ID
+			continue
+		}
+		id, err := s.store.ImportExpense(exp)
+		if err != nil {
+			return fmt.Errorf("import expense %s: %w", exp.ID, err)
+		}
+		imported[exp.ID] = id
+	}
+
+	return s.restoreAttachments(archive, imported, dryRun, report)
+}
+
+// restoreAttachments stores the files of the expenses that were added,
+// under the IDs the expenses were given
+func (s *BackupService) restoreAttachments(archive *backup.Archive, imported map[string]string, dryRun bool, report *backup.Report) error {
+	for _, archived := range archive.Attachments {
+		expenseID, ok := imported[archived.ExpenseID]
+		if !ok {
+			continue
+		}
+
+		report.AttachmentsAdded++
+		if dryRun {
+			continue
+		}
+		a := &attachment.Attachment{
+			ExpenseID:   expenseID,
+			Filename:    archived.Filename,
+			ContentType: archived.ContentType,
+			UploadedBy:  archived.UploadedBy,
+			UploadedAt:  archived.UploadedAt,
+		}
+		if err := s.store.SaveAttachment(a, archived.Data); err != nil {
+			return fmt.Errorf("restore attachment %s: %w", archived.ID, err)
+		}
+	}
+	return nil
+}
+
+// restoreUsers creates missing accounts with a random password, since the
+// archive has none; an admin has to reset it before the user can log in
+func (s *BackupService) restoreUsers(archive *backup.Archive, mode backup.Mode, dryRun bool, report *backup.Report) error {
+	for _, archived := range archive.Users {
+		role, err := user.ParseRole(archived.Role)
+		if err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("user %s: %v, skipped", archived.Username, err))
+			report.UsersSkipped = append(report.UsersSkipped, archived.Username)
+			continue
+		}
+
+		existing, err := s.store.FindUser(archived.Username)
+		if err != nil && !errors.Is(err, user.ErrUserNotFound) {
+			return err
+		}
+
+		switch {
+		case existing == nil:
+			report.UsersAdded = append(report.UsersAdded, archived.Username)
+			if dryRun {
+				continue
+			}
+			if err := s.createUser(archived.Username); err != nil {
+				return fmt.Errorf("create user %s: %w", archived.Username, err)
+			}
+			existing = &user.UserDTO{Username: archived.Username, Role: user.DefaultRole(archived.Username)}
+		case mode == backup.ModeMerge || (existing.Role == role && existing.Disabled == archived.Disabled):
+			report.UsersSkipped = append(report.UsersSkipped, archived.Username)
+			continue
+		default:
+			report.UsersUpdated = append(report.UsersUpdated, archived.Username)
+			if dryRun {
+				continue
+			}
+		}
+
+		if existing.Role != role {
+			if err := s.store.SetUserRole(archived.Username, role); err != nil {
+				return fmt.Errorf("set role of %s: %w", archived.Username, err)
+			}
+		}
+		if existing.Disabled != archived.Disabled {
+			if err := s.store.SetUserDisabled(archived.Username, archived.Disabled); err != nil {
+				return fmt.Errorf("set status of %s: %w", archived.Username, err)
+			}
+		}
+	}
+
+	if len(report.UsersAdded) > 0 {
+		report.Warnings = append(report.Warnings, "new users have a random password and must have it reset by an admin before they can log in")
+	}
+	return nil
+}
+
+func (s *BackupService) createUser(username string) error {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	return s.store.CreateUser(username, hex.EncodeToString(secret))
+}
+
+// restoreSettings only overwrites in replace mode; a merge keeps the
+// settings the instance already has
+func (s *BackupService) restoreSettings(archive *backup.Archive, mode backup.Mode, dryRun bool, report *backup.Report) error {
+	for _, setting := range archive.Settings {
+		if setting.Key != backup.SettingRegistrationMode {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("unknown setting %q ignored", setting.Key))
+			continue
+		}
+		if mode != backup.ModeReplace {
+			continue
+		}
+
+		value, err := user.ParseRegistrationMode(setting.Value)
+		if err != nil {
+			report.Warnings = append(report.Warnings, err.Error())
+			continue
+		}
+		current, err := s.store.GetRegistrationMode()
+		if err != nil {
+			return err
+		}
+		if current == value {
+			continue
+		}
+
+		report.SettingsUpdated = append(report.SettingsUpdated, setting.Key)
+		if !dryRun {
+			if err := s.store.SaveRegistrationMode(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restoreRates adds the archived rates that are missing. A replace also
+// overwrites the rates of the same currency and day; rates that are not in
+// the archive are kept either way, as expenses only record the rate they
+// were converted at.
+func (s *BackupService) restoreRates(archive *backup.Archive, mode backup.Mode, dryRun bool, report *backup.Report) error {
+	current, err := s.store.ListRates()
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]string, len(current))
+	for _, rate := range current {
+		stored[string(rate.Currency)+" "+rate.Date.Format(exchange.DateLayout)] = rate.Rate
+	}
+
+	for _, archived := range archive.Rates {
+		existing, ok := stored[archived.Currency+" "+archived.Date.Format(exchange.DateLayout)]
+		if ok && (mode == backup.ModeMerge || existing == archived.Rate) {
+			continue
+		}
+
+		report.RatesSaved++
+		if dryRun {
+			continue
+		}
+		rate := exchange.Rate{
+			Currency:  expense.Currency(archived.Currency),
+			Date:      archived.Date,
+			Rate:      archived.Rate,
+			UpdatedBy: archived.UpdatedBy,
+			UpdatedAt: archived.UpdatedAt,
+		}
+		if err := s.store.SaveRate(rate); err != nil {
+			return fmt.Errorf("restore rate %s %s: %w", archived.Currency, archived.Date.Format(exchange.DateLayout), err)
+		}
+	}
+	return nil
+}
+
+// sameItem tells whether a and b differ in nothing a restore writes
+func sameItem(a, b catalogue.Item) bool {
+	return a.Name == b.Name && strings.Join(a.Aliases, "\x00") == strings.Join(b.Aliases, "\x00") &&
+		a.Category == b.Category && a.Unit == b.Unit
+}
+
+// restoreItems adds the archived catalogue items whose name is not in the
+// catalogue. A replace also overwrites the aliases, category and unit of
+// the items of the same name; items that are not in the archive are kept.
+func (s *BackupService) restoreItems(archive *backup.Archive, mode backup.Mode, dryRun bool, report *backup.Report) error {
+	current, err := s.store.ListItems()
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]catalogue.Item, len(current))
+	for _, item := range current {
+		stored[expense.NormalizeText(item.Name)] = item
+	}
+
+	for _, archived := range archive.Items {
+		item := catalogue.Item{
+			Name:      archived.Name,
+			Aliases:   archived.Aliases,
+			Category:  archived.Category,
+			Unit:      archived.Unit,
+			UpdatedBy: archived.UpdatedBy,
+			UpdatedAt: archived.UpdatedAt,
+		}.Clean()
+		existing, ok := stored[expense.NormalizeText(item.Name)]
+		if ok && (mode == backup.ModeMerge || sameItem(existing, item)) {
+			continue
+		}
+
+		report.ItemsSaved++
+		if dryRun {
+			continue
+		}
+		if ok {
+			item.ID = existing.ID
+			err = s.store.UpdateItem(item)
+		} else {
+			_, err = s.store.CreateItem(item)
+		}
+		if err != nil {
+			return fmt.Errorf("restore catalogue item %q: %w", archived.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/catalogue"
+	"expense-tracker/domain/expense"
+)
+
+// ItemName is a way the items of active expenses are written, how many use
+// it and the catalogue item it matches, if any
+type ItemName struct {
+	Items string          `json:"items"`
+	Count int             `json:"count"`
+	Match *catalogue.Item `json:"match,omitempty"`
+}
+
+// MergeResult reports the expenses a merge rewrote to the catalogue name.
+// Skipped ones changed while the merge ran and keep their items.
+type MergeResult struct {
+	Item      catalogue.Item `json:"item"`
+	Rewritten int            `json:"rewritten"`
+	Skipped   int            `json:"skipped"`
+}
+
+// CatalogueService keeps the item catalogue and rewrites expenses to it
+type CatalogueService struct {
+	repo     catalogue.Repository
+	expenses *ExpenseService
+	auditLog *AuditService
+}
+
+func NewCatalogueService(repo catalogue.Repository, expenses *ExpenseService, auditLog *AuditService) *CatalogueService {
+	return &CatalogueService{repo: repo, expenses: expenses, auditLog: auditLog}
+}
+
+// ListItems returns the catalogue, by name
+func (s *CatalogueService) ListItems() ([]catalogue.Item, error) {
+	return s.repo.ListItems()
+}
+
+// SaveItem creates item, or replaces the one with item.ID. Its name and
+// aliases must not be another item's.
+func (s *CatalogueService) SaveItem(item catalogue.Item, actor audit.Actor) (*catalogue.Item, error) {
+	item = item.Clean()
+	if err := item.Validate(); err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListItems()
+	if err != nil {
+		return nil, err
+	}
+	if err := catalogue.CheckConflict(items, item); err != nil {
+		return nil, err
+	}
+
+	var before map[string]interface{}
+	if item.ID != "" {
+		existing, err := s.repo.GetItem(item.ID)
+		if err != nil {
+			return nil, err
+		}
+		before = itemSnapshot(*existing)
+	}
+	item.UpdatedBy = actor.Username
+	item.UpdatedAt = time.Now().UTC()
+	if item.ID == "" {
+		if item.ID, err = s.repo.CreateItem(item); err != nil {
+			return nil, err
+		}
+	} else if err := s.repo.UpdateItem(item); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[CATALOGUE] %s saved item %q", actor.Username, item.Name)
+	s.auditLog.Record(actor, audit.ActionCatalogueSave, item.ID, before, itemSnapshot(item))
+	return &item, nil
+}
+
+func (s *CatalogueService) DeleteItem(id string, actor audit.Actor) error {
+	existing, err := s.repo.GetItem(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteItem(id); err != nil {
+		return err
+	}
+	s.auditLog.Record(actor, audit.ActionCatalogueDelete, id, itemSnapshot(*existing), nil)
+	return nil
+}
+
+func itemSnapshot(item catalogue.Item) map[string]interface{} {
+	return map[string]interface{}{
+		"name":     item.Name,
+		"aliases":  item.Aliases,
+		"category": item.Category,
+		"unit":     item.Unit,
+	}
+}
+
+// ItemNames lists how the items of active expenses are written, the most
+// used first, leaving out those that are exactly a catalogue name
+func (s *CatalogueService) ItemNames() ([]ItemName, error) {
+	items, err := s.repo.ListItems()
+	if err != nil {
+		return nil, err
+	}
+	canonical := make(map[string]bool, len(items))
+	for _, item := range items {
+		canonical[item.Name] = true
+	}
+	dtos, err := s.expenses.GetAllExpenses()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, dto := range dtos {
+		if !canonical[dto.Items] {
+			counts[dto.Items]++
+		}
+	}
+	names := make([]ItemName, 0, len(counts))
+	for written, count := range counts {
+		name := ItemName{Items: written, Count: count}
+		if item, ok := catalogue.Match(items, written); ok {
+			name.Match = &item
+		}
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].Count != names[j].Count {
+			return names[i].Count > names[j].Count
+		}
+		return names[i].Items < names[j].Items
+	})
+	return names, nil
+}
+
+// Merge makes names aliases of the item with id, then rewrites the items
+// of every active expense written as one of its names or aliases to the
+// item's name. Each expense is updated on its own, so the audit log, the
+// event stream and the unit prices follow.
+func (s *CatalogueService) Merge(id string, names []string, actor audit.Actor) (*MergeResult, error) {
+	existing, err := s.repo.GetItem(id)
+	if err != nil {
+		return nil, err
+	}
+	item := *existing
+	if len(names) > 0 {
+		merged := item
+		merged.Aliases = append(append([]string{}, item.Aliases...), names...)
+		saved, err := s.SaveItem(merged, actor)
+		if err != nil {
+			return nil, err
+		}
+		item = *saved
+	}
+
+	keys := make(map[string]bool)
+	for _, key := range item.Keys() {
+		keys[key] = true
+	}
+	dtos, err := s.expenses.GetAllExpenses()
+	if err != nil {
+		return nil, err
+	}
+	result := &MergeResult{Item: item}
+	for _, dto := range dtos {
+		if dto.Items == item.Name || !keys[expense.NormalizeText(dto.Items)] {
+			continue
+		}
+		err := s.expenses.UpdateExpense(dto.ID, dto.Version, expense.Changes{Items: &item.Name}, actor)
+		switch {
+		case errors.Is(err, expense.ErrVersionConflict), errors.Is(err, expense.ErrExpenseDeleted),
+			errors.Is(err, expense.ErrExpenseNotFound):
+			log.Printf("[CATALOGUE] Expense %s changed during the merge, skipping: %v", dto.ID, err)
+			result.Skipped++
+		case err != nil:
+			return result, err
+		default:
+			result.Rewritten++
+		}
+	}
+
+	log.Printf("[CATALOGUE] %s merged %d expense(s) into %q", actor.Username, result.Rewritten, item.Name)
+	s.auditLog.Record(actor, audit.ActionCatalogueMerge, item.ID, nil, map[string]interface{}{
+		"name":      item.Name,
+		"names":     names,
+		"rewritten": result.Rewritten,
+		"skipped":   result.Skipped,
+	})
+	return result, nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/chat"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/user"
+)
+
+// ChatUserRepository is what the chat service needs to know about users
+type ChatUserRepository interface {
+	FindUser(username string) (*user.UserDTO, error)
+}
+
+// ChatService records expenses sent as chat messages by linked chat
+// accounts and replies with what was recorded
+type ChatService struct {
+	links      chat.Repository
+	users      ChatUserRepository
+	expenses   *ExpenseService
+	duplicates *DuplicateService
+	messenger  chat.Messenger
+	auditLog   *AuditService
+}
+
+func NewChatService(links chat.Repository, users ChatUserRepository, expenses *ExpenseService, duplicates *DuplicateService, messenger chat.Messenger, auditLog *AuditService) *ChatService {
+	return &ChatService{
+		links:      links,
+		users:      users,
+		expenses:   expenses,
+		duplicates: duplicates,
+		messenger:  messenger,
+		auditLog:   auditLog,
+	}
+}
+
+const chatHelp = "Gửi chi phí như khi nhập trên web, ví dụ \"3kg gạo 180k\".\n" +
+	"/unlink - hủy liên kết tài khoản chat này"
+
+const chatNotLinked = "Tài khoản chat này chưa được liên kết. Mở ứng dụng, bấm \"Liên kết Telegram\" " +
+	"rồi gửi /link <mã> tại đây."
+
+// NewLinkCode gives username a code to send to the bot
+func (s *ChatService) NewLinkCode(username string) (*chat.LinkCode, error) {
+	code, err := chat.NewLinkCode()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	linkCode := chat.LinkCode{Code: code, Username: username, CreatedAt: now, ExpiresAt: now.Add(chat.LinkCodeTTL)}
+	if err := s.links.CreateLinkCode(linkCode); err != nil {
+		return nil, err
+	}
+	return &linkCode, nil
+}
+
+func (s *ChatService) Links(username string) ([]chat.Link, error) {
+	return s.links.ListChatLinks(username)
+}
+
+func (s *ChatService) Unlink(provider, chatUserID string, actor audit.Actor) error {
+	if err := s.links.DeleteChatLink(actor.Username, provider, chatUserID); err != nil {
+		return err
+	}
+	s.auditLog.Record(actor, audit.ActionChatUnlink, provider+":"+chatUserID, nil, nil)
+	return nil
+}
+
+// HandleMessage answers one incoming message. Only failing to send the
+// reply is an error; problems with the message are explained in the reply.
+func (s *ChatService) HandleMessage(msg chat.Message) error {
+	reply := s.respond(msg)
+	if err := s.messenger.SendMessage(msg.ChatID, reply); err != nil {
+		log.Printf("[CHAT] Reply to %s chat %s failed: %v", msg.Provider, msg.ChatID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *ChatService) respond(msg chat.Message) string {
+	text := strings.TrimSpace(msg.Text)
+	if strings.HasPrefix(text, "/") {
+		return s.command(msg, text)
+	}
+
+	link, err := s.links.FindChatLink(msg.Provider, msg.SenderID)
+	if errors.Is(err, chat.ErrNotLinked) {
+		return chatNotLinked
+	}
+	if err != nil {
+		log.Printf("[CHAT] Link lookup error: %v", err)
+		return "❌ Lỗi hệ thống, hãy thử lại sau"
+	}
+	account, err := s.users.FindUser(link.Username)
+	if err != nil || account.Disabled {
+		return "❌ Tài khoản " + link.Username + " không tồn tại hoặc đã bị khóa"
+	}
+
+	actor := audit.Actor{Username: account.Username}
+	parsed, err := s.expenses.CreateExpenseFromMessageWithDetails(text, actor)
+	if err != nil {
+		log.Printf("[CHAT] %s: cannot record %q: %v", account.Username, text, err)
+		return "❌ Không ghi được chi phí: " + err.Error()
+	}
+	log.Printf("[CHAT] %s recorded expense %v from %s", account.Username, parsed["id"], msg.Provider)
+	return s.summary(parsed)
+}
+
+// command handles /start, /link, /unlink and /help. Telegram appends the
+// bot's name in groups ("/link@expense_bot CODE").
+func (s *ChatService) command(msg chat.Message, text string) string {
+	fields := strings.Fields(text)
+	name := strings.ToLower(strings.SplitN(fields[0], "@", 2)[0])
+	args := fields[1:]
+
+	switch name {
+	case "/start", "/link":
+		if len(args) == 0 {
+			if link, err := s.links.FindChatLink(msg.Provider, msg.SenderID); err == nil {
+				return "Xin chào " + link.Username + "!\n" + chatHelp
+			}
+			return chatNotLinked
+		}
+		return s.link(msg, strings.ToUpper(args[0]))
+	case "/unlink":
+		link, err := s.links.FindChatLink(msg.Provider, msg.SenderID)
+		if err != nil {
+			return chatNotLinked
+		}
+		if err := s.Unlink(msg.Provider, msg.SenderID, audit.Actor{Username: link.Username}); err != nil {
+			log.Printf("[CHAT] Unlink error: %v", err)
+			return "❌ Lỗi hệ thống, hãy thử lại sau"
+		}
+		return "Đã hủy liên kết với tài khoản " + link.Username
+	default:
+		return chatHelp
+	}
+}
+
+func (s *ChatService) link(msg chat.Message, code string) string {
+	username, err := s.links.ConsumeLinkCode(code, time.Now())
+	if errors.Is(err, chat.ErrLinkCodeInvalid) {
+		return "❌ Mã liên kết không đúng hoặc đã hết hạn. Hãy lấy mã mới trong ứng dụng."
+	}
+	if err != nil {
+		log.Printf("[CHAT] Link code error: %v", err)
+		return "❌ Lỗi hệ thống, hãy thử lại sau"
+	}
+
+	link := chat.Link{
+		Provider:   msg.Provider,
+		ChatUserID: msg.SenderID,
+		ChatName:   msg.SenderName,
+		Username:   username,
+		LinkedAt:   time.Now(),
+	}
+	if err := s.links.SaveChatLink(link); err != nil {
+		log.Printf("[CHAT] Save link error: %v", err)
+		return "❌ Lỗi hệ thống, hãy thử lại sau"
+	}
+
+	log.Printf("[CHAT] %s chat user %s linked to %s", msg.Provider, msg.SenderID, username)
+	s.auditLog.Record(audit.Actor{Username: username}, audit.ActionChatLink, msg.Provider+":"+msg.SenderID, nil,
+		map[string]interface{}{"chatName": msg.SenderName})
+	return "✅ Đã liên kết với tài khoản " + username + ".\n" + chatHelp
+}
+
+// summary describes a recorded expense, warning when it looks like one
+// already recorded
+func (s *ChatService) summary(parsed map[string]interface{}) string {
+	amount, _ := parsed["amount"].(int64)
+	var b strings.Builder
+	fmt.Fprintf(&b, "✅ Đã ghi: %s - %s VND", getStringField(parsed, "items"), formatVND(amount))
+	if label, ok := chatKindLabels[expense.Kind(getStringField(parsed, "kind"))]; ok {
+		fmt.Fprintf(&b, " (%s)", label)
+	}
+	if original := getStringField(parsed, "original"); original != "" {
+		fmt.Fprintf(&b, "\n💱 %s (tỷ giá %s)", original, getStringField(parsed, "rate"))
+	}
+	if quantity := getStringField(parsed, "quantity"); quantity != "" {
+		fmt.Fprintf(&b, "\n📦 %s %s", quantity, getStringField(parsed, "unit"))
+	}
+	fmt.Fprintf(&b, "\n📅 %s · 👤 %s", getStringField(parsed, "paidDate"), getStringField(parsed, "paidBy"))
+
+	if matches, err := s.duplicates.FindDuplicatesOf(getStringField(parsed, "id")); err == nil && len(matches) > 0 {
+		first := matches[0]
+		fmt.Fprintf(&b, "\n⚠️ Có thể trùng với \"%s\" (%s VND, %s)", first.Items, formatVND(first.Amount), first.PaidDate)
+	}
+	return b.String()
+}
+
+// chatKindLabels name the kinds other than a plain expense in replies
+var chatKindLabels = map[expense.Kind]string{
+	expense.KindRefund:   "hoàn tiền",
+	expense.KindIncome:   "thu nhập",
+	expense.KindTransfer: "chuyển tiền",
+}
+
+// formatVND groups thousands with dots, as written in Vietnam: 180.000
+func formatVND(amount int64) string {
+	digits := strconv.FormatInt(amount, 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"expense-tracker/domain/expense"
+	"expense-tracker/infrastructure/memory"
+	"expense-tracker/infrastructure/telegram"
+)
+
+const testBotToken = "test-token"
+
+// fakeBotAPI stands in for the Telegram Bot API and keeps the messages the
+// bot sends. Once failing is set it refuses them as Telegram does.
+type fakeBotAPI struct {
+	*httptest.Server
+	mu      sync.Mutex
+	sent    []sentChatMessage
+	failing bool
+}
+
+type sentChatMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	api := &fakeBotAPI{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bot"+testBotToken+"/sendMessage", func(w http.ResponseWriter, r *http.Request) {
+		var msg sentChatMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "description": "Bad Request: " + err.Error()})
+			return
+		}
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		if api.failing {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "description": "Forbidden: bot was blocked by the user"})
+			return
+		}
+		api.sent = append(api.sent, msg)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": map[string]interface{}{"message_id": len(api.sent)}})
+	})
+	api.Server = httptest.NewServer(mux)
+	t.Cleanup(api.Close)
+	return api
+}
+
+// replies returns the messages sent since the last call
+func (api *fakeBotAPI) replies() []sentChatMessage {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	sent := api.sent
+	api.sent = nil
+	return sent
+}
+
+// cannedMessageParser parses the messages it knows the way Gemini would
+type cannedMessageParser map[string]cannedMessage
+
+type cannedMessage struct {
+	items          string
+	amount         int64
+	quantity, unit string
+}
+
+var testPaidDate = time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+func (p cannedMessageParser) Parse(message string) (string, int64, string, string, string, string, string, time.Time, error) {
+	parsed, ok := p[message]
+	if !ok {
+		return "", 0, "", "", "", "", "", time.Time{}, errors.New("cannot parse " + message)
+	}
+	return parsed.items, parsed.amount, parsed.quantity, parsed.unit, "", "", message, testPaidDate, nil
+}
+
+// newTestExpenseService records expenses in store, parsing messages with
+// parser, in VND
+func newTestExpenseService(store *memory.Repository, parser expense.MessageParser) *ExpenseService {
+	auditLog := NewAuditService(store)
+	return NewExpenseService(store, parser, auditLog, nil, NewExchangeService(store, expense.VND, auditLog), store)
+}
+
+type chatTest struct {
+	service *ChatService
+	store   *memory.Repository
+	api     *fakeBotAPI
+}
+
+func newChatTest(t *testing.T) *chatTest {
+	store := newTestStore(t)
+	if err := store.CreateUser("linh", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	expenses := newTestExpenseService(store, cannedMessageParser{
+		"3kg gạo 180k": {items: "Gạo", amount: 180000, quantity: "3", unit: "kg"},
+	})
+	auditLog := NewAuditService(store)
+	api := newFakeBotAPI(t)
+	service := NewChatService(store, store, expenses, NewDuplicateService(expenses, store, auditLog),
+		telegram.NewClient(api.URL, testBotToken), auditLog)
+	return &chatTest{service: service, store: store, api: api}
+}
+
+// send posts text as the Telegram user 1001 and returns the bot's replies
+func (c *chatTest) send(t *testing.T, text string) []string {
+	t.Helper()
+	update := telegram.Update{
+		UpdateID: 1,
+		Message: &telegram.Message{
+			MessageID: 1,
+			From:      &telegram.User{ID: 1001, FirstName: "Linh", Username: "linh_t"},
+			Chat:      telegram.Chat{ID: 1001, Type: "private"},
+			Date:      time.Now().Unix(),
+			Text:      text,
+		},
+	}
+	msg, ok := update.ChatMessage()
+	if !ok {
+		t.Fatalf("update with %q carries no chat message", text)
+	}
+	if err := c.service.HandleMessage(msg); err != nil {
+		t.Fatalf("HandleMessage(%q): %v", text, err)
+	}
+	var texts []string
+	for _, reply := range c.api.replies() {
+		if reply.ChatID != "1001" {
+			t.Errorf("reply to %q went to chat %s, want 1001", text, reply.ChatID)
+		}
+		texts = append(texts, reply.Text)
+	}
+	return texts
+}
+
+func TestChatConversation(t *testing.T) {
+	c := newChatTest(t)
+
+	expectReply := func(text, want string) {
+		t.Helper()
+		replies := c.send(t, text)
+		if len(replies) != 1 || replies[0] != want {
+			t.Errorf("replies to %q = %q, want [%q]", text, replies, want)
+		}
+	}
+
+	expectReply("/start", chatNotLinked)
+	expectReply("3kg gạo 180k", chatNotLinked)
+	expectReply("/link WRONG123", "❌ Mã liên kết không đúng hoặc đã hết hạn. Hãy lấy mã mới trong ứng dụng.")
+
+	code, err := c.service.NewLinkCode("linh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectReply("/link@expense_bot "+strings.ToLower(code.Code), "✅ Đã liên kết với tài khoản linh.\n"+chatHelp)
+	expectReply("/link "+code.Code, "❌ Mã liên kết không đúng hoặc đã hết hạn. Hãy lấy mã mới trong ứng dụng.")
+	expectReply("/start", "Xin chào linh!\n"+chatHelp)
+
+	expectReply("3kg gạo 180k", "✅ Đã ghi: Gạo - 180.000 VND\n📦 3 kg\n📅 2024-03-15 · 👤 linh")
+	expectReply("3kg gạo 180k", "✅ Đã ghi: Gạo - 180.000 VND\n📦 3 kg\n📅 2024-03-15 · 👤 linh"+
+		"\n⚠️ Có thể trùng với \"Gạo\" (180.000 VND, 2024-03-15)")
+	expectReply("mua gì đó", "❌ Không ghi được chi phí: cannot parse mua gì đó")
+
+	expenses, err := c.store.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expenses) != 2 {
+		t.Fatalf("recorded %d expenses, want 2", len(expenses))
+	}
+	for _, exp := range expenses {
+		if exp["paidBy"] != "linh" || exp["items"] != "Gạo" || exp["amount"] != int64(180000) {
+			t.Errorf("recorded %v, want Gạo 180000 paid by linh", exp)
+		}
+	}
+
+	expectReply("/unlink", "Đã hủy liên kết với tài khoản linh")
+	expectReply("3kg gạo 180k", chatNotLinked)
+}
+
+func TestChatReplyRefused(t *testing.T) {
+	c := newChatTest(t)
+	c.api.failing = true
+
+	msg, _ := telegram.Update{Message: &telegram.Message{
+		From: &telegram.User{ID: 1001, FirstName: "Linh"},
+		Chat: telegram.Chat{ID: 1001, Type: "private"},
+		Text: "/help",
+	}}.ChatMessage()
+	err := c.service.HandleMessage(msg)
+	if err == nil || !strings.Contains(err.Error(), "bot was blocked") {
+		t.Errorf("HandleMessage with the reply refused = %v, want the Bot API's error", err)
+	}
+	if strings.Contains(err.Error(), testBotToken) {
+		t.Errorf("error %q shows the bot token", err)
+	}
+}
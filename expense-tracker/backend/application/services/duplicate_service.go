@@ -0,0 +1,174 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/expense"
+)
+
+// DuplicateMatch is an active expense that looks like the same purchase as
+// the one being checked
+type DuplicateMatch struct {
+	expense.ExpenseDTO
+	Reasons []string `json:"reasons"`
+}
+
+// DuplicatePair is two active expenses that look like the same purchase
+type DuplicatePair struct {
+	Key     string             `json:"key"`
+	First   expense.ExpenseDTO `json:"first"`
+	Second  expense.ExpenseDTO `json:"second"`
+	Reasons []string           `json:"reasons"`
+}
+
+// DuplicateService finds expenses that were probably logged twice, merges
+// them or remembers that they are not duplicates
+type DuplicateService struct {
+	expenses   *ExpenseService
+	dismissals expense.DismissalRepository
+	auditLog   *AuditService
+}
+
+func NewDuplicateService(expenses *ExpenseService, dismissals expense.DismissalRepository, auditLog *AuditService) *DuplicateService {
+	return &DuplicateService{expenses: expenses, dismissals: dismissals, auditLog: auditLog}
+}
+
+// activeCandidates returns the active expenses with what detection compares,
+// sorted by paid date, and the dismissed pair keys
+func (s *DuplicateService) activeCandidates() ([]expense.ExpenseDTO, []expense.DuplicateCandidate, map[string]bool, error) {
+	dtos, err := s.expenses.GetAllExpenses()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sort.SliceStable(dtos, func(i, j int) bool { return dtos[i].PaidDate < dtos[j].PaidDate })
+
+	candidates := make([]expense.DuplicateCandidate, len(dtos))
+	for i, dto := range dtos {
+		paidDate, _ := time.Parse("2006-01-02", dto.PaidDate)
+		candidates[i] = expense.DuplicateCandidate{
+			ID:              dto.ID,
+			Items:           dto.Items,
+			Amount:          dto.Amount,
+			PaidDate:        paidDate,
+			OriginalMessage: dto.OriginalMessage,
+		}
+	}
+
+	keys, err := s.dismissals.ListDismissedDuplicates()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	dismissed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		dismissed[key] = true
+	}
+	return dtos, candidates, dismissed, nil
+}
+
+// FindDuplicatesOf returns the active expenses that look like the same
+// purchase as the expense with id, leaving out dismissed pairs
+func (s *DuplicateService) FindDuplicatesOf(id string) ([]DuplicateMatch, error) {
+	dtos, candidates, dismissed, err := s.activeCandidates()
+	if err != nil {
+		return nil, err
+	}
+
+	target := -1
+	for i, candidate := range candidates {
+		if candidate.ID == id {
+			target = i
+			break
+		}
+	}
+	if target < 0 {
+		return nil, nil
+	}
+
+	var matches []DuplicateMatch
+	for i, candidate := range candidates {
+		if dismissed[expense.DuplicatePairKey(id, candidate.ID)] {
+			continue
+		}
+		if reasons := expense.DuplicateReasons(candidates[target], candidate); len(reasons) > 0 {
+			matches = append(matches, DuplicateMatch{ExpenseDTO: dtos[i], Reasons: reasons})
+		}
+	}
+	return matches, nil
+}
+
+// FindDuplicatePairs lists every pair of active expenses that look like the
+// same purchase and have not been dismissed, most recent first
+func (s *DuplicateService) FindDuplicatePairs() ([]DuplicatePair, error) {
+	dtos, candidates, dismissed, err := s.activeCandidates()
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := []DuplicatePair{}
+	for i := len(candidates) - 1; i >= 0; i-- {
+		// Sorted by paid date, so only the earlier expenses within the
+		// window need comparing
+		for j := i - 1; j >= 0; j-- {
+			if candidates[i].PaidDate.Sub(candidates[j].PaidDate) > expense.DuplicateWindow {
+				break
+			}
+			key := expense.DuplicatePairKey(candidates[i].ID, candidates[j].ID)
+			if dismissed[key] {
+				continue
+			}
+			if reasons := expense.DuplicateReasons(candidates[i], candidates[j]); len(reasons) > 0 {
+				pairs = append(pairs, DuplicatePair{Key: key, First: dtos[i], Second: dtos[j], Reasons: reasons})
+			}
+		}
+	}
+	return pairs, nil
+}
+
+// Merge keeps one expense and moves its duplicate to the trash, where it
+// can still be restored. Both versions must be the ones the caller saw.
+func (s *DuplicateService) Merge(keepID string, keepVersion int64, dropID string, dropVersion int64, actor audit.Actor) error {
+	kept, err := s.expenses.GetExpense(keepID)
+	if err != nil {
+		return err
+	}
+	if kept["status"] == string(expense.StatusDeleted) {
+		return expense.ErrExpenseDeleted
+	}
+	if getVersionField(kept) != keepVersion {
+		return expense.ErrVersionConflict
+	}
+
+	if err := s.expenses.DeleteExpense(dropID, dropVersion, actor); err != nil {
+		return err
+	}
+
+	log.Printf("[SERVICE] %s merged expense %s into %s", actor.Username, dropID, keepID)
+	s.auditLog.Record(actor, audit.ActionExpenseMerge, keepID, nil, map[string]interface{}{
+		"kept":   keepID,
+		"merged": dropID,
+	})
+	return nil
+}
+
+// Current returns the stored state of an expense, for answering a conflict
+func (s *DuplicateService) Current(id string) (map[string]interface{}, error) {
+	return s.expenses.GetExpense(id)
+}
+
+// Dismiss records that two expenses are separate purchases, so that they
+// are no longer reported as duplicates of each other
+func (s *DuplicateService) Dismiss(firstID, secondID string, actor audit.Actor) error {
+	key := expense.DuplicatePairKey(firstID, secondID)
+	if err := s.dismissals.DismissDuplicate(key, actor.Username); err != nil {
+		return err
+	}
+
+	s.auditLog.Record(actor, audit.ActionDuplicateDismiss, key, nil, map[string]interface{}{
+		"first":  firstID,
+		"second": secondID,
+	})
+	return nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"io"
+	"log"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/expense"
+)
+
+// ExchangeService keeps the rates that convert foreign currency expenses into
+// the base currency, which every amount and summary is reported in
+type ExchangeService struct {
+	repo     exchange.Repository
+	base     expense.Currency
+	auditLog *AuditService
+}
+
+func NewExchangeService(repo exchange.Repository, base expense.Currency, auditLog *AuditService) *ExchangeService {
+	return &ExchangeService{repo: repo, base: base, auditLog: auditLog}
+}
+
+// Base is the currency amounts are converted into
+func (s *ExchangeService) Base() expense.Currency {
+	return s.base
+}
+
+// ListRates returns every rate, ordered by currency then date
+func (s *ExchangeService) ListRates() ([]exchange.Rate, error) {
+	return s.repo.ListRates()
+}
+
+// SaveRate stores rate, replacing the one of the same currency and day.
+// Expenses already recorded keep the rate they were converted at.
+func (s *ExchangeService) SaveRate(rate exchange.Rate, actor audit.Actor) error {
+	if err := s.save(&rate, actor); err != nil {
+		return err
+	}
+	log.Printf("[EXCHANGE] %s set %s on %s to %s %s", actor.Username, rate.Currency, rate.Date.Format(exchange.DateLayout), rate.Rate, s.base)
+	s.auditLog.Record(actor, audit.ActionRateSave, rateTarget(rate.Currency, rate.Date), nil, rateAuditData(rate))
+	return nil
+}
+
+func (s *ExchangeService) save(rate *exchange.Rate, actor audit.Actor) error {
+	rate.Date = exchange.Day(rate.Date)
+	if err := rate.Validate(); err != nil {
+		return err
+	}
+	if rate.Currency == s.base {
+		return exchange.ErrBaseCurrency
+	}
+	rate.UpdatedBy = actor.Username
+	rate.UpdatedAt = time.Now()
+	return s.repo.SaveRate(*rate)
+}
+
+// DeleteRate removes the rate of currency on date; exchange.ErrNotFound
+// means there is none
+func (s *ExchangeService) DeleteRate(currency expense.Currency, date time.Time, actor audit.Actor) error {
+	if err := s.repo.DeleteRate(currency, exchange.Day(date)); err != nil {
+		return err
+	}
+	log.Printf("[EXCHANGE] %s deleted %s on %s", actor.Username, currency, date.Format(exchange.DateLayout))
+	s.auditLog.Record(actor, audit.ActionRateDelete, rateTarget(currency, date), nil, nil)
+	return nil
+}
+
+// ImportRates saves every rate of a "currency,date,rate" CSV file and returns
+// how many there were. Nothing is saved unless the whole file is valid.
+func (s *ExchangeService) ImportRates(r io.Reader, actor audit.Actor) (int, error) {
+	rates, err := exchange.ParseCSV(r)
+	if err != nil {
+		return 0, err
+	}
+	for _, rate := range rates {
+		if rate.Currency == s.base {
+			return 0, exchange.ErrBaseCurrency
+		}
+	}
+	for i := range rates {
+		if err := s.save(&rates[i], actor); err != nil {
+			return i, err
+		}
+	}
+	log.Printf("[EXCHANGE] %s imported %d rate(s)", actor.Username, len(rates))
+	s.auditLog.Record(actor, audit.ActionRateImport, "", nil, map[string]interface{}{"count": len(rates)})
+	return len(rates), nil
+}
+
+// Convert turns amount, in minor units of currency, into the base currency
+// at the rate in force on date, and returns the rate it used. An amount
+// already in the base currency is returned as it is, with no rate.
+// exchange.ErrNoRate means the currency has no rate yet.
+func (s *ExchangeService) Convert(amount int64, currency expense.Currency, date time.Time) (int64, string, error) {
+	if currency == "" || currency == s.base {
+		return amount, "", nil
+	}
+	rates, err := s.repo.ListRates()
+	if err != nil {
+		return 0, "", err
+	}
+	rate, err := exchange.Find(rates, currency, date)
+	if err != nil {
+		return 0, "", err
+	}
+	converted, err := exchange.Convert(amount, currency, s.base, rate.Rate)
+	if err != nil {
+		return 0, "", err
+	}
+	return converted, rate.Rate, nil
+}
+
+func rateTarget(currency expense.Currency, date time.Time) string {
+	return string(currency) + "/" + date.Format(exchange.DateLayout)
+}
+
+func rateAuditData(rate exchange.Rate) map[string]interface{} {
+	return map[string]interface{}{
+		"currency": string(rate.Currency),
+		"date":     rate.Date.Format(exchange.DateLayout),
+		"rate":     rate.Rate,
+	}
+}
@@ -2,9 +2,15 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/catalogue"
 	"expense-tracker/domain/expense"
 	"expense-tracker/domain/user"
 )
@@ -12,38 +18,170 @@ import (
 type ExpenseService struct {
 	expenseRepo expense.Repository
 	parser      expense.MessageParser
+	auditLog    *AuditService
+	events      expense.EventPublisher
+	exchange    *ExchangeService
+	catalogue   catalogue.Repository
 }
 
-func NewExpenseService(repo expense.Repository, parser expense.MessageParser) *ExpenseService {
+// NewExpenseService raises an event for every create, update, delete and
+// restore on events, after the change is stored; nil raises none. Amounts
+// paid in another currency are converted with the rates of exchange, and
+// the items of a new expense are recorded under their name in catalogue.
+func NewExpenseService(repo expense.Repository, parser expense.MessageParser, auditLog *AuditService, events expense.EventPublisher, exchange *ExchangeService, catalogue catalogue.Repository) *ExpenseService {
 	return &ExpenseService{
 		expenseRepo: repo,
 		parser:      parser,
+		auditLog:    auditLog,
+		events:      events,
+		exchange:    exchange,
+		catalogue:   catalogue,
 	}
 }
 
+// publish sends an event with the expense as it is after the change
+func (s *ExpenseService) publish(eventType expense.EventType, actor audit.Actor, after map[string]interface{}) {
+	if s.events == nil || after == nil {
+		return
+	}
+	s.fillCurrency(after)
+	id, _ := after["id"].(string)
+	paidBy, _ := after["paidBy"].(string)
+	s.events.Publish(expense.Event{
+		Type:      eventType,
+		ExpenseID: id,
+		PaidBy:    paidBy,
+		Actor:     actor.Username,
+		Expense:   after,
+		Time:      time.Now(),
+	})
+}
+
 func (s *ExpenseService) CreateExpenseFromMessage(message, userName string) error {
-	_, err := s.CreateExpenseFromMessageWithDetails(message, userName)
+	_, err := s.CreateExpenseFromMessageWithDetails(message, audit.Actor{Username: userName})
 	return err
 }
 
-func (s *ExpenseService) CreateExpenseFromMessageWithDetails(message, userName string) (map[string]interface{}, error) {
-	user, err := user.NewUser(userName)
-	if err != nil {
+// ExpenseInput is an expense read from a message or a receipt, before it
+// is recorded. Amount may have either sign; it gets the one Kind requires.
+// An empty Kind is an expense. Amount is in minor units of Currency, and an
+// empty Currency is the base currency. Tags are cleaned as
+// expense.CleanTags does.
+type ExpenseInput struct {
+	Items           string
+	Amount          int64
+	Currency        expense.Currency
+	Kind            expense.Kind
+	PaidTo          string
+	Quantity        string
+	Unit            string
+	BaseQuantity    string
+	BaseUnit        string
+	OriginalMessage string
+	PaidDate        time.Time
+	Tags            []string
+	Notes           string
+}
+
+// CreateExpenseFromMessageWithDetails parses message and records the expense
+// as paid by actor.Username. Its kind comes from the wording: "hoàn tiền" or
+// "được trả lại" make it a refund. Its currency does too: "$12.50" or
+// "300 baht" are converted into the base currency. Hashtags become tags:
+// "#tet 500k bánh chưng" is "500k bánh chưng" tagged "tet".
+func (s *ExpenseService) CreateExpenseFromMessageWithDetails(message string, actor audit.Actor) (map[string]interface{}, error) {
+	return s.CreateTransactionFromMessage(message, expense.DetectKind(message), "", nil, "", actor)
+}
+
+// CreateTransactionFromMessage parses message and records it with the kind
+// given, and the recipient when it is a transfer. tags are added to the
+// hashtags of the message.
+func (s *ExpenseService) CreateTransactionFromMessage(message string, kind expense.Kind, paidTo string, tags []string, notes string, actor audit.Actor) (map[string]interface{}, error) {
+	if _, err := user.NewUser(actor.Username); err != nil {
 		return nil, err
 	}
 
-	items, amount, quantity, unit, baseQuantity, baseUnit, originalMessage, paidDate, err := s.parser.Parse(message)
+	// The parser reads the message without its hashtags, which are not part
+	// of the items
+	text, hashtags := expense.ExtractTags(message)
+	items, amount, quantity, unit, baseQuantity, baseUnit, originalMessage, paidDate, err := s.parser.Parse(text)
 	if err != nil {
 		return nil, err
 	}
+	if len(hashtags) > 0 {
+		originalMessage = message
+	}
 
 	log.Printf("[SERVICE] Parsed from AI: items=%s, quantity=%s, unit=%s, baseQuantity=%s, baseUnit=%s", 
 		items, quantity, unit, baseQuantity, baseUnit)
 
-	exp := expense.NewExpenseWithDate(items, amount, user.Name(), paidDate)
-	exp.SetQuantityUnit(quantity, unit)
-	exp.SetBaseQuantityUnit(baseQuantity, baseUnit)
-	exp.SetOriginalMessage(originalMessage)
+	currency, _ := expense.DetectCurrency(message)
+	return s.CreateExpense(ExpenseInput{
+		Items:           items,
+		Amount:          amount,
+		Currency:        currency,
+		Kind:            kind,
+		PaidTo:          paidTo,
+		Quantity:        quantity,
+		Unit:            unit,
+		BaseQuantity:    baseQuantity,
+		BaseUnit:        baseUnit,
+		OriginalMessage: originalMessage,
+		PaidDate:        paidDate,
+		Tags:            expense.MergeTags(hashtags, tags),
+		Notes:           notes,
+	}, actor)
+}
+
+// CreateExpense records an expense that has already been parsed, as paid by
+// actor.Username, and returns it as CreateExpenseFromMessageWithDetails does
+func (s *ExpenseService) CreateExpense(input ExpenseInput, actor audit.Actor) (map[string]interface{}, error) {
+	user, err := user.NewUser(actor.Username)
+	if err != nil {
+		return nil, err
+	}
+	if input.Items == "" {
+		return nil, errors.New("items cannot be empty")
+	}
+	input, item := s.matchCatalogue(input)
+	kind, err := expense.ParseKind(string(input.Kind))
+	if err != nil {
+		return nil, err
+	}
+	currency := input.Currency
+	if currency == "" {
+		currency = s.exchange.Base()
+	}
+	original := kind.Signed(input.Amount)
+	amount, rate, err := s.exchange.Convert(original, currency, input.PaidDate)
+	if err != nil {
+		return nil, err
+	}
+	paidTo := strings.TrimSpace(input.PaidTo)
+	if err := expense.ValidateTransaction(kind, amount, user.Name(), paidTo); err != nil {
+		return nil, err
+	}
+	tags, err := expense.CleanTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+	notes := strings.TrimSpace(input.Notes)
+	if err := expense.ValidateNotes(notes); err != nil {
+		return nil, err
+	}
+
+	exp := expense.NewExpenseWithDate(input.Items, amount, user.Name(), input.PaidDate)
+	exp.SetOriginal(expense.NewMoneyIn(original, currency), rate)
+	exp.SetKind(kind, paidTo)
+	// The parser's quantity is only a reading of the message: one that does
+	// not make sense is dropped rather than failing the whole expense
+	quantity, base, err := expense.MeasureQuantity(input.Quantity, input.Unit, input.BaseQuantity, input.BaseUnit)
+	if err != nil {
+		log.Printf("[SERVICE] Dropping quantity %q %q: %v", input.Quantity, input.Unit, err)
+	}
+	exp.SetMeasure(quantity, base)
+	exp.SetOriginalMessage(input.OriginalMessage)
+	exp.SetTags(tags)
+	exp.SetNotes(notes)
 	
 	log.Printf("[SERVICE] Expense before save: Items=%s, Quantity=%s, Unit=%s, BaseQuantity=%s, BaseUnit=%s", 
 		exp.Items(), exp.Quantity(), exp.Unit(), exp.BaseQuantity(), exp.BaseUnit())
@@ -54,19 +192,72 @@ func (s *ExpenseService) CreateExpenseFromMessageWithDetails(message, userName s
 
 	// Return parsed data
 	parsedData := map[string]interface{}{
-		"items":        items,
-		"amount":       amount,
-		"quantity":     quantity,
-		"unit":         unit,
-		"baseQuantity": baseQuantity,
-		"baseUnit":     baseUnit,
-		"paidDate":     paidDate.Format("2006-01-02"),
-		"paidBy":       user.Name(),
+		"id":             exp.ID(),
+		"items":          input.Items,
+		"amount":         amount,
+		"currency":       string(currency),
+		"originalAmount": original,
+		"kind":           string(kind),
+		"quantity":       exp.Quantity(),
+		"unit":           exp.Unit(),
+		"baseQuantity":   exp.BaseQuantity(),
+		"baseUnit":       exp.BaseUnit(),
+		"paidDate":       input.PaidDate.Format("2006-01-02"),
+		"paidBy":         user.Name(),
+	}
+	if paidTo != "" {
+		parsedData["paidTo"] = paidTo
+	}
+	if len(tags) > 0 {
+		parsedData["tags"] = tags
+	}
+	if notes != "" {
+		parsedData["notes"] = notes
+	}
+	if item != nil && item.Category != "" {
+		parsedData["category"] = item.Category
+	}
+	if rate != "" {
+		parsedData["rate"] = rate
+		parsedData["original"] = exp.Original().String()
+	}
+
+	s.auditLog.Record(actor, audit.ActionExpenseCreate, exp.ID(), nil, parsedData)
+	if created, err := s.expenseRepo.GetByID(exp.ID()); err == nil {
+		s.publish(expense.ExpenseCreated, actor, created)
 	}
 
 	return parsedData, nil
 }
 
+// matchCatalogue records the items of input under the name of the
+// catalogue item they match, if any, and gives a quantity read without a
+// unit the item's default unit. The catalogue failing only leaves the items
+// as they were read.
+func (s *ExpenseService) matchCatalogue(input ExpenseInput) (ExpenseInput, *catalogue.Item) {
+	if s.catalogue == nil {
+		return input, nil
+	}
+	items, err := s.catalogue.ListItems()
+	if err != nil {
+		log.Printf("[SERVICE] Catalogue unavailable, keeping items %q: %v", input.Items, err)
+		return input, nil
+	}
+	item, ok := catalogue.Match(items, input.Items)
+	if !ok {
+		return input, nil
+	}
+
+	if item.Name != input.Items {
+		log.Printf("[SERVICE] Items %q matched catalogue item %q", input.Items, item.Name)
+	}
+	input.Items = item.Name
+	if strings.TrimSpace(input.Unit) == "" && strings.TrimSpace(input.Quantity) != "" {
+		input.Unit = item.Unit
+	}
+	return input, &item
+}
+
 func (s *ExpenseService) GetAllExpenses() ([]expense.ExpenseDTO, error) {
 	expenses, err := s.expenseRepo.GetAll()
 	if err != nil {
@@ -75,18 +266,8 @@ func (s *ExpenseService) GetAllExpenses() ([]expense.ExpenseDTO, error) {
 
 	var dtos []expense.ExpenseDTO
 	for _, exp := range expenses {
-		dto := expense.ExpenseDTO{
-			ID:              exp["no"].(string),
-			Items:           exp["items"].(string),
-			Amount:          exp["amount"].(int64),
-			Quantity:        getStringField(exp, "quantity"),
-			Unit:            getStringField(exp, "unit"),
-			BaseQuantity:    getStringField(exp, "baseQuantity"),
-			BaseUnit:        getStringField(exp, "baseUnit"),
-			OriginalMessage: getStringField(exp, "originalMessage"),
-			PaidDate:        exp["paidDate"].(string),
-			PaidBy:          exp["paidBy"].(string),
-		}
+		s.fillCurrency(exp)
+		dto := expenseDTOFromMap(exp, "no")
 		log.Printf("[SERVICE] DTO: ID=%s, Items=%s, Quantity=%s, Unit=%s", dto.ID, dto.Items, dto.Quantity, dto.Unit)
 		dtos = append(dtos, dto)
 	}
@@ -94,6 +275,37 @@ func (s *ExpenseService) GetAllExpenses() ([]expense.ExpenseDTO, error) {
 	return dtos, nil
 }
 
+// GetExpensesByTags returns the active expenses carrying every one of tags,
+// compared by expense.TagKey
+func (s *ExpenseService) GetExpensesByTags(tags []string) ([]expense.ExpenseDTO, error) {
+	dtos, err := s.GetAllExpenses()
+	if err != nil {
+		return nil, err
+	}
+	tagged := []expense.ExpenseDTO{}
+	for _, dto := range dtos {
+		if expense.HasTags(dto.Tags, tags) {
+			tagged = append(tagged, dto)
+		}
+	}
+	return tagged, nil
+}
+
+// GetTagReport totals the active expenses of each tag in the base currency
+func (s *ExpenseService) GetTagReport() ([]expense.TagTotal, error) {
+	dtos, err := s.GetAllExpenses()
+	if err != nil {
+		return nil, err
+	}
+	return expense.SummarizeTags(dtos), nil
+}
+
+// BaseCurrency is the currency amounts and summaries are in
+func (s *ExpenseService) BaseCurrency() expense.Currency {
+	return s.exchange.Base()
+}
+
+// GetExpenseSummary totals each member in the base currency
 func (s *ExpenseService) GetExpenseSummary() (map[string]int64, error) {
 	return s.expenseRepo.GetSummaryByPaidBy()
 }
@@ -102,8 +314,256 @@ func (s *ExpenseService) GetDeletedExpenses() ([]map[string]interface{}, error)
 	return s.expenseRepo.GetDeleted()
 }
 
-func (s *ExpenseService) DeleteExpense(id string) error {
-	return s.expenseRepo.Delete(id)
+// GetExpense returns the stored state of one expense, deleted or not
+func (s *ExpenseService) GetExpense(id string) (map[string]interface{}, error) {
+	data, err := s.expenseRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.fillCurrency(data)
+	return data, nil
+}
+
+// fillCurrency names the base currency on an expense stored before
+// currencies existed, which backends return with an empty one
+func (s *ExpenseService) fillCurrency(data map[string]interface{}) {
+	if getStringField(data, "currency") == "" {
+		data["currency"] = string(s.exchange.Base())
+	}
+}
+
+// UpdateExpense applies changes to an active expense the caller last saw at
+// version; expense.ErrVersionConflict means someone changed it since
+func (s *ExpenseService) UpdateExpense(id string, version int64, changes expense.Changes, actor audit.Actor) error {
+	if err := changes.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkTransaction(id, &changes); err != nil {
+		return err
+	}
+	if err := s.checkQuantity(id, &changes); err != nil {
+		return err
+	}
+	if changes.Tags != nil {
+		tags, err := expense.CleanTags(*changes.Tags)
+		if err != nil {
+			return err
+		}
+		changes.Tags = &tags
+	}
+	if changes.Notes != nil {
+		notes := strings.TrimSpace(*changes.Notes)
+		changes.Notes = &notes
+	}
+
+	return s.changeExpense(id, audit.ActionExpenseUpdate, expense.ExpenseUpdated, actor, func() error {
+		return s.expenseRepo.Update(id, version, changes)
+	})
+}
+
+// checkTransaction applies the sign rules to changes that touch the kind,
+// amount, currency, date, payer or recipient of the expense with id: the
+// amount gets the sign of the kind the expense ends up with, and the
+// recipient is dropped when it stops being a transfer. The amount sent is in
+// the expense's currency; when it, the currency or the date changes, it is
+// converted again at the rate in force on the date.
+func (s *ExpenseService) checkTransaction(id string, changes *expense.Changes) error {
+	changes.OriginalAmount, changes.Rate = nil, nil
+	if changes.Kind == nil && changes.Amount == nil && changes.PaidBy == nil && changes.PaidTo == nil &&
+		changes.Currency == nil && changes.PaidDate == nil {
+		return nil
+	}
+	current, err := s.expenseRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	kind := expense.KindOf(getStringField(current, "kind"))
+	if changes.Kind != nil {
+		kind = *changes.Kind
+	}
+	amount, _ := current["amount"].(int64)
+	original, _ := current["originalAmount"].(int64)
+	amount, original = kind.Signed(amount), kind.Signed(original)
+	if changes.Amount != nil || changes.Currency != nil || changes.PaidDate != nil {
+		currency := expense.Currency(getStringField(current, "currency"))
+		if currency == "" {
+			currency = s.exchange.Base()
+		}
+		if changes.Currency != nil {
+			if currency, err = expense.ParseCurrency(string(*changes.Currency)); err != nil {
+				return err
+			}
+		}
+		if changes.Amount != nil {
+			original = kind.Signed(*changes.Amount)
+		}
+		paidDate, _ := time.Parse("2006-01-02", getStringField(current, "paidDate"))
+		if changes.PaidDate != nil {
+			paidDate = *changes.PaidDate
+		}
+		var rate string
+		if amount, rate, err = s.exchange.Convert(original, currency, paidDate); err != nil {
+			return err
+		}
+		changes.Currency, changes.OriginalAmount, changes.Rate = &currency, &original, &rate
+	} else if changes.Kind != nil {
+		changes.OriginalAmount = &original
+	}
+	paidBy := getStringField(current, "paidBy")
+	if changes.PaidBy != nil {
+		paidBy = *changes.PaidBy
+	}
+	paidTo := getStringField(current, "paidTo")
+	if changes.PaidTo != nil {
+		paidTo = strings.TrimSpace(*changes.PaidTo)
+	}
+	if kind != expense.KindTransfer && changes.PaidTo == nil {
+		paidTo = ""
+	}
+
+	if err := expense.ValidateTransaction(kind, amount, paidBy, paidTo); err != nil {
+		return err
+	}
+	changes.Amount = &amount
+	changes.PaidTo = &paidTo
+	return nil
+}
+
+// checkQuantity measures the quantity of the expense with id as changes
+// leave it, and sets all four quantity fields to the result: the base
+// quantity is always computed from the unit, except for a pack whose
+// content was given. A changed quantity or unit drops the stored base.
+func (s *ExpenseService) checkQuantity(id string, changes *expense.Changes) error {
+	if changes.Quantity == nil && changes.Unit == nil && changes.BaseQuantity == nil && changes.BaseUnit == nil {
+		return nil
+	}
+	current, err := s.expenseRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	pick := func(change *string, field string) string {
+		if change != nil {
+			return *change
+		}
+		return getStringField(current, field)
+	}
+	quantity, unit := pick(changes.Quantity, "quantity"), pick(changes.Unit, "unit")
+	var baseQuantity, baseUnit string
+	if changes.BaseQuantity != nil || changes.BaseUnit != nil || (changes.Quantity == nil && changes.Unit == nil) {
+		baseQuantity, baseUnit = pick(changes.BaseQuantity, "baseQuantity"), pick(changes.BaseUnit, "baseUnit")
+	}
+
+	q, base, err := expense.MeasureQuantity(quantity, unit, baseQuantity, baseUnit)
+	if err != nil {
+		return err
+	}
+	quantity, unit, baseQuantity, baseUnit = q.Value(), q.Unit(), base.Value(), base.Unit()
+	changes.Quantity, changes.Unit = &quantity, &unit
+	changes.BaseQuantity, changes.BaseUnit = &baseQuantity, &baseUnit
+	return nil
+}
+
+// DeleteExpense moves an expense the caller last saw at version to the trash
+func (s *ExpenseService) DeleteExpense(id string, version int64, actor audit.Actor) error {
+	return s.changeExpense(id, audit.ActionExpenseDelete, expense.ExpenseDeleted, actor, func() error {
+		return s.expenseRepo.Delete(id, version)
+	})
+}
+
+// RestoreExpense takes an expense the caller last saw at version out of the
+// trash
+func (s *ExpenseService) RestoreExpense(id string, version int64, actor audit.Actor) error {
+	return s.changeExpense(id, audit.ActionExpenseRestore, expense.ExpenseRestored, actor, func() error {
+		return s.expenseRepo.Restore(id, version)
+	})
+}
+
+// changeExpense runs change, records the expense before and after it in the
+// audit log and publishes eventType
+func (s *ExpenseService) changeExpense(id, action string, eventType expense.EventType, actor audit.Actor, change func() error) error {
+	before, err := s.expenseRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	after, err := s.expenseRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	s.auditLog.Record(actor, action, id, before, after)
+	s.publish(eventType, actor, after)
+	return nil
+}
+
+// PurgeExpense permanently removes an expense that is already in the trash
+func (s *ExpenseService) PurgeExpense(id string, actor audit.Actor) error {
+	before, err := s.expenseRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.expenseRepo.Purge(id); err != nil {
+		return err
+	}
+
+	s.auditLog.Record(actor, audit.ActionExpensePurge, id, before, nil)
+	return nil
+}
+
+// EmptyTrash permanently removes every deleted expense
+func (s *ExpenseService) EmptyTrash(actor audit.Actor) (int, error) {
+	purged, err := s.expenseRepo.PurgeDeleted(time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("[SERVICE] %s emptied the trash: %d expenses purged", actor.Username, purged)
+	s.auditLog.Record(actor, audit.ActionTrashEmpty, "", nil, map[string]interface{}{"purged": purged})
+	return purged, nil
+}
+
+// PurgeExpiredTrash permanently removes expenses that have been in the
+// trash for longer than retention
+func (s *ExpenseService) PurgeExpiredTrash(retention time.Duration) (int, error) {
+	purged, err := s.expenseRepo.PurgeDeleted(time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		log.Printf("[SERVICE] Purged %d expenses deleted more than %v ago", purged, retention)
+		s.auditLog.Record(audit.System, audit.ActionTrashExpire, "", nil, map[string]interface{}{
+			"purged":    purged,
+			"retention": retention.String(),
+		})
+	}
+	return purged, nil
+}
+
+// RunTrashPurge calls PurgeExpiredTrash now and then every interval until
+// ctx is done. Failures are logged and retried on the next tick.
+func (s *ExpenseService) RunTrashPurge(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PurgeExpiredTrash(retention); err != nil {
+			log.Printf("[SERVICE] Trash purge failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 func (s *ExpenseService) ExportToCSV() ([]byte, error) {
@@ -116,18 +576,26 @@ func (s *ExpenseService) ExportToCSV() ([]byte, error) {
 	writer := csv.NewWriter(&buf)
 
 	// Write headers
-	headers := []string{"Mô tả", "Số lượng", "Đơn vị", "Số tiền (VND)", "Ngày", "Người trả"}
+	headers := []string{"Mô tả", "Loại", "Số lượng", "Đơn vị", "Số tiền (" + string(s.exchange.Base()) + ")",
+		"Số tiền gốc", "Tiền tệ", "Ngày", "Người trả", "Người nhận", "Thẻ", "Ghi chú"}
 	writer.Write(headers)
 
 	// Write data
 	for _, expense := range expenses {
+		s.fillCurrency(expense)
 		record := []string{
 			expense["items"].(string),
+			getStringField(expense, "kind"),
 			getStringField(expense, "quantity"),
 			getStringField(expense, "unit"),
 			fmt.Sprintf("%d", expense["amount"].(int64)),
+			formatOriginal(expense),
+			getStringField(expense, "currency"),
 			expense["paidDate"].(string),
 			expense["paidBy"].(string),
+			getStringField(expense, "paidTo"),
+			strings.Join(getTagsField(expense), ", "),
+			getStringField(expense, "notes"),
 		}
 		writer.Write(record)
 	}
@@ -136,6 +604,54 @@ func (s *ExpenseService) ExportToCSV() ([]byte, error) {
 	return buf.Bytes(), writer.Error()
 }
 
+// formatOriginal writes the amount paid with the decimals of its currency
+func formatOriginal(data map[string]interface{}) string {
+	original, _ := data["originalAmount"].(int64)
+	return expense.Currency(getStringField(data, "currency")).FormatAmount(original)
+}
+
+// getVersionField reads the version from a repository map; expenses that
+// predate versioning count as version 1
+func getVersionField(data map[string]interface{}) int64 {
+	if version, ok := data["version"].(int64); ok && version > 0 {
+		return version
+	}
+	return 1
+}
+
+// expenseDTOFromMap converts a repository map whose ID is under idKey:
+// "no" from GetAll, "id" from GetByID
+func expenseDTOFromMap(data map[string]interface{}, idKey string) expense.ExpenseDTO {
+	amount, _ := data["amount"].(int64)
+	original, _ := data["originalAmount"].(int64)
+	return expense.ExpenseDTO{
+		ID:              getStringField(data, idKey),
+		Items:           getStringField(data, "items"),
+		Amount:          amount,
+		Currency:        expense.Currency(getStringField(data, "currency")),
+		OriginalAmount:  original,
+		Rate:            getStringField(data, "rate"),
+		Quantity:        getStringField(data, "quantity"),
+		Unit:            getStringField(data, "unit"),
+		BaseQuantity:    getStringField(data, "baseQuantity"),
+		BaseUnit:        getStringField(data, "baseUnit"),
+		OriginalMessage: getStringField(data, "originalMessage"),
+		PaidDate:        getStringField(data, "paidDate"),
+		PaidBy:          getStringField(data, "paidBy"),
+		Kind:            expense.KindOf(getStringField(data, "kind")),
+		PaidTo:          getStringField(data, "paidTo"),
+		Tags:            getTagsField(data),
+		Notes:           getStringField(data, "notes"),
+		Version:         getVersionField(data),
+	}
+}
+
+// getTagsField reads the tags from a repository map
+func getTagsField(data map[string]interface{}) []string {
+	tags, _ := data["tags"].([]string)
+	return tags
+}
+
 // Helper function to safely get string field from map
 func getStringField(data map[string]interface{}, field string) string {
 	if val, exists := data[field]; exists && val != nil {
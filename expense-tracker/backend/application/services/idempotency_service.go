@@ -0,0 +1,69 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"expense-tracker/domain/idempotency"
+)
+
+// idempotencyStaleAfter is how long a claimed key may stay pending before a
+// retry takes it over. It is well above the time a parse can take, so only
+// a request that died part way is taken over.
+const idempotencyStaleAfter = 2 * time.Minute
+
+// IdempotencyService lets clients retry a create safely: the first request
+// under a key runs, and repeats within the window get its response back
+type IdempotencyService struct {
+	repo   idempotency.Repository
+	window time.Duration
+}
+
+// NewIdempotencyService remembers each key for window
+func NewIdempotencyService(repo idempotency.Repository, window time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, window: window}
+}
+
+// Begin claims key for username. A nil record means the caller owns the key
+// and must run the request, then call Finish or Abandon. Otherwise the
+// request already ran and the record holds the response to replay.
+// ErrKeyReused means the key came with a different request, ErrInProgress
+// that the first request has not finished yet.
+func (s *IdempotencyService) Begin(username, key, requestHash string) (*idempotency.Record, error) {
+	if err := idempotency.ValidateKey(key); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	existing, err := s.repo.ClaimIdempotencyKey(idempotency.Record{
+		Username:    username,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.window),
+	}, now.Add(-idempotencyStaleAfter))
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	switch {
+	case existing.RequestHash != requestHash:
+		return nil, idempotency.ErrKeyReused
+	case !existing.Completed:
+		return nil, idempotency.ErrInProgress
+	}
+	log.Printf("[SERVICE] Replaying response for idempotency key %s of %s (expense %s)", key, username, existing.ExpenseID)
+	return existing, nil
+}
+
+// Finish stores the response of a request that claimed key
+func (s *IdempotencyService) Finish(username, key, expenseID string, statusCode int, response []byte) error {
+	return s.repo.CompleteIdempotencyKey(username, key, expenseID, statusCode, response)
+}
+
+// Abandon frees key after its request failed, so that a retry runs again
+func (s *IdempotencyService) Abandon(username, key string) {
+	if err := s.repo.ReleaseIdempotencyKey(username, key); err != nil {
+		log.Printf("[SERVICE] Failed to release idempotency key %s of %s: %v", key, username, err)
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/price"
+)
+
+// PriceSeries is the unit prices of an item in one base unit, oldest first
+type PriceSeries struct {
+	Unit string `json:"unit"`
+	price.Stats
+	Points []price.Point `json:"points"`
+}
+
+// PriceHistory is everything paid for an item, one series per base unit:
+// eggs bought by the piece and by the kilogram are not compared
+type PriceHistory struct {
+	Item   string        `json:"item"`
+	Series []PriceSeries `json:"series"`
+}
+
+// PriceAlert reports a new expense whose unit price is far above what the
+// item has recently cost. Ratio is the unit price as a percentage of the
+// median.
+type PriceAlert struct {
+	Item      string `json:"item"`
+	Unit      string `json:"unit"`
+	UnitPrice int64  `json:"unitPrice"`
+	Median    int64  `json:"median"`
+	Ratio     int64  `json:"ratio"`
+	Count     int    `json:"count"`
+}
+
+// PriceService keeps the unit price of every active expense that has a base
+// quantity, following expense events, and compares new prices with them
+type PriceService struct {
+	repo     price.Repository
+	expenses *ExpenseService
+}
+
+func NewPriceService(repo price.Repository, expenses *ExpenseService) *PriceService {
+	return &PriceService{repo: repo, expenses: expenses}
+}
+
+// pricePoint is the unit price of an active expense, or false when it has
+// none: only expenses of kind expense with a positive amount and a base
+// quantity have one
+func pricePoint(dto expense.ExpenseDTO) (price.Point, bool) {
+	if expense.KindOf(string(dto.Kind)) != expense.KindExpense || dto.BaseQuantity == "" {
+		return price.Point{}, false
+	}
+	item := price.ItemKey(dto.Items)
+	if item == "" {
+		return price.Point{}, false
+	}
+	base, err := expense.ParseQuantity(dto.BaseQuantity, dto.BaseUnit)
+	if err != nil {
+		return price.Point{}, false
+	}
+	// Expenses recorded before quantities were measured may have a base
+	// such as "500 g"
+	base = base.Base()
+	unitPrice, ok := price.UnitPrice(dto.Amount, base)
+	if !ok {
+		return price.Point{}, false
+	}
+	paidDate, err := time.Parse("2006-01-02", dto.PaidDate)
+	if err != nil {
+		return price.Point{}, false
+	}
+	return price.Point{
+		ExpenseID: dto.ID,
+		Item:      item,
+		Items:     dto.Items,
+		PaidDate:  paidDate,
+		Amount:    dto.Amount,
+		Quantity:  base.Value(),
+		Unit:      base.Unit(),
+		UnitPrice: unitPrice,
+	}, true
+}
+
+// storedPricePoint is the unit price of an expense as GetByID returns it,
+// which may be in the trash
+func storedPricePoint(data map[string]interface{}) (price.Point, bool) {
+	if getStringField(data, "status") == string(expense.StatusDeleted) {
+		return price.Point{}, false
+	}
+	return pricePoint(expenseDTOFromMap(data, "id"))
+}
+
+// HandleEvent saves or removes the unit price of a changed expense. It is
+// subscribed synchronously so that a new expense has its price before it is
+// checked.
+func (s *PriceService) HandleEvent(event expense.Event) error {
+	if !event.IsExpenseChange() || event.Expense == nil {
+		return nil
+	}
+	if point, ok := storedPricePoint(event.Expense); ok {
+		return s.repo.SavePrice(point)
+	}
+	return s.repo.DeletePrice(event.ExpenseID)
+}
+
+// Rebuild works out the unit prices of all active expenses again, for
+// changes that raise no event such as a restored backup
+func (s *PriceService) Rebuild() (int, error) {
+	dtos, err := s.expenses.GetAllExpenses()
+	if err != nil {
+		return 0, err
+	}
+	points := []price.Point{}
+	for _, dto := range dtos {
+		if point, ok := pricePoint(dto); ok {
+			points = append(points, point)
+		}
+	}
+	if err := s.repo.ReplacePrices(points); err != nil {
+		return 0, err
+	}
+	log.Printf("[PRICES] Rebuilt %d unit prices", len(points))
+	return len(points), nil
+}
+
+// History returns what item has cost, grouped by base unit. item is
+// matched as ItemKey normalizes it.
+func (s *PriceService) History(item string) (*PriceHistory, error) {
+	key := price.ItemKey(item)
+	points, err := s.repo.ListPrices(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, price.ErrNoPrices
+	}
+
+	history := &PriceHistory{Item: key, Series: []PriceSeries{}}
+	index := make(map[string]int)
+	for _, point := range points {
+		i, ok := index[point.Unit]
+		if !ok {
+			i = len(history.Series)
+			index[point.Unit] = i
+			history.Series = append(history.Series, PriceSeries{Unit: point.Unit})
+		}
+		history.Series[i].Points = append(history.Series[i].Points, point)
+	}
+	for i := range history.Series {
+		history.Series[i].Stats = price.Summarize(history.Series[i].Points)
+	}
+	return history, nil
+}
+
+// CheckPrice compares the unit price of the expense with id with the
+// median of the item's prices in the price.RecentWindow before it. It
+// returns nil when the expense has no unit price, the item has fewer than
+// price.MinHistory recent prices, or the price is not far above them.
+func (s *PriceService) CheckPrice(id string) (*PriceAlert, error) {
+	data, err := s.expenses.GetExpense(id)
+	if err != nil {
+		return nil, err
+	}
+	point, ok := storedPricePoint(data)
+	if !ok {
+		return nil, nil
+	}
+	points, err := s.repo.ListPrices(point.Item)
+	if err != nil {
+		return nil, err
+	}
+	recent := price.Recent(points, point)
+	if len(recent) < price.MinHistory {
+		return nil, nil
+	}
+	stats := price.Summarize(recent)
+	if !price.IsHigh(point.UnitPrice, stats.Median) {
+		return nil, nil
+	}
+
+	log.Printf("[PRICES] Expense %s costs %d per %s, the median is %d", id, point.UnitPrice, point.Unit, stats.Median)
+	return &PriceAlert{
+		Item:      point.Item,
+		Unit:      point.Unit,
+		UnitPrice: point.UnitPrice,
+		Median:    stats.Median,
+		Ratio:     point.UnitPrice * 100 / stats.Median,
+		Count:     stats.Count,
+	}, nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/receipt"
+)
+
+// ReceiptService records the line items of a photographed receipt as
+// expenses, keeping the image with them
+type ReceiptService struct {
+	repo     receipt.Repository
+	parser   receipt.Parser
+	expenses *ExpenseService
+	auditLog *AuditService
+}
+
+func NewReceiptService(repo receipt.Repository, parser receipt.Parser, expenses *ExpenseService, auditLog *AuditService) *ReceiptService {
+	return &ReceiptService{
+		repo:     repo,
+		parser:   parser,
+		expenses: expenses,
+		auditLog: auditLog,
+	}
+}
+
+// ReceiptUpload is a stored receipt and the expenses created from it
+type ReceiptUpload struct {
+	Receipt  *receipt.Receipt         `json:"receipt"`
+	Expenses []map[string]interface{} `json:"expenses"`
+}
+
+// Upload parses image, stores it and records an expense per line item, paid
+// by actor.Username on the receipt's date. A receipt whose lines could not
+// be read becomes a single expense for the total.
+func (s *ReceiptService) Upload(image []byte, filename, contentType string, actor audit.Actor) (*ReceiptUpload, error) {
+	if err := receipt.ValidateImage(contentType, int64(len(image))); err != nil {
+		return nil, err
+	}
+
+	parsed, err := s.parser.ParseReceipt(image, contentType)
+	if err != nil {
+		return nil, err
+	}
+	inputs := expenseInputs(parsed)
+	if len(inputs) == 0 {
+		return nil, receipt.ErrUnreadable
+	}
+
+	rec := &receipt.Receipt{
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        int64(len(image)),
+		Merchant:    parsed.Merchant,
+		Date:        parsed.Date,
+		Total:       parsed.Total,
+		Items:       parsed.Items,
+		UploadedBy:  actor.Username,
+		UploadedAt:  time.Now(),
+	}
+	if err := s.repo.SaveReceipt(rec, image); err != nil {
+		return nil, err
+	}
+
+	upload := &ReceiptUpload{Receipt: rec}
+	var createErr error
+	for _, input := range inputs {
+		created, err := s.expenses.CreateExpense(input, actor)
+		if err != nil {
+			createErr = fmt.Errorf("recording %q: %w", input.Items, err)
+			break
+		}
+		upload.Expenses = append(upload.Expenses, created)
+		rec.ExpenseIDs = append(rec.ExpenseIDs, getStringField(created, "id"))
+	}
+
+	// Link whatever was created, even when a later line failed
+	if err := s.repo.LinkReceiptExpenses(rec.ID, rec.ExpenseIDs); err != nil {
+		log.Printf("[RECEIPT] Linking receipt %s to %v failed: %v", rec.ID, rec.ExpenseIDs, err)
+		if createErr == nil {
+			createErr = err
+		}
+	}
+
+	s.auditLog.Record(actor, audit.ActionReceiptUpload, rec.ID, nil, map[string]interface{}{
+		"merchant":   rec.Merchant,
+		"total":      rec.Total,
+		"expenseIds": rec.ExpenseIDs,
+	})
+	log.Printf("[RECEIPT] %s uploaded receipt %s from %q: %d expense(s)", actor.Username, rec.ID, rec.Merchant, len(rec.ExpenseIDs))
+	return upload, createErr
+}
+
+// expenseInputs turns the readable line items into expenses. When the total
+// differs from what the lines add up to, the difference is recorded too, as
+// an expense for taxes and fees or as a refund for a discount, so that the
+// expenses add up to what was paid.
+func expenseInputs(parsed *receipt.Parsed) []ExpenseInput {
+	paidDate := time.Now()
+	if date, err := time.Parse("2006-01-02", parsed.Date); err == nil {
+		paidDate = date
+	}
+	merchant := strings.TrimSpace(parsed.Merchant)
+	origin := "Hóa đơn"
+	if merchant != "" {
+		origin += " " + merchant
+	}
+
+	var inputs []ExpenseInput
+	var itemsTotal int64
+	for _, item := range parsed.Items {
+		description := strings.TrimSpace(item.Description)
+		if description == "" || item.Amount <= 0 {
+			continue
+		}
+		itemsTotal += item.Amount
+		inputs = append(inputs, ExpenseInput{
+			Items:           description,
+			Amount:          item.Amount,
+			Quantity:        item.Quantity,
+			Unit:            item.Unit,
+			BaseQuantity:    item.BaseQuantity,
+			BaseUnit:        item.BaseUnit,
+			OriginalMessage: origin,
+			PaidDate:        paidDate,
+		})
+	}
+
+	switch {
+	case len(inputs) == 0 && parsed.Total > 0:
+		inputs = append(inputs, ExpenseInput{Items: origin, Amount: parsed.Total, OriginalMessage: origin, PaidDate: paidDate})
+	case parsed.Total > itemsTotal && len(inputs) > 0:
+		inputs = append(inputs, ExpenseInput{
+			Items:           "Thuế, phí khác",
+			Amount:          parsed.Total - itemsTotal,
+			OriginalMessage: origin,
+			PaidDate:        paidDate,
+		})
+	case parsed.Total > 0 && parsed.Total < itemsTotal:
+		inputs = append(inputs, ExpenseInput{
+			Items:           "Giảm giá",
+			Amount:          itemsTotal - parsed.Total,
+			Kind:            expense.KindRefund,
+			OriginalMessage: origin,
+			PaidDate:        paidDate,
+		})
+	}
+	return inputs
+}
+
+func (s *ReceiptService) GetReceipt(id string) (*receipt.Receipt, error) {
+	return s.repo.GetReceipt(id)
+}
+
+func (s *ReceiptService) Image(id string) (*receipt.Receipt, []byte, error) {
+	rec, err := s.repo.GetReceipt(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	image, err := s.repo.ReceiptImage(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rec, image, nil
+}
+
+// ForExpense returns the receipt an expense was recorded from
+func (s *ReceiptService) ForExpense(expenseID string) (*receipt.Receipt, error) {
+	return s.repo.FindReceiptByExpense(expenseID)
+}
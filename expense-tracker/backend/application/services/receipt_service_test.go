@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/receipt"
+	"expense-tracker/infrastructure/ai"
+	"expense-tracker/infrastructure/memory"
+)
+
+var receiptImage = []byte("\x89PNG\r\n\x1a\nnot really a photo")
+
+func newReceiptTest(t *testing.T, parser receipt.Parser) (*ReceiptService, *memory.Repository) {
+	store := newTestStore(t)
+	expenses := newTestExpenseService(store, cannedMessageParser{})
+	return NewReceiptService(store, parser, expenses, NewAuditService(store)), store
+}
+
+type storedExpense struct {
+	items  string
+	amount int64
+	kind   expense.Kind
+}
+
+// checkReceiptExpenses checks that the receipt's expenses are stored in
+// order as want, paid by linh on paidDate
+func checkReceiptExpenses(t *testing.T, store *memory.Repository, upload *ReceiptUpload, paidDate string, want []storedExpense) {
+	t.Helper()
+	if len(upload.Receipt.ExpenseIDs) != len(want) || len(upload.Expenses) != len(want) {
+		t.Fatalf("receipt recorded %d expenses (%d returned), want %d", len(upload.Receipt.ExpenseIDs), len(upload.Expenses), len(want))
+	}
+	for i, id := range upload.Receipt.ExpenseIDs {
+		exp, err := store.GetByID(id)
+		if err != nil {
+			t.Fatalf("expense %s of the receipt: %v", id, err)
+		}
+		got := storedExpense{items: exp["items"].(string), amount: exp["amount"].(int64), kind: expense.Kind(exp["kind"].(string))}
+		if got != want[i] {
+			t.Errorf("expense %d = %+v, want %+v", i, got, want[i])
+		}
+		if exp["paidBy"] != "linh" || exp["paidDate"] != paidDate {
+			t.Errorf("expense %d paid by %v on %v, want linh on %s", i, exp["paidBy"], exp["paidDate"], paidDate)
+		}
+	}
+}
+
+func TestReceiptUploadCannedSample(t *testing.T) {
+	parser, err := ai.NewCannedReceiptParser("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, store := newReceiptTest(t, parser)
+
+	upload, err := service.Upload(receiptImage, "coopmart.png", "image/png", audit.Actor{Username: "linh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The sample's lines come to 392.000 of its 412.000 total
+	checkReceiptExpenses(t, store, upload, time.Now().Format("2006-01-02"), []storedExpense{
+		{"Gạo ST25", 185000, expense.KindExpense},
+		{"Thịt ba chỉ", 95000, expense.KindExpense},
+		{"Sữa tươi Vinamilk", 64000, expense.KindExpense},
+		{"Nước mắm Nam Ngư", 48000, expense.KindExpense},
+		{"Thuế, phí khác", 20000, expense.KindExpense},
+	})
+
+	rec, image, err := service.Image(upload.Receipt.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(image, receiptImage) || rec.Merchant != "Co.opmart Nguyễn Đình Chiểu" || rec.UploadedBy != "linh" {
+		t.Errorf("stored receipt %+v with %d bytes, want the upload", rec, len(image))
+	}
+	for _, id := range upload.Receipt.ExpenseIDs {
+		found, err := service.ForExpense(id)
+		if err != nil || found.ID != upload.Receipt.ID {
+			t.Errorf("ForExpense(%s) = %v, %v, want receipt %s", id, found, err, upload.Receipt.ID)
+		}
+	}
+}
+
+func TestReceiptUploadCannedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipt.json")
+	err := os.WriteFile(path, []byte(`{
+		"merchant": "Bách Hóa Xanh",
+		"date": "2024-03-15",
+		"total": 90000,
+		"items": [
+			{"description": "Rau muống", "quantity": "2", "unit": "bó", "amount": 30000},
+			{"description": "  ", "amount": 5000},
+			{"description": "Trứng gà", "quantity": "10", "unit": "quả", "amount": 70000}
+		]
+	}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser, err := ai.NewCannedReceiptParser(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, store := newReceiptTest(t, parser)
+
+	upload, err := service.Upload(receiptImage, "bhx.jpg", "image/jpeg", audit.Actor{Username: "linh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The blank line is skipped, and the 10.000 discount is a refund
+	checkReceiptExpenses(t, store, upload, "2024-03-15", []storedExpense{
+		{"Rau muống", 30000, expense.KindExpense},
+		{"Trứng gà", 70000, expense.KindExpense},
+		{"Giảm giá", -10000, expense.KindRefund},
+	})
+}
+
+func TestReceiptUploadRejected(t *testing.T) {
+	parser, err := ai.NewCannedReceiptParser("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, store := newReceiptTest(t, parser)
+	if _, err := service.Upload(receiptImage, "receipt.gif", "image/gif", audit.Actor{Username: "linh"}); !errors.Is(err, receipt.ErrUnsupportedType) {
+		t.Errorf("uploading a GIF = %v, want %v", err, receipt.ErrUnsupportedType)
+	}
+
+	parser.Receipt = receipt.Parsed{Merchant: "Blurry"}
+	if _, err := service.Upload(receiptImage, "blurry.png", "image/png", audit.Actor{Username: "linh"}); !errors.Is(err, receipt.ErrUnreadable) {
+		t.Errorf("uploading an unreadable receipt = %v, want %v", err, receipt.ErrUnreadable)
+	}
+
+	expenses, err := store.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expenses) != 0 {
+		t.Errorf("rejected receipts recorded %v", expenses)
+	}
+}
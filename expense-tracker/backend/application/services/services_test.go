@@ -0,0 +1,19 @@
+package services
+
+import (
+	"testing"
+
+	"expense-tracker/infrastructure/memory"
+	"expense-tracker/infrastructure/secrets"
+)
+
+// newTestStore returns an empty in-memory store, which implements every
+// repository the services need
+func newTestStore(t *testing.T) *memory.Repository {
+	t.Helper()
+	box, err := secrets.NewBox("services-test-master-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return memory.NewRepository(box)
+}
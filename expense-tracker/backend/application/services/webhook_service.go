@@ -0,0 +1,247 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/webhook"
+)
+
+const (
+	// webhookAttempts is how many times an event is sent before giving up
+	webhookAttempts = 5
+	// webhookBackoff is the wait before the second attempt; it doubles
+	// after each failure (2s, 4s, 8s, 16s)
+	webhookBackoff = 2 * time.Second
+	webhookTimeout = 10 * time.Second
+)
+
+// WebhookPayload is the JSON body of every delivery. Expense is the
+// expense after the change and is left out of pings.
+type WebhookPayload struct {
+	ID      string              `json:"id"`
+	Event   expense.EventType   `json:"event"`
+	Time    time.Time           `json:"time"`
+	Actor   string              `json:"actor,omitempty"`
+	Expense *expense.ExpenseDTO `json:"expense,omitempty"`
+}
+
+// WebhookService manages webhooks and sends them expense events. It is a
+// Subscriber, meant for asynchronous delivery.
+type WebhookService struct {
+	repo     webhook.Repository
+	auditLog *AuditService
+	client   *http.Client
+	backoff  time.Duration
+}
+
+func NewWebhookService(repo webhook.Repository, auditLog *AuditService) *WebhookService {
+	return &WebhookService{
+		repo:     repo,
+		auditLog: auditLog,
+		client:   &http.Client{Timeout: webhookTimeout},
+		backoff:  webhookBackoff,
+	}
+}
+
+// Create adds a webhook with a new secret. The returned subscription holds
+// the secret, which is shown to the admin this once.
+func (s *WebhookService) Create(url string, events []expense.EventType, actor audit.Actor) (*webhook.Subscription, error) {
+	subscription := webhook.Subscription{
+		URL:       url,
+		Events:    events,
+		CreatedBy: actor.Username,
+		CreatedAt: time.Now(),
+	}
+	if err := subscription.Validate(); err != nil {
+		return nil, err
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = secret
+
+	id, err := s.repo.CreateWebhook(subscription)
+	if err != nil {
+		return nil, err
+	}
+	subscription.ID = id
+
+	log.Printf("[SERVICE] %s added webhook %s for %v", actor.Username, id, events)
+	s.auditLog.Record(actor, audit.ActionWebhookCreate, id, nil, map[string]interface{}{
+		"url":    url,
+		"events": events,
+	})
+	return &subscription, nil
+}
+
+func (s *WebhookService) List() ([]webhook.Subscription, error) {
+	return s.repo.ListWebhooks()
+}
+
+func (s *WebhookService) Delete(id string, actor audit.Actor) error {
+	existing, err := s.repo.GetWebhook(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteWebhook(id); err != nil {
+		return err
+	}
+
+	s.auditLog.Record(actor, audit.ActionWebhookDelete, id, map[string]interface{}{
+		"url":    existing.URL,
+		"events": existing.Events,
+	}, nil)
+	return nil
+}
+
+// Deliveries returns the delivery log of a webhook, newest first
+func (s *WebhookService) Deliveries(id string) ([]webhook.Delivery, error) {
+	if _, err := s.repo.GetWebhook(id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(id)
+}
+
+// Ping sends a ping to a webhook once, without retrying, and returns how
+// it went. Failures to reach the receiver are in the delivery, not err.
+func (s *WebhookService) Ping(id string, actor audit.Actor) (*webhook.Delivery, error) {
+	subscription, err := s.repo.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	deliveryID, err := webhook.NewDeliveryID()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(WebhookPayload{ID: deliveryID, Event: webhook.EventPing, Time: time.Now(), Actor: actor.Username})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := s.send(subscription, deliveryID, webhook.EventPing, body, 1)
+	return &delivery, nil
+}
+
+// HandleEvent sends an expense event to every webhook that wants it. Each
+// webhook gets its own goroutine, so that one slow receiver retrying does
+// not hold up the others.
+func (s *WebhookService) HandleEvent(event expense.Event) error {
+	if !event.IsExpenseChange() {
+		return nil
+	}
+	subscriptions, err := s.repo.ListWebhooks()
+	if err != nil {
+		return err
+	}
+
+	dto := expenseDTOFromMap(event.Expense, "id")
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		if !subscription.Wants(event.Type) {
+			continue
+		}
+		deliveryID, err := webhook.NewDeliveryID()
+		if err != nil {
+			return err
+		}
+		body, err := json.Marshal(WebhookPayload{
+			ID:      deliveryID,
+			Event:   event.Type,
+			Time:    event.Time,
+			Actor:   event.Actor,
+			Expense: &dto,
+		})
+		if err != nil {
+			return err
+		}
+		go s.deliver(subscription, deliveryID, event.Type, body)
+	}
+	return nil
+}
+
+// deliver sends body until the receiver accepts it, it rejects it for good
+// or webhookAttempts run out, waiting longer after each failure
+func (s *WebhookService) deliver(subscription *webhook.Subscription, deliveryID string, event expense.EventType, body []byte) {
+	wait := s.backoff
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		delivery := s.send(subscription, deliveryID, event, body, attempt)
+		if delivery.Succeeded() || !retryable(delivery) {
+			return
+		}
+		if attempt < webhookAttempts {
+			time.Sleep(wait)
+			wait *= 2
+		}
+	}
+	log.Printf("[WEBHOOK] Giving up on %s event %s to webhook %s after %d attempts", event, deliveryID, subscription.ID, webhookAttempts)
+}
+
+// retryable reports whether a failed delivery may succeed later: network
+// errors, timeouts, rate limits and server errors. Other client errors
+// mean the receiver rejected the request and will again.
+func retryable(delivery webhook.Delivery) bool {
+	switch {
+	case delivery.StatusCode == 0:
+		return true
+	case delivery.StatusCode == http.StatusRequestTimeout, delivery.StatusCode == http.StatusTooManyRequests:
+		return true
+	default:
+		return delivery.StatusCode >= 500
+	}
+}
+
+// send makes one signed request and logs it as a delivery
+func (s *WebhookService) send(subscription *webhook.Subscription, deliveryID string, event expense.EventType, body []byte, attempt int) webhook.Delivery {
+	start := time.Now()
+	delivery := webhook.Delivery{
+		WebhookID:  subscription.ID,
+		DeliveryID: deliveryID,
+		Event:      event,
+		Attempt:    attempt,
+		Time:       start,
+	}
+
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err == nil {
+		timestamp := start.Unix()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "expense-tracker-webhook")
+		req.Header.Set(webhook.HeaderEvent, string(event))
+		req.Header.Set(webhook.HeaderDelivery, deliveryID)
+		req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(webhook.HeaderSignature, webhook.Sign(subscription.Secret, timestamp, body))
+
+		var resp *http.Response
+		resp, err = s.client.Do(req)
+		if err == nil {
+			// Drain a little so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			delivery.StatusCode = resp.StatusCode
+		}
+	}
+	delivery.DurationMillis = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	if delivery.Succeeded() {
+		log.Printf("[WEBHOOK] %s event %s to webhook %s: %d", event, deliveryID, subscription.ID, delivery.StatusCode)
+	} else {
+		log.Printf("[WEBHOOK] %s event %s to webhook %s failed (attempt %d): status %d %s",
+			event, deliveryID, subscription.ID, attempt, delivery.StatusCode, delivery.Error)
+	}
+	if err := s.repo.RecordDelivery(delivery); err != nil {
+		log.Printf("[WEBHOOK] Cannot log delivery %s: %v", deliveryID, err)
+	}
+	return delivery
+}
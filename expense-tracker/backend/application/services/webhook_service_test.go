@@ -0,0 +1,190 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/webhook"
+)
+
+// webhookReceiver is a local receiver that answers with statuses in turn,
+// repeating the last one, and keeps what it was sent
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) *webhookReceiver {
+	receiver := &webhookReceiver{statuses: statuses}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		status := receiver.statuses[len(receiver.statuses)-1]
+		if n := len(receiver.requests); n < len(receiver.statuses) {
+			status = receiver.statuses[n]
+		}
+		receiver.requests = append(receiver.requests, receivedWebhook{r.Header.Clone(), body})
+		receiver.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func (r *webhookReceiver) received() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedWebhook(nil), r.requests...)
+}
+
+func newTestWebhookService(t *testing.T, url string) (*WebhookService, *webhook.Subscription) {
+	store := newTestStore(t)
+	service := NewWebhookService(store, NewAuditService(store))
+	service.backoff = time.Millisecond
+	subscription, err := service.Create(url, []expense.EventType{expense.ExpenseCreated}, audit.Actor{Username: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service, subscription
+}
+
+func createdEvent() expense.Event {
+	return expense.Event{
+		Type:      expense.ExpenseCreated,
+		ExpenseID: "e1",
+		PaidBy:    "linh",
+		Actor:     "linh",
+		Expense:   map[string]interface{}{"id": "e1", "items": "gạo", "amount": int64(50000), "paidBy": "linh"},
+		Time:      time.Now(),
+	}
+}
+
+// waitForDeliveries polls the delivery log, which HandleEvent fills in the
+// background, until it holds want entries, newest first
+func waitForDeliveries(t *testing.T, service *WebhookService, id string, want int) []webhook.Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := service.Deliveries(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) >= want || time.Now().After(deadline) {
+			if len(deliveries) != want {
+				t.Fatalf("got %d deliveries, want %d: %+v", len(deliveries), want, deliveries)
+			}
+			return deliveries
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	receiver := newWebhookReceiver(t, http.StatusOK)
+	service, subscription := newTestWebhookService(t, receiver.URL)
+
+	if err := service.HandleEvent(createdEvent()); err != nil {
+		t.Fatal(err)
+	}
+	deliveries := waitForDeliveries(t, service, subscription.ID, 1)
+
+	requests := receiver.received()
+	if len(requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(requests))
+	}
+	request := requests[0]
+	timestamp, err := strconv.ParseInt(request.header.Get(webhook.HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header: %v", err)
+	}
+	signature := request.header.Get(webhook.HeaderSignature)
+	if !webhook.Verify(subscription.Secret, timestamp, request.body, signature) {
+		t.Errorf("signature %q does not verify with the webhook secret", signature)
+	}
+	if webhook.Verify("another-secret", timestamp, request.body, signature) {
+		t.Error("signature verifies with another secret")
+	}
+	if got := request.header.Get(webhook.HeaderEvent); got != string(expense.ExpenseCreated) {
+		t.Errorf("event header = %q, want %q", got, expense.ExpenseCreated)
+	}
+	if got := request.header.Get(webhook.HeaderDelivery); got != deliveries[0].DeliveryID {
+		t.Errorf("delivery header = %q, want the logged delivery ID %q", got, deliveries[0].DeliveryID)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+	}{
+		{"server errors and rate limits are retried", []int{500, 429, 503, 200}, 4},
+		{"client errors are not retried", []int{400, 200}, 1},
+		{"not found is not retried", []int{404, 200}, 1},
+		{"retries stop after the last attempt", []int{502}, webhookAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := newWebhookReceiver(t, tt.statuses...)
+			service, subscription := newTestWebhookService(t, receiver.URL)
+
+			if err := service.HandleEvent(createdEvent()); err != nil {
+				t.Fatal(err)
+			}
+			deliveries := waitForDeliveries(t, service, subscription.ID, tt.attempts)
+			// Give a wrongly retried delivery time to show up
+			time.Sleep(20 * time.Millisecond)
+			if got := len(receiver.received()); got != tt.attempts {
+				t.Fatalf("receiver got %d requests, want %d", got, tt.attempts)
+			}
+
+			for i, delivery := range deliveries {
+				attempt := tt.attempts - i
+				status := tt.statuses[len(tt.statuses)-1]
+				if attempt <= len(tt.statuses) {
+					status = tt.statuses[attempt-1]
+				}
+				if delivery.Attempt != attempt || delivery.StatusCode != status {
+					t.Errorf("delivery %d: attempt %d status %d, want attempt %d status %d",
+						i, delivery.Attempt, delivery.StatusCode, attempt, status)
+				}
+				if delivery.WebhookID != subscription.ID || delivery.DeliveryID != deliveries[0].DeliveryID ||
+					delivery.Event != expense.ExpenseCreated || delivery.Error != "" || delivery.Time.IsZero() {
+					t.Errorf("delivery %d = %+v", i, delivery)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookUnreachable(t *testing.T) {
+	receiver := newWebhookReceiver(t, http.StatusOK)
+	receiver.Close()
+	service, subscription := newTestWebhookService(t, receiver.URL)
+
+	delivery, err := service.Ping(subscription.ID, audit.Actor{Username: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.StatusCode != 0 || delivery.Error == "" || delivery.Event != webhook.EventPing {
+		t.Errorf("ping to a closed receiver = %+v, want an error and no status", delivery)
+	}
+	deliveries := waitForDeliveries(t, service, subscription.ID, 1)
+	if deliveries[0].Error != delivery.Error {
+		t.Errorf("logged error %q, want %q", deliveries[0].Error, delivery.Error)
+	}
+}
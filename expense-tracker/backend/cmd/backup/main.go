@@ -0,0 +1,143 @@
+// Command backup writes a backup archive of the configured storage backend,
+// or restores one:
+//
+//	go run ./cmd/backup
+//	go run ./cmd/backup -out backup.jsonl
+//	go run ./cmd/backup -restore backup.jsonl -dry-run
+//	go run ./cmd/backup -restore backup.jsonl -mode replace
+//
+// The backend is chosen from the environment and .env exactly as the server
+// does it. Restore prints its report as JSON; a replace first writes a
+// backup of the current data to BACKUP_DIR.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/backup"
+	"expense-tracker/infrastructure/storage"
+)
+
+// cliActor is recorded in the audit log for changes made from this command
+var cliActor = audit.Actor{Username: "cli"}
+
+// loadEnv applies .env like the server does; a missing file is not an error
+func loadEnv() error {
+	file, err := os.Open(".env")
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 {
+			os.Setenv(strings.TrimSpace(parts[0]), strings.Trim(strings.TrimSpace(parts[1]), "\""))
+		}
+	}
+	return scanner.Err()
+}
+
+func main() {
+	out := flag.String("out", "", "archive to write (default expense-tracker-<timestamp>.jsonl)")
+	restore := flag.String("restore", "", "archive to restore instead of writing one")
+	mode := flag.String("mode", string(backup.ModeMerge), "restore mode: merge or replace")
+	dryRun := flag.Bool("dry-run", false, "report what a restore would change without writing")
+	flag.Parse()
+
+	if err := loadEnv(); err != nil {
+		log.Fatalf("read .env: %v", err)
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		log.Fatalf("open storage: %v", err)
+	}
+	defer store.Close()
+
+	dir := os.Getenv("BACKUP_DIR")
+	if dir == "" {
+		dir = "backups"
+	}
+	service := services.NewBackupService(store, storage.Backend(), dir, services.NewAuditService(store))
+
+	if *restore != "" {
+		if err := runRestore(service, *restore, *mode, *dryRun); err != nil {
+			store.Close()
+			log.Fatal(err)
+		}
+		return
+	}
+
+	path := *out
+	if path == "" {
+		path = "expense-tracker-" + time.Now().Format("20060102-150405") + ".jsonl"
+	}
+	if err := runBackup(service, path); err != nil {
+		store.Close()
+		log.Fatal(err)
+	}
+	log.Printf("Backup written to %s", path)
+}
+
+func runBackup(service *services.BackupService, path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	if err := service.WriteBackup(writer, cliActor); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+func runRestore(service *services.BackupService, path, modeValue string, dryRun bool) error {
+	mode, err := backup.ParseMode(modeValue)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive, err := backup.Read(file)
+	if err != nil {
+		return err
+	}
+
+	report, err := service.Restore(archive, mode, dryRun, cliActor)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if report != nil {
+		encoder.Encode(report)
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+// Command fakebot stands in for the Telegram Bot API, to try the chat
+// webhook without a real bot. Start the server with
+//
+//	TELEGRAM_BOT_TOKEN=test TELEGRAM_WEBHOOK_SECRET=s3cret \
+//	TELEGRAM_API_URL=http://localhost:8090 go run ./cmd
+//
+// then send messages as a chat user and print the bot's replies:
+//
+//	go run ./cmd/fakebot -secret s3cret "/link ABCD2345" "3kg gạo 180k"
+//	go run ./cmd/fakebot -secret s3cret -serve
+//
+// Each argument is posted to the webhook as an update, waiting for the
+// reply before the next. -serve keeps answering sendMessage afterwards.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"expense-tracker/infrastructure/telegram"
+)
+
+// replyTimeout covers the webhook parsing the message with Gemini
+const replyTimeout = 30 * time.Second
+
+type sentMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+func main() {
+	addr := flag.String("addr", ":8090", "address of the fake Bot API")
+	token := flag.String("token", "test", "bot token the server was given")
+	webhook := flag.String("webhook", "http://localhost:8081/api/chat/telegram", "the server's webhook URL")
+	secret := flag.String("secret", "", "TELEGRAM_WEBHOOK_SECRET of the server")
+	from := flag.Int64("from", 1001, "Telegram user ID the messages come from")
+	name := flag.String("name", "linh", "Telegram username the messages come from")
+	serve := flag.Bool("serve", false, "keep serving after sending the messages")
+	flag.Parse()
+	log.SetPrefix("[fakebot] ")
+
+	// The webhook replies before answering the update, so replies are
+	// buffered until the update has been posted
+	replies := make(chan sentMessage, 16)
+	var sending atomic.Bool
+	sending.Store(flag.NArg() > 0)
+	http.HandleFunc("/bot"+*token+"/sendMessage", func(w http.ResponseWriter, r *http.Request) {
+		var msg sentMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "description": "Bad Request: " + err.Error()})
+			return
+		}
+		if !sending.Load() {
+			log.Printf("sendMessage to chat %s:\n%s", msg.ChatID, msg.Text)
+		} else {
+			select {
+			case replies <- msg:
+			default:
+				// No one reads replies beyond the buffer; answering
+				// anyway keeps the server from hanging on sendMessage
+				log.Printf("sendMessage to chat %s (not waited for):\n%s", msg.ChatID, msg.Text)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": map[string]interface{}{"message_id": time.Now().UnixNano()}})
+	})
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Wrong token or a method the fake does not know
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "description": "Not Found"})
+	})
+	go func() {
+		log.Fatal(http.ListenAndServe(*addr, nil))
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	for i, text := range flag.Args() {
+		id := int64(i + 1)
+		update := telegram.Update{
+			UpdateID: id,
+			Message: &telegram.Message{
+				MessageID: id,
+				From:      &telegram.User{ID: *from, FirstName: *name, Username: *name},
+				Chat:      telegram.Chat{ID: *from, Type: "private"},
+				Date:      time.Now().Unix(),
+				Text:      text,
+			},
+		}
+		if err := post(*webhook, *secret, update); err != nil {
+			log.Fatalf("posting %q: %v", text, err)
+		}
+		select {
+		case reply := <-replies:
+			fmt.Printf("> %s\n%s\n\n", text, reply.Text)
+		case <-time.After(replyTimeout):
+			log.Fatalf("no reply to %q", text)
+		}
+	}
+
+	sending.Store(false)
+	if *serve || flag.NArg() == 0 {
+		log.Printf("Fake Bot API on %s, token %q", *addr, *token)
+		select {}
+	}
+}
+
+func post(webhook, secret string, update telegram.Update) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(telegram.SecretHeader, secret)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return fmt.Errorf("webhook answered %d %v", resp.StatusCode, result)
+	}
+	return nil
+}
@@ -2,16 +2,61 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"expense-tracker/application/services"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/receipt"
 	"expense-tracker/infrastructure/ai"
-	"expense-tracker/infrastructure/mongodb"
+	"expense-tracker/infrastructure/eventbus"
+	"expense-tracker/infrastructure/sessionstore"
+	"expense-tracker/infrastructure/storage"
+	"expense-tracker/infrastructure/telegram"
 	"expense-tracker/interfaces/http"
 )
 
+// expenseWatcher is a store that can observe expense changes made by any
+// instance sharing it
+type expenseWatcher interface {
+	WatchExpenses(ctx context.Context, publish func(expense.Event)) error
+}
+
+// streamExpenses feeds the bus behind /api/stream. By default it follows
+// the events this instance raises. STREAM_SOURCE=mongo uses a change
+// stream instead, so that changes made by other instances show up too; it
+// needs a replica set, and the default is kept without one.
+func streamExpenses(store storage.Store, events *eventbus.Dispatcher, bus *eventbus.Bus) {
+	if os.Getenv("STREAM_SOURCE") == "mongo" {
+		watcher, ok := store.(expenseWatcher)
+		if !ok {
+			log.Printf("Warning: STREAM_SOURCE=mongo needs the mongodb storage backend")
+		} else if err := watcher.WatchExpenses(context.Background(), bus.Publish); err != nil {
+			log.Printf("Warning: Change streams unavailable (%v), streaming this instance's changes only", err)
+		} else {
+			return
+		}
+	}
+	events.Subscribe("stream", bus, eventbus.Sync)
+}
+
+// receiptParser reads receipt photos with Gemini, or with a canned fake
+// when RECEIPT_PARSER=canned (RECEIPT_CANNED_FILE overrides its sample)
+func receiptParser(gemini *ai.MessageParser) receipt.Parser {
+	if os.Getenv("RECEIPT_PARSER") != "canned" {
+		return gemini
+	}
+	canned, err := ai.NewCannedReceiptParser(os.Getenv("RECEIPT_CANNED_FILE"))
+	if err != nil {
+		log.Fatal("Failed to load RECEIPT_CANNED_FILE:", err)
+	}
+	log.Println("Receipt parser: canned (RECEIPT_PARSER=canned)")
+	return canned
+}
+
 func loadEnv() error {
 	file, err := os.Open(".env")
 	if err != nil {
@@ -38,6 +83,70 @@ func loadEnv() error {
 	return scanner.Err()
 }
 
+// defaultSessionSecret is only accepted when APP_ENV=development
+const defaultSessionSecret = "default-secret-key-change-in-production"
+
+// placeholderSessionSecrets are the secrets the shipped compose files, .env
+// and docs fall back to. They are public, so like the default they are only
+// accepted when APP_ENV=development.
+var placeholderSessionSecrets = map[string]bool{
+	defaultSessionSecret:                              true,
+	"expense-tracker-secret-default":                  true,
+	"expense-tracker-secret-key-change-in-production": true,
+	"your-secure-session-secret-key":                  true,
+	"your-secure-session-secret-change-in-production": true,
+}
+
+// durationFromEnv reads a Go duration (e.g. "24h") with a fallback
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s=%q, using %v", name, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// backupDir is where backups are written before destructive operations
+func backupDir() string {
+	if dir := os.Getenv("BACKUP_DIR"); dir != "" {
+		return dir
+	}
+	return "backups"
+}
+
+// baseCurrency is what every amount is converted into and summed in. It
+// should not change once expenses are recorded: stored amounts are not
+// converted again.
+func baseCurrency() expense.Currency {
+	value := os.Getenv("BASE_CURRENCY")
+	if value == "" {
+		return expense.VND
+	}
+	currency, err := expense.ParseCurrency(value)
+	if err != nil {
+		log.Fatalf("Invalid BASE_CURRENCY: %v", err)
+	}
+	return currency
+}
+
+// trustedProxies lists the proxies, as addresses or CIDRs separated by
+// commas in TRUSTED_PROXIES, whose X-Forwarded-For is believed. By default
+// none is: the header is the client's to set.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
 func main() {
 	// Load environment variables from file if exists
 	if err := loadEnv(); err != nil {
@@ -53,32 +162,107 @@ func main() {
 		os.Setenv("PORT", "8081")
 	}
 
-	log.Printf("MongoDB URI: %s", os.Getenv("MONGODB_URI"))
+	sessionSecret := os.Getenv("SESSION_SECRET")
+	if sessionSecret == "" || placeholderSessionSecrets[sessionSecret] {
+		if os.Getenv("APP_ENV") != "development" {
+			log.Fatal("SESSION_SECRET must be set to a non-default value (set APP_ENV=development to allow the default)")
+		}
+		log.Println("Warning: using a default session secret (APP_ENV=development)")
+		if sessionSecret == "" {
+			sessionSecret = defaultSessionSecret
+		}
+	}
+
+	log.Printf("Storage: %s", storage.Backend())
 	log.Printf("Port: %s", os.Getenv("PORT"))
 
 	// Infrastructure
-	mongoRepo, err := mongodb.NewRepository()
+	store, err := storage.Open()
 	if err != nil {
-		log.Fatal("Failed to create mongodb repository:", err)
+		log.Fatal("Failed to open storage:", err)
 	}
-	defer mongoRepo.Close()
+	defer store.Close()
 
-	parser := ai.NewMessageParser(mongoRepo)
+	// Encrypt legacy plaintext secrets and re-encrypt after a master key change
+	if rotated, err := store.RotateSecrets(); err != nil {
+		log.Printf("Warning: Failed to rotate stored secrets: %v", err)
+	} else if rotated > 0 {
+		log.Printf("Re-encrypted %d stored secret(s)", rotated)
+	}
+
+	parser := ai.NewMessageParser(store)
 
 	// Initialize default users
-	if err := mongoRepo.InitDefaultUsers(); err != nil {
+	if err := store.InitDefaultUsers(); err != nil {
 		log.Printf("Warning: Failed to initialize default users: %v", err)
 	}
 
 	// Application
-	expenseService := services.NewExpenseService(mongoRepo, parser)
+	auditService := services.NewAuditService(store)
+	events := eventbus.NewDispatcher()
+	eventBus := eventbus.New()
+	streamExpenses(store, events, eventBus)
+	exchangeService := services.NewExchangeService(store, baseCurrency(), auditService)
+	log.Printf("Base currency: %s", exchangeService.Base())
+	expenseService := services.NewExpenseService(store, parser, auditService, events, exchangeService, store)
+	backupService := services.NewBackupService(store, storage.Backend(), backupDir(), auditService)
+	duplicateService := services.NewDuplicateService(expenseService, store, auditService)
+	priceService := services.NewPriceService(store, expenseService)
+	catalogueService := services.NewCatalogueService(store, expenseService, auditService)
+	events.Subscribe("prices", priceService, eventbus.Sync)
+	// Prices follow this instance's changes; anything else, such as an
+	// offline restore, is caught up here
+	if _, err := priceService.Rebuild(); err != nil {
+		log.Printf("Warning: Failed to rebuild unit prices: %v", err)
+	}
+	telegramToken := os.Getenv("TELEGRAM_BOT_TOKEN")
+	telegramSecret := ""
+	if telegramToken != "" {
+		telegramSecret = os.Getenv("TELEGRAM_WEBHOOK_SECRET")
+		if telegramSecret == "" {
+			log.Fatal("TELEGRAM_WEBHOOK_SECRET must be set when TELEGRAM_BOT_TOKEN is")
+		}
+	}
+	chatService := services.NewChatService(store, store, expenseService, duplicateService,
+		telegram.NewClient(os.Getenv("TELEGRAM_API_URL"), telegramToken), auditService)
+	receiptService := services.NewReceiptService(store, receiptParser(parser), expenseService, auditService)
+	attachmentService := services.NewAttachmentService(store, expenseService, auditService)
+	webhookService := services.NewWebhookService(store, auditService)
+	events.Subscribe("webhooks", webhookService, eventbus.Async)
+	idempotencyService := services.NewIdempotencyService(store, durationFromEnv("IDEMPOTENCY_WINDOW", 24*time.Hour))
+
+	// Permanently remove expenses that have been in the trash too long
+	trashRetention := durationFromEnv("TRASH_RETENTION", 30*24*time.Hour)
+	go expenseService.RunTrashPurge(context.Background(), trashRetention, time.Hour)
 
 	// Interface
-	expenseHandler := http.NewExpenseHandler(expenseService)
-	adminHandler := http.NewAdminHandler(expenseService)
-	authHandler := http.NewAuthHandler(mongoRepo)
-	settingsHandler := http.NewSettingsHandler(mongoRepo)
-	router := http.NewRouter(expenseHandler, adminHandler, authHandler, settingsHandler)
+	expenseHandler := http.NewExpenseHandler(expenseService, idempotencyService, duplicateService, priceService)
+	adminHandler := http.NewAdminHandler(expenseService, attachmentService, trashRetention)
+	authHandler := http.NewAuthHandler(store, auditService)
+	settingsHandler := http.NewSettingsHandler(store, auditService, events)
+	userHandler := http.NewUserHandler(store, auditService)
+	sessionHandler := http.NewSessionHandler(store, auditService)
+	auditHandler := http.NewAuditHandler(auditService)
+	backupHandler := http.NewBackupHandler(backupService, priceService)
+	duplicateHandler := http.NewDuplicateHandler(duplicateService)
+	streamHandler := http.NewStreamHandler(eventBus, store)
+	webhookHandler := http.NewWebhookHandler(webhookService)
+	chatHandler := http.NewChatHandler(chatService, telegramSecret)
+	receiptHandler := http.NewReceiptHandler(receiptService)
+	attachmentHandler := http.NewAttachmentHandler(attachmentService)
+	rateHandler := http.NewRateHandler(exchangeService)
+	priceHandler := http.NewPriceHandler(priceService)
+	catalogueHandler := http.NewCatalogueHandler(catalogueService)
+	sessionStore := sessionstore.New(
+		store,
+		[]byte(sessionSecret),
+		durationFromEnv("SESSION_IDLE_TIMEOUT", 24*time.Hour),
+		durationFromEnv("SESSION_MAX_AGE", 7*24*time.Hour),
+	)
+	router := http.NewRouter(sessionStore, expenseHandler, adminHandler, authHandler, settingsHandler, userHandler, sessionHandler, auditHandler, backupHandler, duplicateHandler, streamHandler, webhookHandler, chatHandler, receiptHandler, attachmentHandler, rateHandler, priceHandler, catalogueHandler)
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -86,7 +270,9 @@ func main() {
 	}
 
 	log.Printf("Server starting on :%s", port)
-	log.Printf("Database: %s", os.Getenv("MONGODB_URI"))
+	if storage.Backend() == storage.BackendMongoDB {
+		log.Printf("Database: %s", os.Getenv("MONGODB_URI"))
+	}
 	log.Printf("Admin panel: http://localhost:%s/admin", port)
 	if os.Getenv("GEMINI_API_KEY") != "" {
 		log.Println("AI Parser: Gemini ✅")
@@ -0,0 +1,75 @@
+// Command storecheck runs the storage conformance suite against the memory
+// and SQLite backends:
+//
+//	go run ./cmd/storecheck
+//	go run ./cmd/storecheck -backends memory
+//
+// The MongoDB backend is not run here because the suite needs an empty
+// database for every check.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"expense-tracker/infrastructure/memory"
+	"expense-tracker/infrastructure/secrets"
+	"expense-tracker/infrastructure/sqlite"
+	"expense-tracker/infrastructure/storage"
+	"expense-tracker/infrastructure/storage/conformance"
+)
+
+func main() {
+	backends := flag.String("backends", "memory,sqlite", "comma-separated backends to check")
+	flag.Parse()
+
+	box, err := secrets.NewBox("storecheck-master-key")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dir, err := os.MkdirTemp("", "storecheck")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	factories := map[string]conformance.Factory{
+		storage.BackendMemory: func() (storage.Store, error) {
+			return memory.NewRepository(box), nil
+		},
+		storage.BackendSQLite: func() (storage.Store, error) {
+			file, err := os.CreateTemp(dir, "*.db")
+			if err != nil {
+				return nil, err
+			}
+			file.Close()
+			return sqlite.NewRepository(file.Name(), box)
+		},
+	}
+
+	failed := false
+	for _, name := range strings.Split(*backends, ",") {
+		factory, ok := factories[strings.TrimSpace(name)]
+		if !ok {
+			log.Fatalf("unknown backend %q", name)
+		}
+
+		failures := conformance.Run(factory)
+		for _, failure := range failures {
+			fmt.Printf("FAIL %s: %v\n", name, failure)
+		}
+		if len(failures) > 0 {
+			failed = true
+			continue
+		}
+		fmt.Printf("ok   %s (%d checks)\n", name, len(conformance.Checks()))
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,75 @@
+// Command webhookrecv is a local receiver for trying out webhooks. It
+// prints every delivery and checks its signature:
+//
+//	go run ./cmd/webhookrecv -secret <secret shown when the webhook was added>
+//	go run ./cmd/webhookrecv -addr :9000 -fail 2
+//
+// Add http://localhost:9090/ as the webhook URL. -fail answers the first N
+// deliveries with 500, to watch the retries in the delivery log.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"expense-tracker/domain/webhook"
+)
+
+// maxSkew is how old a delivery's timestamp may be before it is treated as
+// a replay
+const maxSkew = 5 * time.Minute
+
+func main() {
+	addr := flag.String("addr", ":9090", "address to listen on")
+	secret := flag.String("secret", "", "webhook secret; without it signatures are not checked")
+	fail := flag.Int64("fail", 0, "answer the first N deliveries with 500")
+	flag.Parse()
+
+	var received atomic.Int64
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		n := received.Add(1)
+		log.Printf("#%d %s %s delivery %s", n, r.Method, r.Header.Get(webhook.HeaderEvent), r.Header.Get(webhook.HeaderDelivery))
+
+		if *secret != "" {
+			timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+			if !webhook.Verify(*secret, timestamp, body, r.Header.Get(webhook.HeaderSignature)) {
+				log.Printf("#%d bad signature", n)
+				http.Error(w, "bad signature", http.StatusUnauthorized)
+				return
+			}
+			if age := time.Since(time.Unix(timestamp, 0)); age > maxSkew || age < -maxSkew {
+				log.Printf("#%d timestamp is %v off", n, age)
+				http.Error(w, "stale timestamp", http.StatusUnauthorized)
+				return
+			}
+			log.Printf("#%d signature ok", n)
+		}
+
+		var payload interface{}
+		if err := json.Unmarshal(body, &payload); err == nil {
+			pretty, _ := json.MarshalIndent(payload, "", "  ")
+			log.Printf("#%d %s", n, pretty)
+		}
+
+		if n <= *fail {
+			log.Printf("#%d answering 500 (-fail %d)", n, *fail)
+			http.Error(w, "failing on purpose", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("Listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,72 @@
+// Package attachment keeps files such as invoices, warranty cards or photos
+// with the expense they belong to. Attachments live as long as their
+// expense: purging an expense from the trash removes its files too.
+package attachment
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// MaxSize bounds one uploaded file
+const MaxSize = 10 << 20
+
+var (
+	ErrNotFound        = errors.New("attachment not found")
+	ErrTooLarge        = errors.New("attachment is larger than 10 MB")
+	ErrUnsupportedType = errors.New("attachment must be an image (JPEG, PNG, GIF, WebP, HEIC) or a PDF")
+)
+
+// contentTypes are the file types that can be attached
+var contentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"image/heic":      true,
+	"application/pdf": true,
+}
+
+// Validate checks an upload before it is stored
+func Validate(contentType string, size int64) error {
+	if !contentTypes[contentType] {
+		return ErrUnsupportedType
+	}
+	if size > MaxSize {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// Attachment describes a stored file; the content is read separately
+type Attachment struct {
+	ID          string    `json:"id"`
+	ExpenseID   string    `json:"expenseId"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	UploadedBy  string    `json:"uploadedBy"`
+	UploadedAt  time.Time `json:"uploadedAt"`
+}
+
+// IsImage tells whether the file can be shown as a thumbnail
+func (a Attachment) IsImage() bool {
+	return strings.HasPrefix(a.ContentType, "image/")
+}
+
+// Repository stores attachments. The expense repository of the same backend
+// removes the attachments of expenses it purges or clears.
+type Repository interface {
+	// SaveAttachment stores the file and sets the attachment's ID
+	SaveAttachment(a *Attachment, data []byte) error
+	GetAttachment(id string) (*Attachment, error)
+	// AttachmentData returns the stored file
+	AttachmentData(id string) ([]byte, error)
+	// ListAttachments returns an expense's attachments, oldest first
+	ListAttachments(expenseID string) ([]Attachment, error)
+	// ListAllAttachments returns every attachment, for backups and the
+	// admin page
+	ListAllAttachments() ([]Attachment, error)
+	DeleteAttachment(id string) error
+}
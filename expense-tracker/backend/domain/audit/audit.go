@@ -0,0 +1,100 @@
+package audit
+
+import "time"
+
+// Actions recorded in the audit log
+const (
+	ActionExpenseCreate   = "expense.create"
+	ActionExpenseUpdate   = "expense.update"
+	ActionExpenseDelete   = "expense.delete"
+	ActionExpenseRestore  = "expense.restore"
+	ActionExpenseMerge    = "expense.merge"
+	ActionExpensePurge    = "expense.purge"
+	ActionExpenseClearAll = "expense.clear_all"
+	ActionTrashEmpty      = "trash.empty"
+	ActionTrashExpire     = "trash.expire"
+
+	ActionDuplicateDismiss = "duplicate.dismiss"
+
+	ActionLogin       = "auth.login"
+	ActionLoginFailed = "auth.login_failed"
+	ActionLogout      = "auth.logout"
+	ActionRegister    = "auth.register"
+
+	ActionSettingsAPIKey = "settings.api_key"
+
+	ActionUserRole          = "user.role"
+	ActionUserDisable       = "user.disable"
+	ActionUserEnable        = "user.enable"
+	ActionUserResetPassword = "user.reset_password"
+	ActionUserDelete        = "user.delete"
+	ActionRegistrationMode  = "registration.mode"
+	ActionInviteCreate      = "invite.create"
+	ActionInviteDelete      = "invite.delete"
+
+	ActionSessionRevoke       = "session.revoke"
+	ActionSessionRevokeOthers = "session.revoke_others"
+
+	ActionBackupCreate  = "backup.create"
+	ActionBackupRestore = "backup.restore"
+)
+
+const (
+	ActionWebhookCreate = "webhook.create"
+	ActionWebhookDelete = "webhook.delete"
+	ActionChatLink      = "chat.link"
+	ActionChatUnlink    = "chat.unlink"
+	ActionReceiptUpload = "receipt.upload"
+
+	ActionAttachmentUpload = "attachment.upload"
+	ActionAttachmentDelete = "attachment.delete"
+
+	ActionRateSave   = "rate.save"
+	ActionRateDelete = "rate.delete"
+	ActionRateImport = "rate.import"
+
+	ActionCatalogueSave   = "catalogue.save"
+	ActionCatalogueDelete = "catalogue.delete"
+	ActionCatalogueMerge  = "catalogue.merge"
+)
+
+// Actor identifies who performed a change and from where
+type Actor struct {
+	Username string
+	IP       string
+}
+
+// System is the actor recorded for changes made by background jobs
+var System = Actor{Username: "system"}
+
+// Entry is one immutable record of a state change. Before and After hold
+// snapshots of the affected fields; secrets must never be put in them.
+type Entry struct {
+	ID        string                 `json:"id"`
+	Actor     string                 `json:"actor"`
+	Action    string                 `json:"action"`
+	TargetID  string                 `json:"targetId,omitempty"`
+	Before    map[string]interface{} `json:"before,omitempty"`
+	After     map[string]interface{} `json:"after,omitempty"`
+	IP        string                 `json:"ip,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// DefaultLimit caps a search that does not set Filter.Limit
+const DefaultLimit = 200
+
+type Filter struct {
+	Actor    string
+	Action   string
+	TargetID string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// Repository is append-only: entries can be added and searched, never
+// changed or removed
+type Repository interface {
+	AppendAudit(entry Entry) error
+	FindAudit(filter Filter) ([]Entry, error)
+}
@@ -0,0 +1,277 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+
+	"expense-tracker/domain/exchange"
+)
+
+// maxLineSize bounds a single record so that a corrupt file cannot make Read
+// buffer without limit. The largest record is an attachment, whose content
+// grows by a third in base64.
+const maxLineSize = 16 << 20
+
+type line struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type checksum struct {
+	SHA256 string `json:"sha256"`
+}
+
+// Write encodes archive to w. The manifest counts are filled in from the
+// records, so callers only set the descriptive fields.
+func Write(w io.Writer, archive *Archive) error {
+	manifest := archive.Manifest
+	manifest.Format = Format
+	manifest.Version = Version
+	manifest.Counts = map[string]int{
+		TypeExpense:    len(archive.Expenses),
+		TypeAttachment: len(archive.Attachments),
+		TypeUser:       len(archive.Users),
+		TypeSetting:    len(archive.Settings),
+		TypeRate:       len(archive.Rates),
+		TypeItem:       len(archive.Items),
+	}
+
+	sum := sha256.New()
+	out := io.MultiWriter(w, sum)
+
+	if err := writeLine(out, TypeManifest, manifest); err != nil {
+		return err
+	}
+	for _, exp := range archive.Expenses {
+		if err := writeLine(out, TypeExpense, exp); err != nil {
+			return err
+		}
+	}
+	for _, a := range archive.Attachments {
+		if err := writeLine(out, TypeAttachment, a); err != nil {
+			return err
+		}
+	}
+	for _, u := range archive.Users {
+		if err := writeLine(out, TypeUser, u); err != nil {
+			return err
+		}
+	}
+	for _, setting := range archive.Settings {
+		if err := writeLine(out, TypeSetting, setting); err != nil {
+			return err
+		}
+	}
+	for _, rate := range archive.Rates {
+		if err := writeLine(out, TypeRate, rate); err != nil {
+			return err
+		}
+	}
+	for _, item := range archive.Items {
+		if err := writeLine(out, TypeItem, item); err != nil {
+			return err
+		}
+	}
+	return writeLine(w, TypeChecksum, checksum{SHA256: hex.EncodeToString(sum.Sum(nil))})
+}
+
+func writeLine(w io.Writer, recordType string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(line{Type: recordType, Data: raw})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(encoded, '\n'))
+	return err
+}
+
+// Read decodes and validates an archive: the manifest must come first and
+// name a supported version, every record must be well formed, the counts
+// must match and the checksum must cover everything before it. Nothing is
+// returned unless the whole archive is valid.
+func Read(r io.Reader) (*Archive, error) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	sum := sha256.New()
+	archive := &Archive{}
+
+	number := 0
+	sawManifest := false
+	for {
+		raw, err := readLine(reader)
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: missing checksum line", ErrInvalidArchive)
+		}
+		if err != nil {
+			return nil, err
+		}
+		number++
+
+		var record line
+		if err := json.Unmarshal(bytes.TrimSpace(raw), &record); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidArchive, number, err)
+		}
+
+		if !sawManifest && record.Type != TypeManifest {
+			return nil, fmt.Errorf("%w: line %d: expected manifest, got %q", ErrInvalidArchive, number, record.Type)
+		}
+
+		if record.Type == TypeChecksum {
+			if err := verifyChecksum(record.Data, sum); err != nil {
+				return nil, err
+			}
+			if extra, _ := readLine(reader); len(bytes.TrimSpace(extra)) > 0 {
+				return nil, fmt.Errorf("%w: data after checksum line", ErrInvalidArchive)
+			}
+			return archive, verifyCounts(archive)
+		}
+
+		if err := decodeRecord(archive, record, sawManifest); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidArchive, number, err)
+		}
+		sawManifest = true
+		sum.Write(raw)
+	}
+}
+
+// readLine returns the next line including its newline, or io.EOF once the
+// input is exhausted
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	var buf []byte
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			if err == io.EOF && len(buf) > 0 {
+				return buf, nil
+			}
+			return nil, err
+		}
+		buf = append(buf, chunk...)
+		if len(buf) > maxLineSize {
+			return nil, fmt.Errorf("%w: line longer than %d bytes", ErrInvalidArchive, maxLineSize)
+		}
+		if !isPrefix {
+			return append(buf, '\n'), nil
+		}
+	}
+}
+
+func decodeRecord(archive *Archive, record line, sawManifest bool) error {
+	switch record.Type {
+	case TypeManifest:
+		if sawManifest {
+			return fmt.Errorf("second manifest")
+		}
+		if err := json.Unmarshal(record.Data, &archive.Manifest); err != nil {
+			return err
+		}
+		if archive.Manifest.Format != Format {
+			return fmt.Errorf("unknown format %q", archive.Manifest.Format)
+		}
+		if archive.Manifest.Version < 1 || archive.Manifest.Version > Version {
+			return fmt.Errorf("unsupported version %d (this build reads up to %d)", archive.Manifest.Version, Version)
+		}
+	case TypeExpense:
+		var exp Expense
+		if err := json.Unmarshal(record.Data, &exp); err != nil {
+			return err
+		}
+		if err := exp.validate(); err != nil {
+			return fmt.Errorf("expense %s: %v", exp.ID, err)
+		}
+		archive.Expenses = append(archive.Expenses, exp)
+	case TypeAttachment:
+		var a Attachment
+		if err := json.Unmarshal(record.Data, &a); err != nil {
+			return err
+		}
+		if err := a.validate(); err != nil {
+			return fmt.Errorf("attachment %s: %v", a.ID, err)
+		}
+		archive.Attachments = append(archive.Attachments, a)
+	case TypeUser:
+		var u User
+		if err := json.Unmarshal(record.Data, &u); err != nil {
+			return err
+		}
+		if u.Username == "" {
+			return fmt.Errorf("user without username")
+		}
+		archive.Users = append(archive.Users, u)
+	case TypeSetting:
+		var setting Setting
+		if err := json.Unmarshal(record.Data, &setting); err != nil {
+			return err
+		}
+		archive.Settings = append(archive.Settings, setting)
+	case TypeRate:
+		var rate Rate
+		if err := json.Unmarshal(record.Data, &rate); err != nil {
+			return err
+		}
+		if err := rate.validate(); err != nil {
+			return fmt.Errorf("rate %s %s: %v", rate.Currency, rate.Date.Format(exchange.DateLayout), err)
+		}
+		archive.Rates = append(archive.Rates, rate)
+	case TypeItem:
+		var item Item
+		if err := json.Unmarshal(record.Data, &item); err != nil {
+			return err
+		}
+		if err := item.validate(); err != nil {
+			return fmt.Errorf("item %q: %v", item.Name, err)
+		}
+		archive.Items = append(archive.Items, item)
+	default:
+		return fmt.Errorf("unknown record type %q", record.Type)
+	}
+	return nil
+}
+
+func verifyChecksum(data json.RawMessage, sum hash.Hash) error {
+	var expected checksum
+	if err := json.Unmarshal(data, &expected); err != nil {
+		return fmt.Errorf("%w: checksum line: %v", ErrInvalidArchive, err)
+	}
+	if actual := hex.EncodeToString(sum.Sum(nil)); actual != expected.SHA256 {
+		return fmt.Errorf("%w: checksum mismatch, the file is corrupt or was edited", ErrInvalidArchive)
+	}
+	return nil
+}
+
+func verifyCounts(archive *Archive) error {
+	actual := map[string]int{
+		TypeExpense:    len(archive.Expenses),
+		TypeAttachment: len(archive.Attachments),
+		TypeUser:       len(archive.Users),
+		TypeSetting:    len(archive.Settings),
+		TypeRate:       len(archive.Rates),
+		TypeItem:       len(archive.Items),
+	}
+	for recordType, count := range actual {
+		if archive.Manifest.Counts[recordType] != count {
+			return fmt.Errorf("%w: manifest lists %d %s records, found %d",
+				ErrInvalidArchive, archive.Manifest.Counts[recordType], recordType, count)
+		}
+	}
+
+	archived := make(map[string]bool, len(archive.Expenses))
+	for _, exp := range archive.Expenses {
+		archived[exp.ID] = true
+	}
+	for _, a := range archive.Attachments {
+		if !archived[a.ExpenseID] {
+			return fmt.Errorf("%w: attachment %s belongs to expense %s, which is not in the archive",
+				ErrInvalidArchive, a.ID, a.ExpenseID)
+		}
+	}
+	return nil
+}
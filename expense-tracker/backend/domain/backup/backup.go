@@ -0,0 +1,255 @@
+// Package backup defines the portable archive used to back up and restore an
+// instance, independent of the storage backend.
+//
+// An archive is JSON lines. The first line is the manifest, then one line per
+// record, and the last line holds the SHA-256 of every byte before it:
+//
+//	{"type":"manifest","data":{"format":"expense-tracker-backup","version":6,...}}
+//	{"type":"expense","data":{"id":"...","items":"...",...}}
+//	{"type":"attachment","data":{"expenseId":"...","filename":"...","data":"<base64>",...}}
+//	{"type":"user","data":{"username":"...","role":"..."}}
+//	{"type":"setting","data":{"key":"registration_mode","value":"open"}}
+//	{"type":"rate","data":{"currency":"USD","date":"...","rate":"25400",...}}
+//	{"type":"item","data":{"name":"Cà phê","aliases":["cafe"],...}}
+//	{"type":"checksum","data":{"sha256":"..."}}
+//
+// Passwords, sessions, invites and encrypted secrets such as the API key are
+// never written.
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"expense-tracker/domain/attachment"
+	"expense-tracker/domain/catalogue"
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/expense"
+)
+
+const (
+	// Format identifies an archive written by this application
+	Format = "expense-tracker-backup"
+	// Version is bumped whenever a record changes shape. Version 2 added
+	// attachment records, version 3 the kind of an expense, version 4 the
+	// currency of an expense and exchange rate records, version 5 catalogue
+	// item records, version 6 the tags and notes of an expense; older
+	// archives are still read.
+	Version = 6
+)
+
+// Record types, one per line
+const (
+	TypeManifest   = "manifest"
+	TypeExpense    = "expense"
+	TypeAttachment = "attachment"
+	TypeUser       = "user"
+	TypeSetting    = "setting"
+	TypeRate       = "rate"
+	TypeItem       = "item"
+	TypeChecksum   = "checksum"
+)
+
+// SettingRegistrationMode is the only setting carried in an archive; the API
+// key is a secret and stays behind
+const SettingRegistrationMode = "registration_mode"
+
+var ErrInvalidArchive = errors.New("invalid backup archive")
+
+type Manifest struct {
+	Format    string         `json:"format"`
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"createdAt"`
+	CreatedBy string         `json:"createdBy,omitempty"`
+	Source    string         `json:"source,omitempty"`
+	Counts    map[string]int `json:"counts"`
+}
+
+// Expense is a stored expense with everything needed to recreate it,
+// including its status and when it was deleted
+type Expense struct {
+	ID              string     `json:"id"`
+	Items           string     `json:"items"`
+	Amount          int64      `json:"amount"`
+	Currency        string     `json:"currency,omitempty"`
+	OriginalAmount  int64      `json:"originalAmount,omitempty"`
+	Rate            string     `json:"rate,omitempty"`
+	Quantity        string     `json:"quantity,omitempty"`
+	Unit            string     `json:"unit,omitempty"`
+	BaseQuantity    string     `json:"baseQuantity,omitempty"`
+	BaseUnit        string     `json:"baseUnit,omitempty"`
+	OriginalMessage string     `json:"originalMessage,omitempty"`
+	PaidDate        time.Time  `json:"paidDate"`
+	PaidBy          string     `json:"paidBy"`
+	Kind            string     `json:"kind,omitempty"`
+	PaidTo          string     `json:"paidTo,omitempty"`
+	Tags            []string   `json:"tags,omitempty"`
+	Notes           string     `json:"notes,omitempty"`
+	Status          string     `json:"status"`
+	DeletedDate     *time.Time `json:"deletedDate,omitempty"`
+	Version         int64      `json:"version,omitempty"`
+}
+
+func (e Expense) validate() error {
+	if e.Items == "" {
+		return errors.New("items cannot be empty")
+	}
+	if e.PaidBy == "" {
+		return errors.New("paidBy cannot be empty")
+	}
+	kind, err := expense.ParseKind(e.Kind)
+	if err != nil {
+		return err
+	}
+	if err := expense.ValidateTransaction(kind, e.Amount, e.PaidBy, e.PaidTo); err != nil {
+		return err
+	}
+	if e.Currency != "" {
+		if _, err := expense.ParseCurrency(e.Currency); err != nil {
+			return err
+		}
+	}
+	if e.Rate != "" {
+		if _, err := exchange.ParseRate(e.Rate); err != nil {
+			return err
+		}
+	}
+	if _, err := expense.CleanTags(e.Tags); err != nil {
+		return err
+	}
+	if err := expense.ValidateNotes(e.Notes); err != nil {
+		return err
+	}
+	if e.Status != string(expense.StatusActive) && e.Status != string(expense.StatusDeleted) {
+		return fmt.Errorf("unknown status %q", e.Status)
+	}
+	return nil
+}
+
+// Attachment is a file attached to one of the archived expenses, content
+// included. ExpenseID is the archived expense's ID.
+type Attachment struct {
+	ID          string    `json:"id"`
+	ExpenseID   string    `json:"expenseId"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	UploadedBy  string    `json:"uploadedBy"`
+	UploadedAt  time.Time `json:"uploadedAt"`
+	Data        []byte    `json:"data"`
+}
+
+func (a Attachment) validate() error {
+	if a.ExpenseID == "" {
+		return errors.New("expenseId cannot be empty")
+	}
+	return attachment.Validate(a.ContentType, int64(len(a.Data)))
+}
+
+// User is an account without its password
+type User struct {
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Rate is an exchange rate into the base currency of the archived instance
+type Rate struct {
+	Currency  string    `json:"currency"`
+	Date      time.Time `json:"date"`
+	Rate      string    `json:"rate"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (r Rate) validate() error {
+	return exchange.Rate{Currency: expense.Currency(r.Currency), Date: r.Date, Rate: r.Rate}.Validate()
+}
+
+// Item is a catalogue item. Items are matched by name on restore, as their
+// IDs differ between backends.
+type Item struct {
+	Name      string    `json:"name"`
+	Aliases   []string  `json:"aliases,omitempty"`
+	Category  string    `json:"category,omitempty"`
+	Unit      string    `json:"unit,omitempty"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (i Item) validate() error {
+	return catalogue.Item{Name: i.Name}.Validate()
+}
+
+type Archive struct {
+	Manifest    Manifest
+	Expenses    []Expense
+	Attachments []Attachment
+	Users       []User
+	Settings    []Setting
+	Rates       []Rate
+	Items       []Item
+}
+
+// Repository is implemented by storage backends so that expenses can be
+// archived with their status and restored with their original IDs
+type Repository interface {
+	// ExportExpenses returns every expense, deleted ones included
+	ExportExpenses() ([]Expense, error)
+	// ImportExpense stores exp as it is and returns its ID. The archived ID
+	// is kept when the backend can use it and it is free, otherwise a new
+	// one is assigned.
+	ImportExpense(exp Expense) (string, error)
+}
+
+// Mode decides what a restore does with data that is already stored
+type Mode string
+
+const (
+	// ModeMerge adds archived expenses and users that are missing and
+	// leaves everything else alone
+	ModeMerge Mode = "merge"
+	// ModeReplace removes every expense before restoring the archived ones,
+	// and overwrites settings and the role and status of archived users.
+	// Users that are not in the archive are kept, since an archive has no
+	// passwords to recreate them from.
+	ModeReplace Mode = "replace"
+)
+
+func ParseMode(value string) (Mode, error) {
+	switch Mode(value) {
+	case ModeMerge, ModeReplace:
+		return Mode(value), nil
+	}
+	return "", errors.New("invalid restore mode: " + value)
+}
+
+// Report describes what a restore did, or would do on a dry run
+type Report struct {
+	Mode             Mode   `json:"mode"`
+	DryRun           bool   `json:"dryRun"`
+	ArchiveCreatedAt string `json:"archiveCreatedAt"`
+	ExpensesRemoved  int    `json:"expensesRemoved"`
+	ExpensesAdded    int    `json:"expensesAdded"`
+	ExpensesSkipped  int    `json:"expensesSkipped"`
+	// AttachmentsAdded counts the files restored with the added expenses;
+	// a skipped expense keeps the attachments it already has
+	AttachmentsAdded int      `json:"attachmentsAdded"`
+	UsersAdded       []string `json:"usersAdded"`
+	UsersUpdated     []string `json:"usersUpdated"`
+	UsersSkipped     []string `json:"usersSkipped"`
+	SettingsUpdated  []string `json:"settingsUpdated"`
+	SafetyBackup     string   `json:"safetyBackup,omitempty"`
+	Warnings         []string `json:"warnings,omitempty"`
+	// RatesSaved counts the exchange rates written; a merge only adds the
+	// ones missing, a replace also overwrites those with the same date
+	RatesSaved int `json:"ratesSaved"`
+	// ItemsSaved counts the catalogue items written, as RatesSaved does
+	ItemsSaved int `json:"itemsSaved"`
+}
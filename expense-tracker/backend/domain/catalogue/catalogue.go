@@ -0,0 +1,194 @@
+// Package catalogue keeps the canonical names of the things the household
+// buys, so that "Cà phê", "Cafe" and "cafe sữa" are recorded as one item.
+package catalogue
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"expense-tracker/domain/expense"
+)
+
+var (
+	ErrNotFound    = errors.New("catalogue item not found")
+	ErrInvalidName = errors.New("catalogue item needs a name of letters or digits")
+	// ErrNameTaken is a name or alias another item already has
+	ErrNameTaken = errors.New("name is already used by another catalogue item")
+)
+
+// similarThreshold is the share of characters two names must have in common,
+// counted by edit distance, to match without one containing the other:
+// "ca phe" and "caphe", or a typo
+const similarThreshold = 0.8
+
+// minSimilarLength is the shortest name matched by similarity; short names
+// are a few edits away from too many others
+const minSimilarLength = 4
+
+// Item is a canonical item. Expenses whose items match Name or one of the
+// Aliases are recorded as Name. Category and Unit are defaults: Unit is
+// used for a quantity read without one.
+type Item struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Aliases   []string  `json:"aliases"`
+	Category  string    `json:"category,omitempty"`
+	Unit      string    `json:"unit,omitempty"`
+	UpdatedBy string    `json:"updatedBy,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Clean trims every field and drops empty aliases and those that are the
+// name or another alias once normalized
+func (i Item) Clean() Item {
+	i.Name = strings.TrimSpace(i.Name)
+	i.Category = strings.TrimSpace(i.Category)
+	i.Unit = strings.TrimSpace(i.Unit)
+
+	seen := map[string]bool{expense.NormalizeText(i.Name): true}
+	aliases := []string{}
+	for _, alias := range i.Aliases {
+		alias = strings.TrimSpace(alias)
+		key := expense.NormalizeText(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		aliases = append(aliases, alias)
+	}
+	i.Aliases = aliases
+	return i
+}
+
+func (i Item) Validate() error {
+	if expense.NormalizeText(i.Name) == "" {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+// Keys are the name and aliases of i, normalized
+func (i Item) Keys() []string {
+	keys := []string{expense.NormalizeText(i.Name)}
+	for _, alias := range i.Aliases {
+		keys = append(keys, expense.NormalizeText(alias))
+	}
+	return keys
+}
+
+// CheckConflict tells whether item shares a name or alias with one of
+// items other than itself
+func CheckConflict(items []Item, item Item) error {
+	taken := make(map[string]string)
+	for _, other := range items {
+		if other.ID == item.ID {
+			continue
+		}
+		for _, key := range other.Keys() {
+			taken[key] = other.Name
+		}
+	}
+	for _, key := range item.Keys() {
+		if owner, ok := taken[key]; ok {
+			return fmt.Errorf("%w: %q is used by %q", ErrNameTaken, key, owner)
+		}
+	}
+	return nil
+}
+
+// Match finds the item that text names. Several ways of matching are tried,
+// the better first:
+//
+//   - the same name or alias once normalized: "Cà Phê" is "cà phê"
+//   - a name or alias that is a run of whole words of text, the longest
+//     winning: "Cà phê G7" and "cafe sữa" contain "cà phê" and "cafe"
+//   - a name or alias at least similarThreshold alike, for spellings such
+//     as "caphe" and typos
+func Match(items []Item, text string) (Item, bool) {
+	key := expense.NormalizeText(text)
+	if key == "" {
+		return Item{}, false
+	}
+
+	var (
+		best        Item
+		found       bool
+		bestRank    int
+		bestMeasure float64
+	)
+	consider := func(item Item, rank int, measure float64) {
+		if !found || rank > bestRank || (rank == bestRank && measure > bestMeasure) {
+			best, found, bestRank, bestMeasure = item, true, rank, measure
+		}
+	}
+	for _, item := range items {
+		for _, name := range item.Keys() {
+			switch {
+			case name == key:
+				return item, true
+			case containsWords(key, name):
+				consider(item, 2, float64(len(name)))
+			default:
+				if similarity := similarity(key, name); similarity >= similarThreshold {
+					consider(item, 1, similarity)
+				}
+			}
+		}
+	}
+	return best, found
+}
+
+// containsWords tells whether the words of name appear together in text;
+// both are normalized, so words are separated by single spaces
+func containsWords(text, name string) bool {
+	return strings.Contains(" "+text+" ", " "+name+" ")
+}
+
+// similarity is 1 minus the edit distance of a and b over the length of the
+// longer, with spaces ignored
+func similarity(a, b string) float64 {
+	a, b = strings.ReplaceAll(a, " ", ""), strings.ReplaceAll(b, " ", "")
+	longest := utf8.RuneCountInString(a)
+	if n := utf8.RuneCountInString(b); n > longest {
+		longest = n
+	}
+	if longest < minSimilarLength {
+		return 0
+	}
+	return 1 - float64(editDistance([]rune(a), []rune(b)))/float64(longest)
+}
+
+// editDistance is the Levenshtein distance of a and b
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+type Repository interface {
+	// CreateItem stores a new item and returns its ID
+	CreateItem(item Item) (string, error)
+	GetItem(id string) (*Item, error)
+	// ListItems returns every item, by name
+	ListItems() ([]Item, error)
+	// UpdateItem replaces the item with item.ID
+	UpdateItem(item Item) error
+	DeleteItem(id string) error
+}
@@ -0,0 +1,81 @@
+// Package chat lets users record expenses by messaging a chat bot. A chat
+// account is linked to an app user once, with a short-lived code the user
+// gets from the web app and sends to the bot.
+package chat
+
+import (
+	"crypto/rand"
+	"errors"
+	"time"
+)
+
+// ProviderTelegram names links made through the Telegram bot
+const ProviderTelegram = "telegram"
+
+// LinkCodeTTL is how long a linking code can be used
+const LinkCodeTTL = 10 * time.Minute
+
+var (
+	ErrLinkCodeInvalid = errors.New("link code is invalid, expired or already used")
+	ErrNotLinked       = errors.New("chat account is not linked")
+)
+
+// linkCodeAlphabet leaves out letters and digits that are easy to mix up
+// when typing the code on a phone
+const linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// NewLinkCode returns a random 8-character code
+func NewLinkCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		// 256 is a multiple of the alphabet's 32 letters, so this is uniform
+		buf[i] = linkCodeAlphabet[int(b)%len(linkCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// LinkCode lets whoever sends it to the bot act as Username
+type LinkCode struct {
+	Code      string    `json:"code"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Link maps a chat account to the app user its messages are recorded for
+type Link struct {
+	Provider   string    `json:"provider"`
+	ChatUserID string    `json:"chatUserId"`
+	ChatName   string    `json:"chatName,omitempty"`
+	Username   string    `json:"username"`
+	LinkedAt   time.Time `json:"linkedAt"`
+}
+
+// Message is an incoming chat message. Replies go to ChatID; the sender is
+// who gets linked, so the same person is recognised in any chat.
+type Message struct {
+	Provider   string
+	ChatID     string
+	SenderID   string
+	SenderName string
+	Text       string
+}
+
+// Messenger sends replies back to a chat
+type Messenger interface {
+	SendMessage(chatID, text string) error
+}
+
+type Repository interface {
+	CreateLinkCode(code LinkCode) error
+	// ConsumeLinkCode uses up an unexpired code and returns its username
+	ConsumeLinkCode(code string, now time.Time) (string, error)
+	// SaveChatLink links a chat account, replacing any earlier link of it
+	SaveChatLink(link Link) error
+	FindChatLink(provider, chatUserID string) (*Link, error)
+	ListChatLinks(username string) ([]Link, error)
+	DeleteChatLink(username, provider, chatUserID string) error
+}
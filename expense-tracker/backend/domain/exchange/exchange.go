@@ -0,0 +1,194 @@
+// Package exchange keeps the exchange rates used to convert what was paid in
+// a foreign currency into the household's base currency.
+package exchange
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"expense-tracker/domain/expense"
+)
+
+var (
+	ErrNotFound     = errors.New("exchange rate not found")
+	ErrNoRate       = errors.New("no exchange rate for this currency")
+	ErrInvalidRate  = errors.New("rate must be a positive decimal number")
+	ErrInvalidDate  = errors.New("date must be YYYY-MM-DD")
+	ErrBaseCurrency = errors.New("the base currency has no exchange rate")
+	// ErrInvalidFile wraps every error found while reading an imported file
+	ErrInvalidFile = errors.New("invalid exchange rate file")
+)
+
+// DateLayout is how dates are written in requests and files
+const DateLayout = "2006-01-02"
+
+// Rate says what one unit of Currency (one dollar, not one cent) is worth in
+// the base currency, from Date until the next rate of that currency. Rate is
+// a decimal such as "25400" or "0.0265", kept as written so that converting
+// does not go through floating point.
+type Rate struct {
+	Currency  expense.Currency `json:"currency"`
+	Date      time.Time        `json:"date"`
+	Rate      string           `json:"rate"`
+	UpdatedBy string           `json:"updatedBy"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+var decimalPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// ParseRate reads a rate, which must be a plain positive decimal
+func ParseRate(value string) (*big.Rat, error) {
+	value = strings.TrimSpace(value)
+	if !decimalPattern.MatchString(value) {
+		return nil, ErrInvalidRate
+	}
+	rate, ok := new(big.Rat).SetString(value)
+	if !ok || rate.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	return rate, nil
+}
+
+// ParseDate reads a YYYY-MM-DD date as midnight UTC
+func ParseDate(value string) (time.Time, error) {
+	date, err := time.Parse(DateLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	return date, nil
+}
+
+// Day is the calendar day of t, as midnight UTC, which is how rate dates are
+// stored and compared
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Validate checks the currency, that Date is a day and the rate
+func (r Rate) Validate() error {
+	if _, err := expense.ParseCurrency(string(r.Currency)); err != nil {
+		return err
+	}
+	if r.Date.IsZero() || !r.Date.Equal(Day(r.Date)) {
+		return ErrInvalidDate
+	}
+	_, err := ParseRate(r.Rate)
+	return err
+}
+
+// Find picks the rate of currency in force on date: the latest one on or
+// before it, or the earliest one after it when the expense predates every
+// rate
+func Find(rates []Rate, currency expense.Currency, date time.Time) (Rate, error) {
+	day := Day(date)
+	var found *Rate
+	for i := range rates {
+		r := &rates[i]
+		if r.Currency != currency {
+			continue
+		}
+		switch {
+		case found == nil:
+			found = r
+		case !r.Date.After(day) && (found.Date.After(day) || r.Date.After(found.Date)):
+			found = r
+		case found.Date.After(day) && r.Date.Before(found.Date):
+			found = r
+		}
+	}
+	if found == nil {
+		return Rate{}, fmt.Errorf("%w: %s", ErrNoRate, currency)
+	}
+	return *found, nil
+}
+
+// Convert turns amount, in minor units of from, into minor units of base at
+// rate, rounding half away from zero: 12.50 USD at 25400 is 317500 VND
+func Convert(amount int64, from, base expense.Currency, rate string) (int64, error) {
+	r, err := ParseRate(rate)
+	if err != nil {
+		return 0, err
+	}
+	pow := func(n int) *big.Int {
+		return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+	}
+	num := new(big.Int).Mul(big.NewInt(amount), r.Num())
+	num.Mul(num, pow(base.MinorUnits()))
+	den := new(big.Int).Mul(r.Denom(), pow(from.MinorUnits()))
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(int64(num.Sign())))
+	}
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("%w: %s at %s is out of range", ErrInvalidRate, from.FormatAmount(amount), rate)
+	}
+	return quo.Int64(), nil
+}
+
+// ParseCSV reads rates from lines of "currency,date,rate", for example
+// "USD,2024-03-01,25400". A first line naming the columns is skipped.
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	var rates []Rate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "currency") {
+			continue
+		}
+
+		currency, err := expense.ParseCurrency(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line, err)
+		}
+		date, err := ParseDate(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line, err)
+		}
+		rate := strings.TrimSpace(record[2])
+		if _, err := ParseRate(rate); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidFile, line, err)
+		}
+		rates = append(rates, Rate{Currency: currency, Date: date, Rate: rate})
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: no rates", ErrInvalidFile)
+	}
+	return rates, nil
+}
+
+// Sort orders rates by currency, then date, which is how they are listed
+func Sort(rates []Rate) {
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Currency != rates[j].Currency {
+			return rates[i].Currency < rates[j].Currency
+		}
+		return rates[i].Date.Before(rates[j].Date)
+	})
+}
+
+// Repository stores rates, one per currency and date
+type Repository interface {
+	// SaveRate stores rate, replacing the one of the same currency and date
+	SaveRate(rate Rate) error
+	// ListRates returns every rate, ordered by currency then date
+	ListRates() ([]Rate, error)
+	// DeleteRate returns ErrNotFound when there is no such rate
+	DeleteRate(currency expense.Currency, date time.Time) error
+}
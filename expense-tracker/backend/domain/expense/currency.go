@@ -0,0 +1,125 @@
+package expense
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 code. Amounts are kept as whole numbers of its
+// minor unit: đồng for VND, cents for USD and EUR, satang for THB.
+type Currency string
+
+// VND is the currency of everything recorded before currencies existed, and
+// the default base currency
+const VND Currency = "VND"
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidDecimal  = errors.New("invalid decimal amount")
+)
+
+// minorUnits is the number of decimals of each supported currency, from
+// ISO 4217
+var minorUnits = map[Currency]int{
+	"AUD": 2, "CAD": 2, "CHF": 2, "CNY": 2, "EUR": 2, "GBP": 2, "HKD": 2,
+	"IDR": 2, "INR": 2, "JPY": 0, "KHR": 2, "KRW": 0, "LAK": 2, "MYR": 2,
+	"NZD": 2, "PHP": 2, "SGD": 2, "THB": 2, "TWD": 2, "USD": 2, "VND": 0,
+}
+
+// ParseCurrency reads a currency code in any case
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if _, ok := minorUnits[c]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+	return c, nil
+}
+
+// MinorUnits is the number of decimals amounts in c are written with
+func (c Currency) MinorUnits() int {
+	return minorUnits[c]
+}
+
+// scale is 10^MinorUnits, the number of minor units in one unit of c
+func (c Currency) scale() int64 {
+	scale := int64(1)
+	for i := 0; i < c.MinorUnits(); i++ {
+		scale *= 10
+	}
+	return scale
+}
+
+// FormatAmount writes an amount in minor units with its decimals:
+// USD 1250 is "12.50", VND 180000 is "180000"
+func (c Currency) FormatAmount(minor int64) string {
+	if c.MinorUnits() == 0 {
+		return strconv.FormatInt(minor, 10)
+	}
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	scale := c.scale()
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/scale, c.MinorUnits(), minor%scale)
+}
+
+// ParseAmount reads a decimal amount such as "12.5" into minor units. It
+// refuses more decimals than the currency has.
+func (c Currency) ParseAmount(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	whole, fraction, hasFraction := strings.Cut(strings.TrimPrefix(value, "-"), ".")
+	if whole == "" || (hasFraction && fraction == "") || len(fraction) > c.MinorUnits() ||
+		strings.Trim(whole+fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q in %s", ErrInvalidDecimal, value, c)
+	}
+	fraction += strings.Repeat("0", c.MinorUnits()-len(fraction))
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q in %s", ErrInvalidDecimal, value, c)
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// currencyNames are how a message names a currency other than by its code:
+// symbols as written, words without diacritics as NormalizeText leaves them
+var currencyNames = []struct {
+	name     string
+	symbol   bool
+	currency Currency
+}{
+	{"$", true, "USD"},
+	{"€", true, "EUR"},
+	{"฿", true, "THB"},
+	{"£", true, "GBP"},
+	{"¥", true, "JPY"},
+	{"do la", false, "USD"},
+	{"do my", false, "USD"},
+	{"euro", false, "EUR"},
+	{"baht", false, "THB"},
+	{"ringgit", false, "MYR"},
+}
+
+// DetectCurrency finds the currency a message names: a code ("20 usd"), a
+// symbol ("$20") or a common name ("20 đô la", "300 baht"). The second
+// result is false when it names none.
+func DetectCurrency(message string) (Currency, bool) {
+	normalized := " " + NormalizeText(message) + " "
+	for _, word := range strings.Fields(normalized) {
+		if c, err := ParseCurrency(word); err == nil {
+			return c, true
+		}
+	}
+	for _, n := range currencyNames {
+		if n.symbol && strings.Contains(message, n.name) ||
+			!n.symbol && strings.Contains(normalized, " "+n.name+" ") {
+			return n.currency, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,100 @@
+package expense
+
+import (
+	"strings"
+	"time"
+)
+
+// DuplicateWindow is how far apart the paid dates of two records of the
+// same purchase may be, e.g. when one partner logs it the next morning
+const DuplicateWindow = 2 * 24 * time.Hour
+
+// Reasons two expenses are reported as possible duplicates
+const (
+	// ReasonSimilarItems: same amount and overlapping item names
+	ReasonSimilarItems = "similar_items"
+	// ReasonSameMessage: the same original message once normalized
+	ReasonSameMessage = "same_message"
+)
+
+// similarItemsThreshold is the share of words two item lists must have in
+// common (Dice coefficient) to count as similar
+const similarItemsThreshold = 0.6
+
+// DuplicateCandidate is what duplicate detection compares
+type DuplicateCandidate struct {
+	ID              string
+	Items           string
+	Amount          int64
+	PaidDate        time.Time
+	OriginalMessage string
+}
+
+// SimilarItems reports whether two item lists name the same purchase:
+// every word of one in the other ("thịt" and "thịt bò"), or most of their
+// words in common in any order
+func SimilarItems(a, b string) bool {
+	a, b = NormalizeText(a), NormalizeText(b)
+	if a == "" || b == "" {
+		return false
+	}
+
+	wordsA, wordsB := wordSet(a), wordSet(b)
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	if shared == len(wordsA) || shared == len(wordsB) {
+		return true
+	}
+	return float64(2*shared)/float64(len(wordsA)+len(wordsB)) >= similarItemsThreshold
+}
+
+func wordSet(normalized string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(normalized) {
+		words[word] = true
+	}
+	return words
+}
+
+// DuplicateReasons lists why a and b look like the same purchase, or nil
+// when they do not. Both need paid dates within DuplicateWindow.
+func DuplicateReasons(a, b DuplicateCandidate) []string {
+	if a.ID == b.ID {
+		return nil
+	}
+	gap := a.PaidDate.Sub(b.PaidDate)
+	if gap < 0 {
+		gap = -gap
+	}
+	if gap > DuplicateWindow {
+		return nil
+	}
+
+	var reasons []string
+	if a.Amount == b.Amount && SimilarItems(a.Items, b.Items) {
+		reasons = append(reasons, ReasonSimilarItems)
+	}
+	if message := NormalizeText(a.OriginalMessage); message != "" && message == NormalizeText(b.OriginalMessage) {
+		reasons = append(reasons, ReasonSameMessage)
+	}
+	return reasons
+}
+
+// DuplicatePairKey identifies a pair of expenses regardless of order
+func DuplicatePairKey(a, b string) string {
+	if b < a {
+		a, b = b, a
+	}
+	return a + "|" + b
+}
+
+// DismissalRepository remembers pairs an admin has marked as not being
+// duplicates, so that they are not reported again
+type DismissalRepository interface {
+	DismissDuplicate(pairKey, dismissedBy string) error
+	ListDismissedDuplicates() ([]string, error)
+}
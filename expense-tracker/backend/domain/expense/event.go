@@ -0,0 +1,61 @@
+package expense
+
+import "time"
+
+// EventType says what happened
+type EventType string
+
+// Expense events. ExpenseService raises them once the change is stored.
+const (
+	ExpenseCreated  EventType = "created"
+	ExpenseUpdated  EventType = "updated"
+	ExpenseDeleted  EventType = "deleted"
+	ExpenseRestored EventType = "restored"
+)
+
+// SettingsChanged reports that an admin changed a setting, such as
+// the Gemini API key
+const SettingsChanged EventType = "settings_changed"
+
+// Event is something that happened in the domain. For expense events
+// Expense is the expense after the change, as returned by
+// Repository.GetByID; for SettingsChanged, Setting names the setting
+// and never carries its value. Actor is empty when the change was observed
+// in the database rather than made by this process.
+type Event struct {
+	Type      EventType              `json:"type"`
+	ExpenseID string                 `json:"expenseId,omitempty"`
+	PaidBy    string                 `json:"paidBy,omitempty"`
+	Setting   string                 `json:"setting,omitempty"`
+	Actor     string                 `json:"actor,omitempty"`
+	Expense   map[string]interface{} `json:"expense,omitempty"`
+	Time      time.Time              `json:"time"`
+}
+
+// IsExpenseChange reports whether the event is about one expense
+func (e Event) IsExpenseChange() bool {
+	switch e.Type {
+	case ExpenseCreated, ExpenseUpdated, ExpenseDeleted, ExpenseRestored:
+		return true
+	}
+	return false
+}
+
+// EventPublisher receives every event; Publish must not block for long
+// and never fails, so that publishing cannot fail the change itself
+type EventPublisher interface {
+	Publish(event Event)
+}
+
+// Subscriber reacts to events, e.g. to send notifications. An error only
+// gets logged; the change that raised the event has already happened.
+type Subscriber interface {
+	HandleEvent(event Event) error
+}
+
+// SubscriberFunc lets an ordinary function be a Subscriber
+type SubscriberFunc func(event Event) error
+
+func (f SubscriberFunc) HandleEvent(event Event) error {
+	return f(event)
+}
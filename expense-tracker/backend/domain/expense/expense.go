@@ -6,9 +6,11 @@ import (
 )
 
 type Expense struct {
-	id              int
+	id              string
 	items           string
 	amount          Money
+	original        Money
+	rate            string
 	quantity        string
 	unit            string
 	baseQuantity    string
@@ -16,7 +18,12 @@ type Expense struct {
 	originalMessage string
 	paidDate        time.Time
 	paidBy          string
+	kind            Kind
+	paidTo          string
+	tags            []string
+	notes           string
 	status          Status
+	version         int64
 }
 
 type Status string
@@ -46,6 +53,7 @@ func NewExpense(items string, amount int64, paidBy string) (*Expense, error) {
 		unit:     "",  // Sẽ được AI phân tích sau
 		paidDate: time.Now(),
 		paidBy:   paidBy,
+		kind:     KindExpense,
 		status:   StatusActive,
 	}, nil
 }
@@ -70,15 +78,19 @@ func NewExpenseWithQuantityUnit(items string, amount int64, quantity, unit, paid
 		unit:     unit,
 		paidDate: time.Now(),
 		paidBy:   paidBy,
+		kind:     KindExpense,
 		status:   StatusActive,
 	}, nil
 }
 
+// NewExpenseWithDate builds an expense from values that were checked
+// before: the service validates new ones, and repositories load stored ones,
+// whose amount may be negative for a refund or income (see SetKind)
 func NewExpenseWithDate(items string, amount int64, paidBy string, paidDate time.Time) *Expense {
-	money, _ := NewMoney(amount)
 	return &Expense{
 		items:    items,
-		amount:   money,
+		amount:   Money{value: amount},
+		kind:     KindExpense,
 		quantity: "",
 		unit:     "",
 		paidDate: paidDate,
@@ -97,7 +109,43 @@ func (e *Expense) OriginalMessage() string  { return e.originalMessage }
 func (e *Expense) PaidDate() time.Time      { return e.paidDate }
 func (e *Expense) PaidBy() string           { return e.paidBy }
 func (e *Expense) Status() Status           { return e.status }
-func (e *Expense) ID() int                  { return e.id }
+func (e *Expense) ID() string               { return e.id }
+func (e *Expense) Kind() Kind               { return e.kind }
+
+// Amount is in the household's base currency. Original is what was paid, in
+// the currency it was paid in, and Rate the exchange rate that converted it;
+// empty when no conversion was needed.
+func (e *Expense) Original() Money {
+	if e.original.currency == "" {
+		return e.amount
+	}
+	return e.original
+}
+
+func (e *Expense) Rate() string { return e.rate }
+
+// PaidTo is the member who received a transfer; empty for other kinds
+func (e *Expense) PaidTo() string { return e.paidTo }
+
+// Tags are free labels such as "Tết 2026" or "trip Đà Lạt", compared by
+// TagKey; Notes is free text
+func (e *Expense) Tags() []string { return append([]string{}, e.tags...) }
+func (e *Expense) Notes() string  { return e.notes }
+
+// Version counts the changes made to a stored expense. An update, delete or
+// restore must name the version it was based on, so that a change made in
+// the meantime is not silently overwritten.
+func (e *Expense) Version() int64 { return e.version }
+
+// SetID is called by the repository once the expense has been stored
+func (e *Expense) SetID(id string) {
+	e.id = id
+}
+
+// SetVersion is called by the repository when it loads or stores the expense
+func (e *Expense) SetVersion(version int64) {
+	e.version = version
+}
 
 // Business logic methods
 func (e *Expense) Delete() {
@@ -122,6 +170,36 @@ func (e *Expense) SetBaseQuantityUnit(baseQuantity, baseUnit string) {
 	e.baseUnit = baseUnit
 }
 
+// SetMeasure records quantity as written and base, its conversion into a
+// base unit, as MeasureQuantity returns them
+func (e *Expense) SetMeasure(quantity, base Quantity) {
+	e.SetQuantityUnit(quantity.Value(), quantity.Unit())
+	e.SetBaseQuantityUnit(base.Value(), base.Unit())
+}
+
 func (e *Expense) SetOriginalMessage(message string) {
 	e.originalMessage = message
+}
+
+// SetOriginal records the amount as paid and the rate it was converted to
+// the base currency at
+func (e *Expense) SetOriginal(original Money, rate string) {
+	e.original = original
+	e.rate = rate
+}
+
+// SetTags records tags as CleanTags leaves them
+func (e *Expense) SetTags(tags []string) {
+	e.tags = append([]string{}, tags...)
+}
+
+func (e *Expense) SetNotes(notes string) {
+	e.notes = notes
+}
+
+// SetKind records which way the money moved. The amount must already carry
+// the sign the kind requires; see ValidateTransaction.
+func (e *Expense) SetKind(kind Kind, paidTo string) {
+	e.kind = kind
+	e.paidTo = paidTo
 }
\ No newline at end of file
@@ -0,0 +1,114 @@
+package expense
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kind says which way money moved. Amounts are stored signed from the
+// payer's side, so that adding them up per member nets everything:
+//
+//   - expense: the payer spent money for the household; positive
+//   - refund: a shop gave money back to the payer; negative
+//   - income: the payer received money for the household; negative
+//   - transfer: the payer handed money to another member (PaidTo); positive
+//     for the payer and counted as negative for the recipient
+type Kind string
+
+const (
+	KindExpense  Kind = "expense"
+	KindRefund   Kind = "refund"
+	KindIncome   Kind = "income"
+	KindTransfer Kind = "transfer"
+)
+
+var (
+	ErrInvalidKind       = errors.New("kind must be expense, refund, income or transfer")
+	ErrTransferPaidTo    = errors.New("a transfer needs paidTo, a member other than the payer")
+	ErrPaidToNotTransfer = errors.New("paidTo is only for transfers")
+)
+
+// ParseKind reads a kind from a request or a stored record; empty means
+// expense, which is what everything recorded before kinds existed is
+func ParseKind(value string) (Kind, error) {
+	switch Kind(value) {
+	case "":
+		return KindExpense, nil
+	case KindExpense, KindRefund, KindIncome, KindTransfer:
+		return Kind(value), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidKind, value)
+}
+
+// KindOf is ParseKind for values read back from storage, where an unknown
+// kind cannot be refused any more
+func KindOf(value string) Kind {
+	kind, err := ParseKind(value)
+	if err != nil {
+		return KindExpense
+	}
+	return kind
+}
+
+// MoneyIn tells whether the kind brings money back to the payer, which
+// makes its amount negative
+func (k Kind) MoneyIn() bool {
+	return k == KindRefund || k == KindIncome
+}
+
+// Signed gives amount the sign k requires, whichever sign it came with, so
+// that "hoàn tiền 50k" can be entered as 50000
+func (k Kind) Signed(amount int64) int64 {
+	if amount < 0 {
+		amount = -amount
+	}
+	if k.MoneyIn() {
+		return -amount
+	}
+	return amount
+}
+
+// ValidateTransaction checks the sign rules of a stored amount and who a
+// transfer goes to
+func ValidateTransaction(kind Kind, amount int64, paidBy, paidTo string) error {
+	if _, err := NewAmount(kind, amount); err != nil {
+		return err
+	}
+	if kind != KindTransfer {
+		if paidTo != "" {
+			return ErrPaidToNotTransfer
+		}
+		return nil
+	}
+	if paidTo == "" || paidTo == paidBy {
+		return ErrTransferPaidTo
+	}
+	return nil
+}
+
+// kindPhrases are how a message says money came back, without diacritics
+// as NormalizeText leaves them
+var kindPhrases = []struct {
+	phrase string
+	kind   Kind
+}{
+	{"hoan tien", KindRefund},
+	{"duoc tra lai", KindRefund},
+	{"thu nhap", KindIncome},
+	{"nhan luong", KindIncome},
+}
+
+// DetectKind reads the kind from the wording of a message: "hoàn tiền áo
+// 200k" and "được trả lại 50k" are refunds, "thu nhập" and "nhận lương"
+// income. Anything else is an expense. Transfers need a recipient and are
+// never guessed.
+func DetectKind(message string) Kind {
+	normalized := " " + NormalizeText(message) + " "
+	for _, p := range kindPhrases {
+		if strings.Contains(normalized, " "+p.phrase+" ") {
+			return p.kind
+		}
+	}
+	return KindExpense
+}
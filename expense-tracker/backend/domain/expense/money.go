@@ -1,10 +1,16 @@
 package expense
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
-// Money is a value object representing monetary amount in VND
+// Money is a value object representing monetary amount in the minor unit
+// of its currency, VND unless said otherwise. It is negative for money coming
+// back to the payer (see Kind); NewMoney is for amounts that cannot be.
 type Money struct {
-	value int64
+	value    int64
+	currency Currency
 }
 
 func NewMoney(value int64) (Money, error) {
@@ -14,12 +20,48 @@ func NewMoney(value int64) (Money, error) {
 	return Money{value: value}, nil
 }
 
+// NewMoneyIn is value minor units of currency, for example 1250 USD cents
+func NewMoneyIn(value int64, currency Currency) Money {
+	return Money{value: value, currency: currency}
+}
+
+// ErrInvalidAmount is an amount whose sign does not match its kind
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// NewAmount checks value against the sign rules of kind: money coming back
+// (refund, income) is negative, a transfer is positive and an expense is
+// not negative
+func NewAmount(kind Kind, value int64) (Money, error) {
+	switch {
+	case kind.MoneyIn() && value >= 0:
+		return Money{}, fmt.Errorf("%w: a %s must be negative", ErrInvalidAmount, kind)
+	case kind == KindTransfer && value <= 0:
+		return Money{}, fmt.Errorf("%w: a transfer must be positive", ErrInvalidAmount)
+	case kind == KindExpense && value < 0:
+		return Money{}, fmt.Errorf("%w: an expense cannot be negative", ErrInvalidAmount)
+	}
+	return Money{value: value}, nil
+}
+
 func (m Money) Value() int64 {
 	return m.value
 }
 
+func (m Money) Currency() Currency {
+	if m.currency == "" {
+		return VND
+	}
+	return m.currency
+}
+
+// String writes the amount with its decimals and code: "12.50 USD"
+func (m Money) String() string {
+	return m.Currency().FormatAmount(m.value) + " " + string(m.Currency())
+}
+
+// Add sums two amounts of the same currency
 func (m Money) Add(other Money) Money {
-	return Money{value: m.value + other.value}
+	return Money{value: m.value + other.value, currency: m.currency}
 }
 
 func (m Money) IsZero() bool {
@@ -31,5 +73,5 @@ func (m Money) IsPositive() bool {
 }
 
 func (m Money) Equals(other Money) bool {
-	return m.value == other.value
+	return m.value == other.value && m.Currency() == other.Currency()
 }
\ No newline at end of file
@@ -0,0 +1,45 @@
+package expense
+
+import (
+	"strings"
+	"unicode"
+)
+
+// vietnameseBase maps each accented Vietnamese letter to its plain ASCII
+// letter. Lower case only; NormalizeText lowers first.
+var vietnameseBase = func() map[rune]rune {
+	groups := map[rune]string{
+		'a': "àáạảãâầấậẩẫăằắặẳẵ",
+		'e': "èéẹẻẽêềếệểễ",
+		'i': "ìíịỉĩ",
+		'o': "òóọỏõôồốộổỗơờớợởỡ",
+		'u': "ùúụủũưừứựửữ",
+		'y': "ỳýỵỷỹ",
+		'd': "đ",
+	}
+	base := make(map[rune]rune)
+	for plain, accented := range groups {
+		for _, r := range accented {
+			base[r] = plain
+		}
+	}
+	return base
+}()
+
+// NormalizeText lowers s, strips Vietnamese diacritics, turns punctuation
+// into spaces and collapses whitespace, so that "Thịt  bò!" and "thit bo"
+// compare equal
+func NormalizeText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range strings.ToLower(s) {
+		if plain, ok := vietnameseBase[r]; ok {
+			r = plain
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,212 @@
+package expense
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Dimension is what a unit measures. Each has one base unit that quantities
+// are converted into so that they can be compared: kg, L, m and pcs.
+type Dimension string
+
+const (
+	DimensionMass   Dimension = "mass"
+	DimensionVolume Dimension = "volume"
+	DimensionLength Dimension = "length"
+	DimensionCount  Dimension = "count"
+)
+
+var ErrInvalidQuantity = errors.New("quantity must be a positive decimal number")
+
+var baseUnits = map[Dimension]string{
+	DimensionMass:   "kg",
+	DimensionVolume: "L",
+	DimensionLength: "m",
+	DimensionCount:  "pcs",
+}
+
+// BaseUnit is the unit quantities of d are converted into
+func (d Dimension) BaseUnit() string {
+	return baseUnits[d]
+}
+
+// unitDef is one entry of the conversion registry: perBase is how many base
+// units one of it is worth, as a decimal
+type unitDef struct {
+	dimension Dimension
+	perBase   string
+	names     []string
+}
+
+// units is the conversion registry. Names are matched after NormalizeText,
+// so "lít", "Lit" and "LIT" are the same unit. Packaging (hộp, chai, bao...)
+// counts as pieces; what a pack holds is not in its name.
+var units = []unitDef{
+	{DimensionMass, "0.000001", []string{"mg"}},
+	{DimensionMass, "0.001", []string{"g", "gr", "gam", "gram"}},
+	{DimensionMass, "0.1", []string{"lạng", "hg"}},
+	{DimensionMass, "1", []string{"kg", "kilo", "kilogram", "ký", "kí", "cân"}},
+	{DimensionMass, "10", []string{"yến"}},
+	{DimensionMass, "1000", []string{"tấn"}},
+	{DimensionVolume, "0.001", []string{"ml"}},
+	{DimensionVolume, "1", []string{"l", "lít", "liter", "litre"}},
+	{DimensionLength, "0.001", []string{"mm"}},
+	{DimensionLength, "0.01", []string{"cm"}},
+	{DimensionLength, "1", []string{"m", "mét"}},
+	{DimensionLength, "1000", []string{"km"}},
+	{DimensionCount, "1", []string{"pcs", "cái", "chiếc", "quả", "trái", "con", "hộp", "chai", "lon", "gói",
+		"bao", "túi", "thùng", "bó", "cuốn", "ly", "cốc", "bát", "tô", "suất", "phần", "vỉ", "bịch"}},
+	{DimensionCount, "2", []string{"đôi", "cặp"}},
+	{DimensionCount, "10", []string{"chục"}},
+}
+
+var unitRegistry = func() map[string]*unitDef {
+	registry := make(map[string]*unitDef)
+	for i := range units {
+		for _, name := range units[i].names {
+			key := NormalizeText(name)
+			if _, taken := registry[key]; taken {
+				panic("expense: unit " + name + " registered twice")
+			}
+			registry[key] = &units[i]
+		}
+	}
+	return registry
+}()
+
+// countUnit is what an unknown unit is taken to be: some kind of piece
+var countUnit = unitDef{dimension: DimensionCount, perBase: "1"}
+
+// Quantity is an amount of something bought, as a decimal and a unit: "0.5
+// kg", "3 lạng", "2 hộp". The unit is kept as written; it is looked up in the
+// conversion registry to work out the base quantity, and a unit the registry
+// does not know counts as pieces.
+type Quantity struct {
+	value *big.Rat
+	unit  string
+	def   *unitDef
+}
+
+var quantityPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// ParseQuantity reads value, which may use a decimal comma ("0,5"), in unit.
+// An empty unit is pieces.
+func ParseQuantity(value, unit string) (Quantity, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, ".") && strings.Count(value, ",") == 1 {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	if !quantityPattern.MatchString(value) {
+		return Quantity{}, fmt.Errorf("%w: %q", ErrInvalidQuantity, value)
+	}
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok || rat.Sign() <= 0 {
+		return Quantity{}, fmt.Errorf("%w: %q", ErrInvalidQuantity, value)
+	}
+
+	unit = strings.TrimSpace(unit)
+	if unit == "" {
+		unit = DimensionCount.BaseUnit()
+	}
+	def, known := unitRegistry[NormalizeText(unit)]
+	if !known {
+		def = &countUnit
+	}
+	return Quantity{value: rat, unit: unit, def: def}, nil
+}
+
+// IsZero is a quantity that was not given
+func (q Quantity) IsZero() bool {
+	return q.value == nil
+}
+
+// Value is the decimal as a string, with a decimal point and no trailing
+// zeros: "0.5", "3"
+func (q Quantity) Value() string {
+	if q.value == nil {
+		return ""
+	}
+	return formatDecimal(q.value)
+}
+
+// Rat is the value as an exact fraction
+func (q Quantity) Rat() *big.Rat {
+	if q.value == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Set(q.value)
+}
+
+func (q Quantity) Unit() string {
+	return q.unit
+}
+
+func (q Quantity) Dimension() Dimension {
+	if q.def == nil {
+		return DimensionCount
+	}
+	return q.def.dimension
+}
+
+// Base converts q into the base unit of its dimension: 3 lạng is 0.3 kg,
+// 2 đôi is 4 pcs
+func (q Quantity) Base() Quantity {
+	if q.value == nil {
+		return q
+	}
+	perBase, _ := new(big.Rat).SetString(q.def.perBase)
+	base := q.Dimension().BaseUnit()
+	return Quantity{value: new(big.Rat).Mul(q.value, perBase), unit: base, def: unitRegistry[NormalizeText(base)]}
+}
+
+func (q Quantity) String() string {
+	if q.value == nil {
+		return ""
+	}
+	return q.Value() + " " + q.unit
+}
+
+// formatDecimal writes r with up to 6 decimals, which is a milligram in kg
+func formatDecimal(r *big.Rat) string {
+	s := r.FloatString(6)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MeasureQuantity reads the quantity of an expense and works out its base
+// quantity, ignoring whatever base the parser offered unless it cannot be
+// computed: a pack ("2 bao" of coffee of 0.5 kg) says nothing of what it
+// holds, so a base in another dimension is accepted for a count, once
+// checked to be a positive decimal in a known unit and converted. Both
+// results are zero when no quantity was given.
+func MeasureQuantity(quantity, unit, baseQuantity, baseUnit string) (Quantity, Quantity, error) {
+	quantity, unit = strings.TrimSpace(quantity), strings.TrimSpace(unit)
+	baseQuantity, baseUnit = strings.TrimSpace(baseQuantity), strings.TrimSpace(baseUnit)
+	if quantity == "" && unit == "" {
+		if baseQuantity == "" && baseUnit == "" {
+			return Quantity{}, Quantity{}, nil
+		}
+		quantity, unit = baseQuantity, baseUnit
+	}
+	if quantity == "" {
+		quantity = "1"
+	}
+
+	q, err := ParseQuantity(quantity, unit)
+	if err != nil {
+		return Quantity{}, Quantity{}, err
+	}
+	if q.Dimension() == DimensionCount && baseQuantity != "" {
+		offered, err := ParseQuantity(baseQuantity, baseUnit)
+		if err != nil {
+			return Quantity{}, Quantity{}, err
+		}
+		if offered.Dimension() != DimensionCount {
+			return q, offered.Base(), nil
+		}
+	}
+	return q, q.Base(), nil
+}
@@ -1,15 +1,106 @@
 package expense
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrExpenseNotFound   = errors.New("expense not found")
+	ErrExpenseNotDeleted = errors.New("expense is not in the trash")
+	ErrExpenseDeleted    = errors.New("expense is in the trash")
+	// ErrVersionConflict means the expense changed since the version the
+	// caller based its change on
+	ErrVersionConflict = errors.New("expense was changed by someone else")
+)
+
+// Changes lists the fields an update sets; nil fields are left as they are.
+// Amount is in the base currency and OriginalAmount in Currency, as stored;
+// the service works both out from the amount a client sends, which is in the
+// expense's currency.
+type Changes struct {
+	Items          *string
+	Amount         *int64
+	Currency       *Currency
+	OriginalAmount *int64
+	Rate           *string
+	Quantity       *string
+	Unit           *string
+	BaseQuantity   *string
+	BaseUnit       *string
+	PaidDate       *time.Time
+	PaidBy         *string
+	Kind           *Kind
+	PaidTo         *string
+	Tags           *[]string
+	Notes          *string
+}
+
+func (c Changes) Validate() error {
+	if c.Items != nil && *c.Items == "" {
+		return errors.New("items cannot be empty")
+	}
+	if c.PaidBy != nil && *c.PaidBy == "" {
+		return errors.New("paidBy cannot be empty")
+	}
+	if c.Kind != nil {
+		if _, err := ParseKind(string(*c.Kind)); err != nil {
+			return err
+		}
+	}
+	if c.Currency != nil {
+		if _, err := ParseCurrency(string(*c.Currency)); err != nil {
+			return err
+		}
+	}
+	if c.Tags != nil {
+		if _, err := CleanTags(*c.Tags); err != nil {
+			return err
+		}
+	}
+	if c.Notes != nil {
+		if err := ValidateNotes(*c.Notes); err != nil {
+			return err
+		}
+	}
+	// The sign of Amount depends on the kind the expense ends up with,
+	// which the service checks against the stored one
+	return nil
+}
+
+func (c Changes) IsEmpty() bool {
+	return c.Items == nil && c.Amount == nil && c.Quantity == nil && c.Unit == nil &&
+		c.BaseQuantity == nil && c.BaseUnit == nil && c.PaidDate == nil && c.PaidBy == nil &&
+		c.Kind == nil && c.PaidTo == nil && c.Currency == nil && c.OriginalAmount == nil && c.Rate == nil &&
+		c.Tags == nil && c.Notes == nil
+}
 
 type Repository interface {
 	Save(expense *Expense) error
 	FindByID(id int) (*Expense, error)
+	GetByID(id string) (map[string]interface{}, error)
 	FindAll() ([]*Expense, error)
 	FindActiveExpenses() ([]*Expense, error)
+	// GetSummaryByPaidBy nets what each member paid for active expenses:
+	// refunds and income count against the payer, and a transfer counts for
+	// the payer and against the recipient
 	GetSummaryByPaidBy() (map[string]int64, error)
-	Delete(id string) error
+	// Update, Delete and Restore only apply when the stored version equals
+	// version, and bump it; otherwise they return ErrVersionConflict.
+	// Update and Delete need an active expense (ErrExpenseDeleted), Restore
+	// one in the trash (ErrExpenseNotDeleted).
+	Update(id string, version int64, changes Changes) error
+	Delete(id string, version int64) error
+	Restore(id string, version int64) error
+	// ClearAll removes every expense together with its attachments
 	ClearAll() error
+	// Purge permanently removes an expense that is already in the trash,
+	// and its attachments
+	Purge(id string) error
+	// PurgeDeleted permanently removes expenses deleted before the given
+	// time, and their attachments, and returns how many; a zero time empties
+	// the whole trash
+	PurgeDeleted(before time.Time) (int, error)
 	GetAll() ([]map[string]interface{}, error)
 	GetDeleted() ([]map[string]interface{}, error)
 }
@@ -18,16 +109,25 @@ type MessageParser interface {
 	Parse(message string) (items string, amount int64, quantity string, unit string, baseQuantity string, baseUnit string, originalMessage string, paidDate time.Time, error error)
 }
 
-// DTOs for presentation layer
+// DTOs for presentation layer. Currency and OriginalAmount are what was paid;
+// Amount is that in the base currency, converted at Rate.
 type ExpenseDTO struct {
-	ID              string `json:"id"`
-	Items           string `json:"items"`
-	Amount          int64  `json:"amount"`
-	Quantity        string `json:"quantity,omitempty"`
-	Unit            string `json:"unit,omitempty"`
-	BaseQuantity    string `json:"baseQuantity,omitempty"`
-	BaseUnit        string `json:"baseUnit,omitempty"`
-	OriginalMessage string `json:"originalMessage,omitempty"`
-	PaidDate        string `json:"paidDate"`
-	PaidBy          string `json:"paidBy"`
+	ID              string   `json:"id"`
+	Items           string   `json:"items"`
+	Amount          int64    `json:"amount"`
+	Currency        Currency `json:"currency"`
+	OriginalAmount  int64    `json:"originalAmount"`
+	Rate            string   `json:"rate,omitempty"`
+	Quantity        string   `json:"quantity,omitempty"`
+	Unit            string   `json:"unit,omitempty"`
+	BaseQuantity    string   `json:"baseQuantity,omitempty"`
+	BaseUnit        string   `json:"baseUnit,omitempty"`
+	OriginalMessage string   `json:"originalMessage,omitempty"`
+	PaidDate        string   `json:"paidDate"`
+	PaidBy          string   `json:"paidBy"`
+	Kind            Kind     `json:"kind"`
+	PaidTo          string   `json:"paidTo,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Notes           string   `json:"notes,omitempty"`
+	Version         int64    `json:"version"`
 }
\ No newline at end of file
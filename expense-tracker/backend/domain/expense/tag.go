@@ -0,0 +1,164 @@
+package expense
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTags is how many tags one expense may carry
+const MaxTags = 20
+
+// MaxTagLength and MaxNotesLength bound tags and notes, in characters
+const (
+	MaxTagLength   = 50
+	MaxNotesLength = 2000
+)
+
+var (
+	ErrInvalidTags = errors.New("invalid tags")
+	ErrNotesLength = fmt.Errorf("notes must be at most %d characters", MaxNotesLength)
+)
+
+// hashtagPattern finds "#tet" or "#trip_Đà_Lạt" at the start of a message
+// or after a space. A hashtag starts with a letter: "a#b" and "#1" are not
+// ones.
+var hashtagPattern = regexp.MustCompile(`(^|\s)#(\p{L}[\p{L}\p{M}\p{N}_-]*)`)
+
+// TagKey is what tags compare by: "Tết 2026", "tet 2026" and "#tet_2026"
+// are one tag
+func TagKey(tag string) string {
+	return NormalizeText(tag)
+}
+
+// CleanTags trims tags and collapses their spaces, and drops empty ones and
+// repeats by TagKey, keeping the first spelling. Too many tags, or one too
+// long, is ErrInvalidTags.
+func CleanTags(tags []string) ([]string, error) {
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(tag), " ")
+		key := TagKey(tag)
+		if key == "" || seen[key] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidTags, tag, MaxTagLength)
+		}
+		seen[key] = true
+		cleaned = append(cleaned, tag)
+	}
+	if len(cleaned) > MaxTags {
+		return nil, fmt.Errorf("%w: at most %d per expense", ErrInvalidTags, MaxTags)
+	}
+	return cleaned, nil
+}
+
+// ValidateNotes checks the length of notes
+func ValidateNotes(notes string) error {
+	if utf8.RuneCountInString(notes) > MaxNotesLength {
+		return ErrNotesLength
+	}
+	return nil
+}
+
+// ExtractTags takes the hashtags out of message and returns the rest of it
+// and the tags, in order, underscores read as spaces: "#tet 500k bánh chưng"
+// is "500k bánh chưng" tagged "tet"
+func ExtractTags(message string) (string, []string) {
+	var tags []string
+	for _, match := range hashtagPattern.FindAllStringSubmatch(message, -1) {
+		tags = append(tags, strings.ReplaceAll(match[2], "_", " "))
+	}
+	if len(tags) == 0 {
+		return message, nil
+	}
+	rest := hashtagPattern.ReplaceAllString(message, "$1")
+	return strings.Join(strings.Fields(rest), " "), tags
+}
+
+// MergeTags adds the tags of extra that tags lacks, by TagKey
+func MergeTags(tags, extra []string) []string {
+	merged := append([]string{}, tags...)
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		seen[TagKey(tag)] = true
+	}
+	for _, tag := range extra {
+		if key := TagKey(tag); !seen[key] {
+			seen[key] = true
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// HasTags tells whether tags include every one of wanted, by TagKey
+func HasTags(tags, wanted []string) bool {
+	have := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		have[TagKey(tag)] = true
+	}
+	for _, tag := range wanted {
+		if key := TagKey(tag); key != "" && !have[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// TagTotal sums up the active expenses carrying one tag. Total and ByMember
+// net refunds and income as the per-member summary does; transfers move
+// money between members rather than spend it and are left out. FirstDate
+// and LastDate span the paid dates.
+type TagTotal struct {
+	Tag       string           `json:"tag"`
+	Count     int              `json:"count"`
+	Total     int64            `json:"total"`
+	ByMember  map[string]int64 `json:"byMember"`
+	FirstDate string           `json:"firstDate"`
+	LastDate  string           `json:"lastDate"`
+}
+
+// SummarizeTags works out a TagTotal for every tag of expenses, the largest
+// total first. A tag is named by its first spelling in expenses.
+func SummarizeTags(expenses []ExpenseDTO) []TagTotal {
+	index := make(map[string]int)
+	totals := []TagTotal{}
+	for _, dto := range expenses {
+		if dto.Kind == KindTransfer {
+			continue
+		}
+		for _, tag := range dto.Tags {
+			key := TagKey(tag)
+			i, ok := index[key]
+			if !ok {
+				i = len(totals)
+				index[key] = i
+				totals = append(totals, TagTotal{Tag: tag, ByMember: make(map[string]int64),
+					FirstDate: dto.PaidDate, LastDate: dto.PaidDate})
+			}
+			total := &totals[i]
+			total.Count++
+			total.Total += dto.Amount
+			total.ByMember[dto.PaidBy] += dto.Amount
+			if dto.PaidDate < total.FirstDate {
+				total.FirstDate = dto.PaidDate
+			}
+			if dto.PaidDate > total.LastDate {
+				total.LastDate = dto.PaidDate
+			}
+		}
+	}
+	sort.SliceStable(totals, func(i, j int) bool {
+		if totals[i].Total != totals[j].Total {
+			return totals[i].Total > totals[j].Total
+		}
+		return TagKey(totals[i].Tag) < TagKey(totals[j].Tag)
+	})
+	return totals
+}
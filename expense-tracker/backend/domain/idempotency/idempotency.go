@@ -0,0 +1,69 @@
+// Package idempotency remembers the outcome of a create request under a
+// client-chosen key, so that a retried request replays the first response
+// instead of creating the expense again.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// MaxKeyLength bounds the Idempotency-Key header
+const MaxKeyLength = 255
+
+var (
+	ErrInvalidKey = errors.New("idempotency key must be 1 to 255 printable ASCII characters")
+	ErrInProgress = errors.New("a request with this idempotency key is still being processed")
+	ErrKeyReused  = errors.New("idempotency key was already used for a different request")
+)
+
+// Record is what is stored per user and key. It is pending until Completed;
+// ExpenseID, StatusCode and Response are only set once it is.
+type Record struct {
+	Username    string
+	Key         string
+	RequestHash string
+	Completed   bool
+	ExpenseID   string
+	StatusCode  int
+	Response    []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// ValidateKey accepts visible ASCII keys such as UUIDs
+func ValidateKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return ErrInvalidKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// HashRequest fingerprints a request body, so that a key sent again with a
+// different body is rejected rather than answered with the wrong response
+func HashRequest(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// Repository stores records. Records past ExpiresAt are treated as absent
+// and may be removed at any time.
+type Repository interface {
+	// ClaimIdempotencyKey stores record as pending and returns nil. If the
+	// user already has a live record under the key, that record is returned
+	// and nothing is stored; a pending one created before staleBefore is
+	// taken over instead, as its request is assumed to have died.
+	ClaimIdempotencyKey(record Record, staleBefore time.Time) (*Record, error)
+	// CompleteIdempotencyKey stores the outcome of a claimed request
+	CompleteIdempotencyKey(username, key, expenseID string, statusCode int, response []byte) error
+	// ReleaseIdempotencyKey drops a pending record after its request failed,
+	// so that a retry runs again
+	ReleaseIdempotencyKey(username, key string) error
+}
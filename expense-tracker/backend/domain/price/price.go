@@ -0,0 +1,143 @@
+// Package price follows what an item costs per kilogram, litre, metre or
+// piece over time, so that a purchase that costs far more than usual stands
+// out.
+package price
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"time"
+
+	"expense-tracker/domain/expense"
+)
+
+var ErrNoPrices = errors.New("no prices recorded for this item")
+
+// RecentWindow is how far back the prices a new one is compared with go
+const RecentWindow = 90 * 24 * time.Hour
+
+// MinHistory is how many recent prices an item needs before a new one is
+// judged against them
+const MinHistory = 3
+
+// HighRatio is how many times the recent median a unit price must be to be
+// flagged, as a percentage
+const HighRatio = 150
+
+// Point is the unit price paid for an item in one expense. UnitPrice is in
+// minor units of the base currency per Unit, which is always a base unit
+// (kg, L, m or pcs). Item is the item name as ItemKey normalizes it; Items
+// is how the expense wrote it.
+type Point struct {
+	ExpenseID string    `json:"expenseId"`
+	Item      string    `json:"item"`
+	Items     string    `json:"items"`
+	PaidDate  time.Time `json:"paidDate"`
+	Amount    int64     `json:"amount"`
+	Quantity  string    `json:"quantity"`
+	Unit      string    `json:"unit"`
+	UnitPrice int64     `json:"unitPrice"`
+}
+
+// ItemKey is what expenses of the same item have in common: "Gạo ST25" and
+// "gạo st25" are one item
+func ItemKey(items string) string {
+	return expense.NormalizeText(items)
+}
+
+// UnitPrice divides amount by base, a quantity in a base unit, rounding
+// half up. It is false when there is nothing to divide by.
+func UnitPrice(amount int64, base expense.Quantity) (int64, bool) {
+	if base.IsZero() || amount <= 0 {
+		return 0, false
+	}
+	price := new(big.Rat).Quo(new(big.Rat).SetInt64(amount), base.Rat())
+	half := new(big.Rat).Add(price, big.NewRat(1, 2))
+	rounded := new(big.Int).Quo(half.Num(), half.Denom())
+	if !rounded.IsInt64() {
+		return 0, false
+	}
+	return rounded.Int64(), true
+}
+
+// Sort orders points by paid date, then by expense ID
+func Sort(points []Point) {
+	sort.SliceStable(points, func(i, j int) bool {
+		if !points[i].PaidDate.Equal(points[j].PaidDate) {
+			return points[i].PaidDate.Before(points[j].PaidDate)
+		}
+		return points[i].ExpenseID < points[j].ExpenseID
+	})
+}
+
+// Stats sums up a series of unit prices
+type Stats struct {
+	Count   int   `json:"count"`
+	Min     int64 `json:"min"`
+	Max     int64 `json:"max"`
+	Average int64 `json:"average"`
+	Median  int64 `json:"median"`
+}
+
+// Summarize works out the stats of points, which may be in any order
+func Summarize(points []Point) Stats {
+	if len(points) == 0 {
+		return Stats{}
+	}
+	prices := make([]int64, len(points))
+	var sum int64
+	for i, point := range points {
+		prices[i] = point.UnitPrice
+		sum += point.UnitPrice
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i] < prices[j] })
+
+	count := int64(len(prices))
+	median := prices[count/2]
+	if count%2 == 0 {
+		median = (prices[count/2-1] + prices[count/2] + 1) / 2
+	}
+	return Stats{
+		Count:   len(prices),
+		Min:     prices[0],
+		Max:     prices[count-1],
+		Average: (sum + count/2) / count,
+		Median:  median,
+	}
+}
+
+// Recent picks the points other than p's own of p's unit paid within
+// RecentWindow before p, or on the same day
+func Recent(points []Point, p Point) []Point {
+	var recent []Point
+	from := p.PaidDate.Add(-RecentWindow)
+	for _, point := range points {
+		if point.ExpenseID == p.ExpenseID || point.Unit != p.Unit {
+			continue
+		}
+		if point.PaidDate.Before(from) || point.PaidDate.After(p.PaidDate) {
+			continue
+		}
+		recent = append(recent, point)
+	}
+	return recent
+}
+
+// IsHigh tells whether unitPrice is far above median: at least HighRatio
+// percent of it
+func IsHigh(unitPrice, median int64) bool {
+	return median > 0 && unitPrice*100 >= median*HighRatio
+}
+
+// Repository keeps one point per expense that has a price
+type Repository interface {
+	// SavePrice replaces the point of point.ExpenseID
+	SavePrice(point Point) error
+	// DeletePrice removes the point of an expense, if it has one
+	DeletePrice(expenseID string) error
+	// ListPrices returns the points of one item, by paid date
+	ListPrices(item string) ([]Point, error)
+	// ReplacePrices removes every point and saves points instead
+	ReplacePrices(points []Point) error
+}
@@ -0,0 +1,101 @@
+// Package receipt records expenses from a photo of a printed receipt. The
+// image is kept alongside what was read from it and the expenses created,
+// so that a line item can be checked against the original later.
+package receipt
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxImageSize bounds an uploaded image; phone photos are well below it
+const MaxImageSize = 10 << 20
+
+var (
+	ErrNotFound        = errors.New("receipt not found")
+	ErrTooLarge        = errors.New("receipt image is larger than 10 MB")
+	ErrUnsupportedType = errors.New("receipt image must be JPEG, PNG, WebP or HEIC")
+	// ErrUnreadable means the parser found neither a total nor line items
+	ErrUnreadable = errors.New("no total or line items could be read from the receipt")
+)
+
+// contentTypes are the image types the parser accepts
+var contentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+	"image/heic": true,
+}
+
+// ValidateImage checks an upload before it is parsed or stored
+func ValidateImage(contentType string, size int64) error {
+	if !contentTypes[contentType] {
+		return ErrUnsupportedType
+	}
+	if size > MaxImageSize {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// LineItem is one line of a receipt. Amount is the line's total in VND.
+type LineItem struct {
+	Description  string `json:"description"`
+	Quantity     string `json:"quantity,omitempty"`
+	Unit         string `json:"unit,omitempty"`
+	BaseQuantity string `json:"baseQuantity,omitempty"`
+	BaseUnit     string `json:"baseUnit,omitempty"`
+	Amount       int64  `json:"amount"`
+}
+
+// Parsed is what a Parser read from a receipt image. Date is YYYY-MM-DD, or
+// empty when it could not be read.
+type Parsed struct {
+	Merchant string     `json:"merchant"`
+	Date     string     `json:"date,omitempty"`
+	Total    int64      `json:"total"`
+	Items    []LineItem `json:"items"`
+}
+
+// ItemsTotal is the sum of the line items, which can differ from Total by
+// taxes, fees or discounts
+func (p *Parsed) ItemsTotal() int64 {
+	var sum int64
+	for _, item := range p.Items {
+		sum += item.Amount
+	}
+	return sum
+}
+
+// Parser reads a receipt image. The AI provider implements it; a canned
+// fake stands in for it when trying things out without an API key.
+type Parser interface {
+	ParseReceipt(image []byte, contentType string) (*Parsed, error)
+}
+
+// Receipt is a stored receipt image and the expenses recorded from it
+type Receipt struct {
+	ID          string     `json:"id"`
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"contentType"`
+	Size        int64      `json:"size"`
+	Merchant    string     `json:"merchant"`
+	Date        string     `json:"date,omitempty"`
+	Total       int64      `json:"total"`
+	Items       []LineItem `json:"items"`
+	ExpenseIDs  []string   `json:"expenseIds"`
+	UploadedBy  string     `json:"uploadedBy"`
+	UploadedAt  time.Time  `json:"uploadedAt"`
+}
+
+type Repository interface {
+	// SaveReceipt stores the receipt and its image and sets its ID
+	SaveReceipt(r *Receipt, image []byte) error
+	// LinkReceiptExpenses records the expenses created from a receipt
+	LinkReceiptExpenses(id string, expenseIDs []string) error
+	GetReceipt(id string) (*Receipt, error)
+	// ReceiptImage returns the stored image
+	ReceiptImage(id string) ([]byte, error)
+	// FindReceiptByExpense returns the receipt an expense was recorded from
+	FindReceiptByExpense(expenseID string) (*Receipt, error)
+}
@@ -0,0 +1,35 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// DefaultInviteTTL is how long an invite code stays valid when no TTL is given
+const DefaultInviteTTL = 7 * 24 * time.Hour
+
+var ErrInviteInvalid = errors.New("invite code is invalid, expired or already used")
+
+// NewInviteCode returns a random, URL-safe single-use invite code
+func NewInviteCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+type InviteDTO struct {
+	Code      string     `json:"code"`
+	CreatedBy string     `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedBy    string     `json:"usedBy,omitempty"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
+
+func (i InviteDTO) IsUsable(now time.Time) bool {
+	return i.UsedAt == nil && now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,19 @@
+package user
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionDTO describes an active login without exposing its token
+type SessionDTO struct {
+	ID        string    `json:"id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastSeen  time.Time `json:"lastSeen"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Current   bool      `json:"current"`
+}
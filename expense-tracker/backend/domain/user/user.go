@@ -1,12 +1,62 @@
 package user
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type User struct {
 	id   string
 	name string
 }
 
+type Role string
+
+const (
+	RoleAdmin      Role = "admin"
+	RoleSupervisor Role = "supervisor"
+)
+
+// DefaultRole is the role given to a newly created account: the account
+// named "admin" administers the instance, everyone else is a supervisor
+func DefaultRole(username string) Role {
+	if username == "admin" {
+		return RoleAdmin
+	}
+	return RoleSupervisor
+}
+
+func ParseRole(value string) (Role, error) {
+	switch Role(value) {
+	case RoleAdmin, RoleSupervisor:
+		return Role(value), nil
+	}
+	return "", errors.New("invalid role: " + value)
+}
+
+// RegistrationMode controls who may create an account through /auth/register
+type RegistrationMode string
+
+const (
+	RegistrationOpen   RegistrationMode = "open"
+	RegistrationInvite RegistrationMode = "invite"
+	RegistrationClosed RegistrationMode = "closed"
+)
+
+func ParseRegistrationMode(value string) (RegistrationMode, error) {
+	switch RegistrationMode(value) {
+	case RegistrationOpen, RegistrationInvite, RegistrationClosed:
+		return RegistrationMode(value), nil
+	}
+	return "", errors.New("invalid registration mode: " + value)
+}
+
+var (
+	ErrUserExists   = errors.New("username already exists")
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserDisabled = errors.New("user is disabled")
+)
+
 func NewUser(name string) (*User, error) {
 	if name == "" {
 		return nil, errors.New("name cannot be empty")
@@ -14,4 +64,12 @@ func NewUser(name string) (*User, error) {
 	return &User{name: name}, nil
 }
 
-func (u *User) Name() string { return u.name }
\ No newline at end of file
+func (u *User) Name() string { return u.name }
+
+// DTOs for presentation layer
+type UserDTO struct {
+	Username  string    `json:"username"`
+	Role      Role      `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"createdAt"`
+}
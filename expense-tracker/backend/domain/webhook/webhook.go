@@ -0,0 +1,160 @@
+// Package webhook describes outgoing webhooks: URLs that are sent a signed
+// JSON request whenever an expense they subscribed to changes.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"expense-tracker/domain/expense"
+)
+
+var (
+	ErrNotFound     = errors.New("webhook not found")
+	ErrInvalidURL   = errors.New("webhook URL must be an absolute http or https URL")
+	ErrNoEvents     = errors.New("webhook must subscribe to at least one event")
+	ErrUnknownEvent = errors.New("unknown webhook event")
+)
+
+// EventPing is sent by the test-ping endpoint; every webhook gets it
+const EventPing expense.EventType = "ping"
+
+// Events lists the event types a webhook can subscribe to
+var Events = []expense.EventType{expense.ExpenseCreated, expense.ExpenseUpdated, expense.ExpenseDeleted, expense.ExpenseRestored}
+
+// DeliveryLogSize is how many recent deliveries are kept per webhook
+const DeliveryLogSize = 100
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Subscription is a URL and the events it is sent. Secret signs the
+// requests; it is encrypted at rest and never shown after creation.
+type Subscription struct {
+	ID        string              `json:"id"`
+	URL       string              `json:"url"`
+	Events    []expense.EventType `json:"events"`
+	Secret    string              `json:"-"`
+	CreatedBy string              `json:"createdBy"`
+	CreatedAt time.Time           `json:"createdAt"`
+}
+
+// Validate checks the URL and that every event is one of Events
+func (s Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	if len(s.Events) == 0 {
+		return ErrNoEvents
+	}
+	for _, event := range s.Events {
+		if !knownEvent(event) {
+			return ErrUnknownEvent
+		}
+	}
+	return nil
+}
+
+// Wants reports whether the webhook is sent events of type t
+func (s Subscription) Wants(t expense.EventType) bool {
+	if t == EventPing {
+		return true
+	}
+	for _, event := range s.Events {
+		if event == t {
+			return true
+		}
+	}
+	return false
+}
+
+func knownEvent(t expense.EventType) bool {
+	for _, event := range Events {
+		if event == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one attempt at sending an event to a webhook. Attempts at
+// the same event share DeliveryID, which is also sent in HeaderDelivery
+// so that receivers can ignore repeats.
+type Delivery struct {
+	ID             string            `json:"id,omitempty"`
+	WebhookID      string            `json:"webhookId"`
+	DeliveryID     string            `json:"deliveryId"`
+	Event          expense.EventType `json:"event"`
+	Attempt        int               `json:"attempt"`
+	StatusCode     int               `json:"statusCode,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	DurationMillis int64             `json:"durationMs"`
+	Time           time.Time         `json:"time"`
+}
+
+// Succeeded reports whether the receiver answered with a 2xx status
+func (d Delivery) Succeeded() bool {
+	return d.StatusCode >= 200 && d.StatusCode < 300
+}
+
+// NewSecret returns a random signing secret
+func NewSecret() (string, error) {
+	return randomHex(32)
+}
+
+// NewDeliveryID returns a random ID for the attempts at one event
+func NewDeliveryID() (string, error) {
+	return randomHex(16)
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Sign returns the HeaderSignature value for body sent at timestamp (Unix
+// seconds): "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with secret. Receivers compute the same and compare, and reject
+// old timestamps to stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is Sign(secret, timestamp, body),
+// comparing in constant time
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Repository stores webhooks, with their secrets decrypted on the way out,
+// and a log of their recent deliveries
+type Repository interface {
+	CreateWebhook(subscription Subscription) (string, error)
+	GetWebhook(id string) (*Subscription, error)
+	ListWebhooks() ([]Subscription, error)
+	// DeleteWebhook removes the webhook and its delivery log
+	DeleteWebhook(id string) error
+	// RecordDelivery appends to the log, keeping DeliveryLogSize per webhook
+	RecordDelivery(delivery Delivery) error
+	// ListDeliveries returns a webhook's logged deliveries, newest first
+	ListDeliveries(webhookID string) ([]Delivery, error)
+}
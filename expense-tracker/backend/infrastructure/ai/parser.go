@@ -90,25 +90,31 @@ func NewMessageParser(repo APIKeyRepository) *MessageParser {
 	}
 }
 
+// refreshClient creates the client once an API key has been saved in the
+// settings page
+func (p *MessageParser) refreshClient() {
+	if p.client != nil {
+		return
+	}
+	apiKey, _ := p.repo.GetAPIKey()
+	if apiKey == "" {
+		return
+	}
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err == nil {
+		p.client = client
+		log.Printf("[AI] Gemini client created from MongoDB key")
+	}
+}
+
 func (p *MessageParser) Parse(message string) (string, int64, string, string, string, string, string, time.Time, error) {
 	log.Printf("[AI] Parsing message: %s", message)
 	
-	// Refresh client if needed
-	if p.client == nil {
-		apiKey, _ := p.repo.GetAPIKey()
-		if apiKey != "" {
-			ctx := context.Background()
-			client, err := genai.NewClient(ctx, &genai.ClientConfig{
-				APIKey:  apiKey,
-				Backend: genai.BackendGeminiAPI,
-			})
-			if err == nil {
-				p.client = client
-				log.Printf("[AI] Gemini client created from MongoDB key")
-			}
-		}
-	}
-	
+	p.refreshClient()
 	if p.client == nil {
 		log.Printf("[AI] No Gemini client available, returning basic parse")
 		return strings.Title(strings.ToLower(message)), 1, "", "", "", "", message, time.Now(), nil
@@ -129,7 +135,7 @@ Current date: ` + currentDate + `
 Message: "` + message + `"
 
 Return ONLY valid JSON with this exact structure:
-{"items": "description", "amount": number_in_VND, "quantity": "display_number", "unit": "display_unit", "baseQuantity": "base_number", "baseUnit": "iso_unit", "paidDate": "YYYY-MM-DD"}
+{"items": "description", "amount": number_in_VND_or_stated_currency, "quantity": "display_number", "unit": "display_unit", "baseQuantity": "base_number", "baseUnit": "iso_unit", "paidDate": "YYYY-MM-DD"}
 
 IMPORTANT: You MUST include baseQuantity and baseUnit fields in your response!
 
@@ -147,10 +153,16 @@ Rules:
 Base units (ISO): kg (mass), L (volume), m (length), pcs (count)
 Conversions: 1000g=1kg, 1000ml=1L, 100cm=1m
 
+Refunds ("hoàn tiền", "được trả lại"): amount is still positive and items is what was refunded, without those words
+
+Other currencies ("20 usd", "$12.50", "300 baht"): do NOT convert to VND; amount is in the smallest unit of that currency as written, cents for USD/EUR ($12.50 → 1250), whole units for JPY/KRW
+
 Examples:
 "2 bao cà phê 0.5kg 200k" → {"items": "Cà phê", "amount": 200000, "quantity": "2", "unit": "bao", "baseQuantity": "1", "baseUnit": "kg", "paidDate": "` + currentDate + `"}
 "500g thịt 150k" → {"items": "Thịt", "amount": 150000, "quantity": "500", "unit": "g", "baseQuantity": "0.5", "baseUnit": "kg", "paidDate": "` + currentDate + `"}
-"3kg gạo 180k" → {"items": "Gạo", "amount": 180000, "quantity": "3", "unit": "kg", "baseQuantity": "3", "baseUnit": "kg", "paidDate": "` + currentDate + `"}`
+"3kg gạo 180k" → {"items": "Gạo", "amount": 180000, "quantity": "3", "unit": "kg", "baseQuantity": "3", "baseUnit": "kg", "paidDate": "` + currentDate + `"}
+"hoàn tiền áo khoác 200k" → {"items": "Áo khoác", "amount": 200000, "quantity": "", "unit": "", "baseQuantity": "", "baseUnit": "", "paidDate": "` + currentDate + `"}
+"khách sạn $12.50" → {"items": "Khách sạn", "amount": 1250, "quantity": "", "unit": "", "baseQuantity": "", "baseUnit": "", "paidDate": "` + currentDate + `"}`
 
 	log.Printf("[AI] Calling Gemini API...")
 	
@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"expense-tracker/domain/receipt"
+	"google.golang.org/genai"
+)
+
+// ErrNoClient means no Gemini API key is configured. Unlike text messages,
+// receipts have no fallback parse.
+var ErrNoClient = errors.New("no Gemini API key configured")
+
+const receiptPrompt = `Read this Vietnamese shop receipt and return ONLY valid JSON:
+{"merchant": "shop name", "date": "YYYY-MM-DD", "total": number_in_VND, "items": [{"description": "item", "quantity": "display_number", "unit": "display_unit", "baseQuantity": "base_number", "baseUnit": "iso_unit", "amount": line_total_in_VND}]}
+
+Rules:
+- "total" is the amount paid, after taxes and discounts
+- "amount" is the line total (quantity x unit price), not the unit price
+- Amounts are whole VND: "180.000" and "180,000" are 180000
+- Leave "date" empty when the receipt shows none; today is %s
+- Write item descriptions in Vietnamese with normal capitalisation, e.g. "Gạo ST25"
+- Base units (ISO): kg (mass), L (volume), m (length), pcs (count); 500g → baseQuantity "0.5", baseUnit "kg"
+- Leave quantity and unit fields empty when the line has none`
+
+// ParseReceipt implements receipt.Parser by sending the image to Gemini
+// together with the prompt
+func (p *MessageParser) ParseReceipt(image []byte, contentType string) (*receipt.Parsed, error) {
+	p.refreshClient()
+	if p.client == nil {
+		return nil, ErrNoClient
+	}
+
+	if time.Since(p.lastCall) < 1*time.Second {
+		time.Sleep(1*time.Second - time.Since(p.lastCall))
+	}
+	p.lastCall = time.Now()
+
+	log.Printf("[AI] Parsing %s receipt (%d bytes)", contentType, len(image))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	prompt := fmt.Sprintf(receiptPrompt, time.Now().Format("2006-01-02"))
+	contents := []*genai.Content{genai.NewContentFromParts([]*genai.Part{
+		genai.NewPartFromBytes(image, contentType),
+		genai.NewPartFromText(prompt),
+	}, genai.RoleUser)}
+	result, err := p.client.Models.GenerateContent(ctx, "models/gemini-2.5-flash", contents,
+		&genai.GenerateContentConfig{ResponseMIMEType: "application/json"})
+	if err != nil {
+		return nil, fmt.Errorf("gemini: %w", err)
+	}
+
+	responseText := strings.TrimSpace(result.Text())
+	log.Printf("[AI] Gemini receipt response: %s", responseText)
+	responseText = strings.TrimPrefix(responseText, "```json")
+	responseText = strings.TrimPrefix(responseText, "```")
+	responseText = strings.TrimSuffix(responseText, "```")
+
+	var parsed receipt.Parsed
+	if err := json.Unmarshal([]byte(strings.TrimSpace(responseText)), &parsed); err != nil {
+		return nil, fmt.Errorf("gemini returned invalid JSON: %w", err)
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,121 @@
+// Package eventbus fans expense events out to the subscribers in this
+// process, such as open /api/stream connections.
+package eventbus
+
+import (
+	"log"
+	"sync"
+
+	"expense-tracker/domain/expense"
+)
+
+const (
+	// historySize is how many recent events are kept for subscribers that
+	// reconnect with the ID of the last event they saw
+	historySize = 256
+	// subscriberBuffer is how many events a subscriber may fall behind
+	// before it is dropped
+	subscriberBuffer = 64
+)
+
+// Envelope is an event with the sequence number the bus gave it
+type Envelope struct {
+	ID    uint64
+	Event expense.Event
+}
+
+// Subscription delivers events on C. C is closed when the subscription is
+// closed or when the subscriber fell too far behind; in the latter case
+// the subscriber has missed events and should reload.
+type Subscription struct {
+	C   <-chan Envelope
+	bus *Bus
+	id  int
+}
+
+// Close stops delivery; it is safe to call more than once
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s.id)
+}
+
+type Bus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Envelope
+	subscribers map[int]chan Envelope
+	nextSub     int
+}
+
+func New() *Bus {
+	return &Bus{subscribers: make(map[int]chan Envelope)}
+}
+
+// Publish numbers event and hands it to every subscriber without waiting
+func (b *Bus) Publish(event expense.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	envelope := Envelope{ID: b.lastID, Event: event}
+	b.history = append(b.history, envelope)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for id, ch := range b.subscribers {
+		select {
+		case ch <- envelope:
+		default:
+			log.Printf("[EVENTS] Subscriber %d fell behind, dropping it", id)
+			close(ch)
+			delete(b.subscribers, id)
+		}
+	}
+}
+
+// HandleEvent lets the bus subscribe to a Dispatcher; only expense
+// changes are streamed
+func (b *Bus) HandleEvent(event expense.Event) error {
+	if event.IsExpenseChange() {
+		b.Publish(event)
+	}
+	return nil
+}
+
+// Subscribe starts delivery of new events. With lastID > 0 the events
+// published after it are returned as backlog; missed is true when some of
+// them are no longer kept.
+func (b *Bus) Subscribe(lastID uint64) (sub *Subscription, backlog []Envelope, missed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case lastID == 0 || lastID == b.lastID:
+	case lastID > b.lastID:
+		// Numbering restarts with the process, so the subscriber saw
+		// events from before a restart
+		missed = true
+	default:
+		missed = len(b.history) == 0 || b.history[0].ID > lastID+1
+		for _, envelope := range b.history {
+			if envelope.ID > lastID {
+				backlog = append(backlog, envelope)
+			}
+		}
+	}
+
+	b.nextSub++
+	ch := make(chan Envelope, subscriberBuffer)
+	b.subscribers[b.nextSub] = ch
+	return &Subscription{C: ch, bus: b, id: b.nextSub}, backlog, missed
+}
+
+func (b *Bus) unsubscribe(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ch, ok := b.subscribers[id]; ok {
+		close(ch)
+		delete(b.subscribers, id)
+	}
+}
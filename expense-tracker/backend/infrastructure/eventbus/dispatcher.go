@@ -0,0 +1,92 @@
+package eventbus
+
+import (
+	"log"
+	"sync"
+
+	"expense-tracker/domain/expense"
+)
+
+// asyncQueue is how many events an asynchronous subscriber may have
+// waiting before further events for it are dropped
+const asyncQueue = 256
+
+// Delivery says how a subscriber gets its events
+type Delivery int
+
+const (
+	// Sync calls the subscriber before Publish returns, for quick work that
+	// must see events in order with the change, such as the stream bus
+	Sync Delivery = iota
+	// Async queues events for the subscriber's own goroutine, for slow work
+	// such as network calls
+	Async
+)
+
+type subscriber struct {
+	name    string
+	handler expense.Subscriber
+	queue   chan expense.Event
+}
+
+// Dispatcher hands every published event to its subscribers. A subscriber
+// that returns an error or panics is logged and skipped; the others and
+// the publisher carry on.
+type Dispatcher struct {
+	mu          sync.RWMutex
+	subscribers []*subscriber
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Subscribe adds handler under name, used in logs
+func (d *Dispatcher) Subscribe(name string, handler expense.Subscriber, delivery Delivery) {
+	sub := &subscriber{name: name, handler: handler}
+	if delivery == Async {
+		sub.queue = make(chan expense.Event, asyncQueue)
+		go run(sub)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscribers = append(d.subscribers, sub)
+}
+
+// Publish delivers event to the synchronous subscribers in the order they
+// subscribed and queues it for the asynchronous ones
+func (d *Dispatcher) Publish(event expense.Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, sub := range d.subscribers {
+		if sub.queue == nil {
+			deliver(sub, event)
+			continue
+		}
+		select {
+		case sub.queue <- event:
+		default:
+			log.Printf("[EVENTS] %s is %d events behind, dropping %s event", sub.name, asyncQueue, event.Type)
+		}
+	}
+}
+
+func run(sub *subscriber) {
+	for event := range sub.queue {
+		deliver(sub, event)
+	}
+}
+
+// deliver calls the subscriber, keeping its errors and panics to itself
+func deliver(sub *subscriber, event expense.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[EVENTS] %s panicked on %s event: %v", sub.name, event.Type, r)
+		}
+	}()
+	if err := sub.handler.HandleEvent(event); err != nil {
+		log.Printf("[EVENTS] %s failed on %s event: %v", sub.name, event.Type, err)
+	}
+}
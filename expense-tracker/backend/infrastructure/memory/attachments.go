@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"sort"
+
+	"expense-tracker/domain/attachment"
+)
+
+type attachmentRecord struct {
+	attachment attachment.Attachment
+	data       []byte
+}
+
+func (r *Repository) SaveAttachment(a *attachment.Attachment, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a.ID = r.newID()
+	a.Size = int64(len(data))
+	r.attachments[a.ID] = &attachmentRecord{
+		attachment: *a,
+		data:       append([]byte(nil), data...),
+	}
+	return nil
+}
+
+func (r *Repository) GetAttachment(id string) (*attachment.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.attachments[id]
+	if !ok {
+		return nil, attachment.ErrNotFound
+	}
+	a := stored.attachment
+	return &a, nil
+}
+
+func (r *Repository) AttachmentData(id string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.attachments[id]
+	if !ok {
+		return nil, attachment.ErrNotFound
+	}
+	return stored.data, nil
+}
+
+func (r *Repository) ListAttachments(expenseID string) ([]attachment.Attachment, error) {
+	return r.listAttachments(func(a attachment.Attachment) bool { return a.ExpenseID == expenseID })
+}
+
+func (r *Repository) ListAllAttachments() ([]attachment.Attachment, error) {
+	return r.listAttachments(func(attachment.Attachment) bool { return true })
+}
+
+// listAttachments returns the matching attachments in upload order
+func (r *Repository) listAttachments(match func(attachment.Attachment) bool) ([]attachment.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := []attachment.Attachment{}
+	for _, stored := range r.attachments {
+		if match(stored.attachment) {
+			list = append(list, stored.attachment)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].UploadedAt.Equal(list[j].UploadedAt) {
+			return list[i].UploadedAt.Before(list[j].UploadedAt)
+		}
+		return idLess(list[i].ID, list[j].ID)
+	})
+	return list, nil
+}
+
+func (r *Repository) DeleteAttachment(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.attachments[id]; !ok {
+		return attachment.ErrNotFound
+	}
+	delete(r.attachments, id)
+	return nil
+}
+
+// removeAttachmentsOf drops the files of a purged expense; the caller holds
+// the lock
+func (r *Repository) removeAttachmentsOf(expenseID string) {
+	for id, stored := range r.attachments {
+		if stored.attachment.ExpenseID == expenseID {
+			delete(r.attachments, id)
+		}
+	}
+}
+
+// idLess orders the numeric IDs handed out by newID
+func idLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
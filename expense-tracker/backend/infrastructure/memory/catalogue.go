@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"sort"
+
+	"expense-tracker/domain/catalogue"
+)
+
+func copyItem(item catalogue.Item) catalogue.Item {
+	item.Aliases = append([]string{}, item.Aliases...)
+	return item
+}
+
+func (r *Repository) CreateItem(item catalogue.Item) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item = copyItem(item)
+	item.ID = r.newID()
+	r.items[item.ID] = item
+	return item.ID, nil
+}
+
+func (r *Repository) GetItem(id string) (*catalogue.Item, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	item, ok := r.items[id]
+	if !ok {
+		return nil, catalogue.ErrNotFound
+	}
+	found := copyItem(item)
+	return &found, nil
+}
+
+func (r *Repository) ListItems() ([]catalogue.Item, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]catalogue.Item, 0, len(r.items))
+	for _, item := range r.items {
+		items = append(items, copyItem(item))
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Name != items[j].Name {
+			return items[i].Name < items[j].Name
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items, nil
+}
+
+func (r *Repository) UpdateItem(item catalogue.Item) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.items[item.ID]; !ok {
+		return catalogue.ErrNotFound
+	}
+	r.items[item.ID] = copyItem(item)
+	return nil
+}
+
+func (r *Repository) DeleteItem(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.items[id]; !ok {
+		return catalogue.ErrNotFound
+	}
+	delete(r.items, id)
+	return nil
+}
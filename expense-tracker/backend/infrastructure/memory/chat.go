@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"expense-tracker/domain/chat"
+)
+
+func chatLinkKey(provider, chatUserID string) string {
+	return provider + "\x00" + chatUserID
+}
+
+func (r *Repository) CreateLinkCode(code chat.LinkCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, existing := range r.linkCodes {
+		if !existing.ExpiresAt.After(code.CreatedAt) {
+			delete(r.linkCodes, k)
+		}
+	}
+	r.linkCodes[code.Code] = code
+	return nil
+}
+
+func (r *Repository) ConsumeLinkCode(code string, now time.Time) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.linkCodes[code]
+	if !ok {
+		return "", chat.ErrLinkCodeInvalid
+	}
+	delete(r.linkCodes, code)
+	if !existing.ExpiresAt.After(now) {
+		return "", chat.ErrLinkCodeInvalid
+	}
+	return existing.Username, nil
+}
+
+func (r *Repository) SaveChatLink(link chat.Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.chatLinks[chatLinkKey(link.Provider, link.ChatUserID)] = link
+	return nil
+}
+
+func (r *Repository) FindChatLink(provider, chatUserID string) (*chat.Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	link, ok := r.chatLinks[chatLinkKey(provider, chatUserID)]
+	if !ok {
+		return nil, chat.ErrNotLinked
+	}
+	return &link, nil
+}
+
+func (r *Repository) ListChatLinks(username string) ([]chat.Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var links []chat.Link
+	for _, link := range r.chatLinks {
+		if link.Username == username {
+			links = append(links, link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].LinkedAt.Before(links[j].LinkedAt) })
+	return links, nil
+}
+
+func (r *Repository) DeleteChatLink(username, provider, chatUserID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := chatLinkKey(provider, chatUserID)
+	if link, ok := r.chatLinks[key]; !ok || link.Username != username {
+		return chat.ErrNotLinked
+	}
+	delete(r.chatLinks, key)
+	return nil
+}
@@ -0,0 +1,23 @@
+package memory
+
+import "sort"
+
+func (r *Repository) DismissDuplicate(pairKey, dismissedBy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dismissed[pairKey] = true
+	return nil
+}
+
+func (r *Repository) ListDismissedDuplicates() ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]string, 0, len(r.dismissed))
+	for key := range r.dismissed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
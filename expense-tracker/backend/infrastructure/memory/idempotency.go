@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"time"
+
+	"expense-tracker/domain/idempotency"
+)
+
+func idempotencyKey(username, key string) string {
+	return username + "\x00" + key
+}
+
+func (r *Repository) ClaimIdempotencyKey(record idempotency.Record, staleBefore time.Time) (*idempotency.Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, existing := range r.idempotency {
+		if !existing.ExpiresAt.After(record.CreatedAt) {
+			delete(r.idempotency, k)
+		}
+	}
+
+	k := idempotencyKey(record.Username, record.Key)
+	if existing, ok := r.idempotency[k]; ok && (existing.Completed || !existing.CreatedAt.Before(staleBefore)) {
+		found := *existing
+		return &found, nil
+	}
+
+	record.Completed = false
+	r.idempotency[k] = &record
+	return nil, nil
+}
+
+func (r *Repository) CompleteIdempotencyKey(username, key, expenseID string, statusCode int, response []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.idempotency[idempotencyKey(username, key)]
+	if !ok {
+		return nil
+	}
+	record.Completed = true
+	record.ExpenseID = expenseID
+	record.StatusCode = statusCode
+	record.Response = append([]byte(nil), response...)
+	return nil
+}
+
+func (r *Repository) ReleaseIdempotencyKey(username, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := idempotencyKey(username, key)
+	if record, ok := r.idempotency[k]; ok && !record.Completed {
+		delete(r.idempotency, k)
+	}
+	return nil
+}
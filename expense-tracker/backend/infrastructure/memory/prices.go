@@ -0,0 +1,44 @@
+package memory
+
+import "expense-tracker/domain/price"
+
+func (r *Repository) SavePrice(point price.Point) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prices[point.ExpenseID] = point
+	return nil
+}
+
+func (r *Repository) DeletePrice(expenseID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.prices, expenseID)
+	return nil
+}
+
+func (r *Repository) ListPrices(item string) ([]price.Point, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	points := []price.Point{}
+	for _, point := range r.prices {
+		if point.Item == item {
+			points = append(points, point)
+		}
+	}
+	price.Sort(points)
+	return points, nil
+}
+
+func (r *Repository) ReplacePrices(points []price.Point) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prices = make(map[string]price.Point, len(points))
+	for _, point := range points {
+		r.prices[point.ExpenseID] = point
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"time"
+
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/expense"
+)
+
+func rateKey(currency expense.Currency, date time.Time) string {
+	return string(currency) + "\x00" + date.Format(exchange.DateLayout)
+}
+
+func (r *Repository) SaveRate(rate exchange.Rate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rates[rateKey(rate.Currency, rate.Date)] = rate
+	return nil
+}
+
+func (r *Repository) ListRates() ([]exchange.Rate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rates := make([]exchange.Rate, 0, len(r.rates))
+	for _, rate := range r.rates {
+		rates = append(rates, rate)
+	}
+	exchange.Sort(rates)
+	return rates, nil
+}
+
+func (r *Repository) DeleteRate(currency expense.Currency, date time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := rateKey(currency, date)
+	if _, ok := r.rates[key]; !ok {
+		return exchange.ErrNotFound
+	}
+	delete(r.rates, key)
+	return nil
+}
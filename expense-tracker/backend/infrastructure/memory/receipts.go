@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"expense-tracker/domain/receipt"
+)
+
+type receiptRecord struct {
+	receipt receipt.Receipt
+	image   []byte
+}
+
+// copyReceipt keeps callers from changing stored slices
+func copyReceipt(r receipt.Receipt) *receipt.Receipt {
+	r.Items = append([]receipt.LineItem(nil), r.Items...)
+	r.ExpenseIDs = append([]string(nil), r.ExpenseIDs...)
+	return &r
+}
+
+func (r *Repository) SaveReceipt(rec *receipt.Receipt, image []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec.ID = r.newID()
+	r.receipts[rec.ID] = &receiptRecord{
+		receipt: *copyReceipt(*rec),
+		image:   append([]byte(nil), image...),
+	}
+	return nil
+}
+
+func (r *Repository) LinkReceiptExpenses(id string, expenseIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.receipts[id]
+	if !ok {
+		return receipt.ErrNotFound
+	}
+	stored.receipt.ExpenseIDs = append([]string(nil), expenseIDs...)
+	return nil
+}
+
+func (r *Repository) GetReceipt(id string) (*receipt.Receipt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.receipts[id]
+	if !ok {
+		return nil, receipt.ErrNotFound
+	}
+	return copyReceipt(stored.receipt), nil
+}
+
+func (r *Repository) ReceiptImage(id string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.receipts[id]
+	if !ok {
+		return nil, receipt.ErrNotFound
+	}
+	return stored.image, nil
+}
+
+func (r *Repository) FindReceiptByExpense(expenseID string) (*receipt.Receipt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.receipts {
+		for _, id := range stored.receipt.ExpenseIDs {
+			if id == expenseID {
+				return copyReceipt(stored.receipt), nil
+			}
+		}
+	}
+	return nil, receipt.ErrNotFound
+}
@@ -0,0 +1,586 @@
+package memory
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/backup"
+	"expense-tracker/domain/catalogue"
+	"expense-tracker/domain/chat"
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/idempotency"
+	"expense-tracker/domain/price"
+	domainuser "expense-tracker/domain/user"
+	"expense-tracker/domain/webhook"
+	"expense-tracker/infrastructure/secrets"
+	"expense-tracker/infrastructure/sessionstore"
+)
+
+// Repository keeps everything in process memory. Data is lost on restart,
+// which makes it suitable for tests and demos only.
+type Repository struct {
+	mu       sync.RWMutex
+	nextID   int
+	expenses []*expenseRecord
+	settings map[string]string
+	users    map[string]*userRecord
+	invites  map[string]*domainuser.InviteDTO
+	sessions map[string]*sessionstore.Record
+	audit    []audit.Entry
+	secrets  *secrets.Box
+
+	// idempotency is keyed by idempotencyKey(username, key)
+	idempotency map[string]*idempotency.Record
+	dismissed   map[string]bool
+	webhooks    map[string]*webhook.Subscription
+	// deliveries holds each webhook's delivery log, oldest first
+	deliveries map[string][]webhook.Delivery
+	linkCodes  map[string]chat.LinkCode
+	// chatLinks is keyed by chatLinkKey(provider, chatUserID)
+	chatLinks map[string]chat.Link
+	receipts  map[string]*receiptRecord
+	// attachments is keyed by attachment ID
+	attachments map[string]*attachmentRecord
+	// rates is keyed by rateKey(currency, date)
+	rates map[string]exchange.Rate
+	// prices is keyed by expense ID
+	prices map[string]price.Point
+	// items is the catalogue, keyed by item ID
+	items map[string]catalogue.Item
+}
+
+type expenseRecord struct {
+	ID              string
+	Items           string
+	Amount          int64
+	Currency        string
+	OriginalAmount  int64
+	Rate            string
+	Quantity        string
+	Unit            string
+	BaseQuantity    string
+	BaseUnit        string
+	OriginalMessage string
+	PaidDate        time.Time
+	PaidBy          string
+	Kind            string
+	PaidTo          string
+	Tags            []string
+	Notes           string
+	Status          string
+	DeletedDate     *time.Time
+	Version         int64
+}
+
+// NewRepository returns an empty repository. Secrets such as the API key are
+// encrypted with box; a nil box means they cannot be saved.
+func NewRepository(box *secrets.Box) *Repository {
+	return &Repository{
+		settings: make(map[string]string),
+		users:    make(map[string]*userRecord),
+		invites:  make(map[string]*domainuser.InviteDTO),
+		sessions: make(map[string]*sessionstore.Record),
+		secrets:  box,
+
+		idempotency: make(map[string]*idempotency.Record),
+		dismissed:   make(map[string]bool),
+		webhooks:    make(map[string]*webhook.Subscription),
+		deliveries:  make(map[string][]webhook.Delivery),
+		linkCodes:   make(map[string]chat.LinkCode),
+		chatLinks:   make(map[string]chat.Link),
+		receipts:    make(map[string]*receiptRecord),
+		attachments: make(map[string]*attachmentRecord),
+		rates:       make(map[string]exchange.Rate),
+		prices:      make(map[string]price.Point),
+		items:       make(map[string]catalogue.Item),
+	}
+}
+
+func (r *Repository) newID() string {
+	r.nextID++
+	return strconv.Itoa(r.nextID)
+}
+
+func (rec *expenseRecord) toMap(idKey string) map[string]interface{} {
+	return map[string]interface{}{
+		idKey:             rec.ID,
+		"items":           rec.Items,
+		"amount":          rec.Amount,
+		"currency":        rec.Currency,
+		"originalAmount":  rec.original(),
+		"rate":            rec.Rate,
+		"quantity":        rec.Quantity,
+		"unit":            rec.Unit,
+		"baseQuantity":    rec.BaseQuantity,
+		"baseUnit":        rec.BaseUnit,
+		"originalMessage": rec.OriginalMessage,
+		"paidDate":        rec.PaidDate.Format("2006-01-02"),
+		"paidBy":          rec.PaidBy,
+		"kind":            string(expense.KindOf(rec.Kind)),
+		"paidTo":          rec.PaidTo,
+		"tags":            append([]string{}, rec.Tags...),
+		"notes":           rec.Notes,
+		"version":         rec.Version,
+	}
+}
+
+func (rec *expenseRecord) toExpense() *expense.Expense {
+	exp := expense.NewExpenseWithDate(rec.Items, rec.Amount, rec.PaidBy, rec.PaidDate)
+	exp.SetID(rec.ID)
+	exp.SetVersion(rec.Version)
+	exp.SetQuantityUnit(rec.Quantity, rec.Unit)
+	exp.SetBaseQuantityUnit(rec.BaseQuantity, rec.BaseUnit)
+	exp.SetOriginalMessage(rec.OriginalMessage)
+	exp.SetKind(expense.KindOf(rec.Kind), rec.PaidTo)
+	exp.SetTags(rec.Tags)
+	exp.SetNotes(rec.Notes)
+	if rec.Currency != "" {
+		exp.SetOriginal(expense.NewMoneyIn(rec.OriginalAmount, expense.Currency(rec.Currency)), rec.Rate)
+	}
+	if rec.Status == string(expense.StatusDeleted) {
+		exp.Delete()
+	}
+	return exp
+}
+
+// original is the amount as paid; expenses stored before currencies existed
+// were paid in the base currency
+func (rec *expenseRecord) original() int64 {
+	if rec.Currency == "" {
+		return rec.Amount
+	}
+	return rec.OriginalAmount
+}
+
+func (r *Repository) find(id string) *expenseRecord {
+	for _, rec := range r.expenses {
+		if rec.ID == id {
+			return rec
+		}
+	}
+	return nil
+}
+
+func (r *Repository) Save(exp *expense.Expense) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec := &expenseRecord{
+		ID:              r.newID(),
+		Items:           exp.Items(),
+		Amount:          exp.Amount(),
+		Currency:        string(exp.Original().Currency()),
+		OriginalAmount:  exp.Original().Value(),
+		Rate:            exp.Rate(),
+		Quantity:        exp.Quantity(),
+		Unit:            exp.Unit(),
+		BaseQuantity:    exp.BaseQuantity(),
+		BaseUnit:        exp.BaseUnit(),
+		OriginalMessage: exp.OriginalMessage(),
+		PaidDate:        exp.PaidDate(),
+		PaidBy:          exp.PaidBy(),
+		Kind:            string(exp.Kind()),
+		PaidTo:          exp.PaidTo(),
+		Tags:            exp.Tags(),
+		Notes:           exp.Notes(),
+		Status:          string(expense.StatusActive),
+		Version:         1,
+	}
+	r.expenses = append(r.expenses, rec)
+	exp.SetID(rec.ID)
+	exp.SetVersion(rec.Version)
+	return nil
+}
+
+func (r *Repository) FindByID(id int) (*expense.Expense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rec := r.find(strconv.Itoa(id))
+	if rec == nil {
+		return nil, expense.ErrExpenseNotFound
+	}
+	return rec.toExpense(), nil
+}
+
+func (r *Repository) GetByID(id string) (map[string]interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rec := r.find(id)
+	if rec == nil {
+		return nil, expense.ErrExpenseNotFound
+	}
+
+	result := rec.toMap("id")
+	result["status"] = rec.Status
+	if rec.DeletedDate != nil {
+		result["deletedDate"] = rec.DeletedDate.Format("2006-01-02")
+	}
+	return result, nil
+}
+
+func (r *Repository) FindAll() ([]*expense.Expense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var expenses []*expense.Expense
+	for _, rec := range r.expenses {
+		expenses = append(expenses, rec.toExpense())
+	}
+	return expenses, nil
+}
+
+func (r *Repository) FindActiveExpenses() ([]*expense.Expense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var expenses []*expense.Expense
+	for _, rec := range r.expenses {
+		if rec.Status != string(expense.StatusDeleted) {
+			expenses = append(expenses, rec.toExpense())
+		}
+	}
+	return expenses, nil
+}
+
+func (r *Repository) GetAll() ([]map[string]interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var expenses []map[string]interface{}
+	for _, rec := range r.expenses {
+		if rec.Status != string(expense.StatusDeleted) {
+			expenses = append(expenses, rec.toMap("no"))
+		}
+	}
+	return expenses, nil
+}
+
+func (r *Repository) GetSummaryByPaidBy() (map[string]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	summary := make(map[string]int64)
+	for _, rec := range r.expenses {
+		if rec.Status == string(expense.StatusDeleted) {
+			continue
+		}
+		summary[rec.PaidBy] += rec.Amount
+		if expense.KindOf(rec.Kind) == expense.KindTransfer {
+			summary[rec.PaidTo] -= rec.Amount
+		}
+	}
+	return summary, nil
+}
+
+// findVersion returns the expense with id if it is at version and has
+// status, or the error explaining why not
+func (r *Repository) findVersion(id string, version int64, status expense.Status) (*expenseRecord, error) {
+	rec := r.find(id)
+	if rec == nil {
+		return nil, expense.ErrExpenseNotFound
+	}
+	if rec.Version != version {
+		return nil, expense.ErrVersionConflict
+	}
+	if rec.Status != string(status) {
+		if status == expense.StatusDeleted {
+			return nil, expense.ErrExpenseNotDeleted
+		}
+		return nil, expense.ErrExpenseDeleted
+	}
+	return rec, nil
+}
+
+func (r *Repository) Update(id string, version int64, changes expense.Changes) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, err := r.findVersion(id, version, expense.StatusActive)
+	if err != nil {
+		return err
+	}
+	setString := func(field *string, value *string) {
+		if value != nil {
+			*field = *value
+		}
+	}
+	setString(&rec.Items, changes.Items)
+	setString(&rec.Quantity, changes.Quantity)
+	setString(&rec.Unit, changes.Unit)
+	setString(&rec.BaseQuantity, changes.BaseQuantity)
+	setString(&rec.BaseUnit, changes.BaseUnit)
+	setString(&rec.PaidBy, changes.PaidBy)
+	setString(&rec.PaidTo, changes.PaidTo)
+	if changes.Kind != nil {
+		rec.Kind = string(*changes.Kind)
+	}
+	if changes.Amount != nil {
+		rec.Amount = *changes.Amount
+	}
+	if changes.Currency != nil {
+		rec.Currency = string(*changes.Currency)
+	}
+	if changes.OriginalAmount != nil {
+		rec.OriginalAmount = *changes.OriginalAmount
+	}
+	setString(&rec.Rate, changes.Rate)
+	if changes.Tags != nil {
+		rec.Tags = append([]string{}, *changes.Tags...)
+	}
+	setString(&rec.Notes, changes.Notes)
+	if changes.PaidDate != nil {
+		rec.PaidDate = *changes.PaidDate
+	}
+	rec.Version++
+	return nil
+}
+
+func (r *Repository) Delete(id string, version int64) error {
+	if id == "" {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, err := r.findVersion(id, version, expense.StatusActive)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	rec.Status = string(expense.StatusDeleted)
+	rec.DeletedDate = &now
+	rec.Version++
+	return nil
+}
+
+func (r *Repository) Restore(id string, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, err := r.findVersion(id, version, expense.StatusDeleted)
+	if err != nil {
+		return err
+	}
+	rec.Status = string(expense.StatusActive)
+	rec.DeletedDate = nil
+	rec.Version++
+	return nil
+}
+
+func (r *Repository) GetDeleted() ([]map[string]interface{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var expenses []map[string]interface{}
+	for _, rec := range r.expenses {
+		if rec.Status != string(expense.StatusDeleted) {
+			continue
+		}
+		item := rec.toMap("id")
+		item["deletedDate"] = "N/A"
+		if rec.DeletedDate != nil {
+			item["deletedDate"] = rec.DeletedDate.Format("2006-01-02")
+		}
+		expenses = append(expenses, item)
+	}
+	return expenses, nil
+}
+
+func (r *Repository) ClearAll() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log.Printf("[MEMORY] Clearing %d expenses", len(r.expenses))
+	r.expenses = nil
+	r.attachments = make(map[string]*attachmentRecord)
+	return nil
+}
+
+func (r *Repository) Purge(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, rec := range r.expenses {
+		if rec.ID != id {
+			continue
+		}
+		if rec.Status != string(expense.StatusDeleted) {
+			return expense.ErrExpenseNotDeleted
+		}
+		r.expenses = append(r.expenses[:i], r.expenses[i+1:]...)
+		r.removeAttachmentsOf(id)
+		return nil
+	}
+	return expense.ErrExpenseNotFound
+}
+
+func (r *Repository) PurgeDeleted(before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.expenses[:0]
+	purged := 0
+	for _, rec := range r.expenses {
+		expired := rec.Status == string(expense.StatusDeleted) &&
+			(before.IsZero() || (rec.DeletedDate != nil && rec.DeletedDate.Before(before)))
+		if expired {
+			purged++
+			r.removeAttachmentsOf(rec.ID)
+			continue
+		}
+		kept = append(kept, rec)
+	}
+	r.expenses = kept
+	return purged, nil
+}
+
+func (r *Repository) ExportExpenses() ([]backup.Expense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expenses := make([]backup.Expense, 0, len(r.expenses))
+	for _, rec := range r.expenses {
+		expenses = append(expenses, backup.Expense(*rec))
+	}
+	return expenses, nil
+}
+
+func (r *Repository) ImportExpense(exp backup.Expense) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec := expenseRecord(exp)
+	rec.Kind = string(expense.KindOf(rec.Kind))
+	if rec.Version < 1 {
+		rec.Version = 1
+	}
+	if n, err := strconv.Atoi(rec.ID); err != nil || n <= 0 || r.find(rec.ID) != nil {
+		rec.ID = r.newID()
+	} else if n > r.nextID {
+		r.nextID = n
+	}
+	r.expenses = append(r.expenses, &rec)
+	return rec.ID, nil
+}
+
+func (r *Repository) Close() error {
+	return nil
+}
+
+// secretSettingKeys lists the settings whose values are encrypted at rest
+var secretSettingKeys = []string{"gemini_api_key"}
+
+func (r *Repository) SaveAPIKey(apiKey string) error {
+	if r.secrets == nil {
+		return secrets.ErrNoMasterKey
+	}
+	encrypted, err := r.secrets.Encrypt(apiKey)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings["gemini_api_key"] = encrypted
+	return nil
+}
+
+func (r *Repository) GetAPIKey() (string, error) {
+	r.mu.RLock()
+	value := r.settings["gemini_api_key"]
+	r.mu.RUnlock()
+
+	if value == "" || !secrets.IsEncrypted(value) {
+		return value, nil
+	}
+	if r.secrets == nil {
+		return "", secrets.ErrNoMasterKey
+	}
+	return r.secrets.Decrypt(value)
+}
+
+// RotateSecrets re-encrypts secrets written under a previous master key
+func (r *Repository) RotateSecrets() (int, error) {
+	if r.secrets == nil {
+		return 0, secrets.ErrNoMasterKey
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rotated := 0
+	for _, key := range secretSettingKeys {
+		value, ok := r.settings[key]
+		if !ok || !r.secrets.NeedsRotation(value) {
+			continue
+		}
+		plaintext, err := r.secrets.Decrypt(value)
+		if err != nil {
+			return rotated, err
+		}
+		encrypted, err := r.secrets.Encrypt(plaintext)
+		if err != nil {
+			return rotated, err
+		}
+		r.settings[key] = encrypted
+		rotated++
+	}
+
+	webhooks, err := r.rotateWebhookSecrets()
+	return rotated + webhooks, err
+}
+
+func (r *Repository) AppendAudit(entry audit.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.ID = r.newID()
+	r.audit = append(r.audit, entry)
+	return nil
+}
+
+func (r *Repository) FindAudit(filter audit.Filter) ([]audit.Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []audit.Entry
+	for _, entry := range r.audit {
+		if filter.Actor != "" && entry.Actor != filter.Actor {
+			continue
+		}
+		if filter.Action != "" && entry.Action != filter.Action {
+			continue
+		}
+		if filter.TargetID != "" && entry.TargetID != filter.TargetID {
+			continue
+		}
+		if !filter.From.IsZero() && entry.Timestamp.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !entry.Timestamp.Before(filter.To) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = audit.DefaultLimit
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	domainuser "expense-tracker/domain/user"
+	"expense-tracker/infrastructure/sessionstore"
+)
+
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
+
+func copyRecord(record *sessionstore.Record) *sessionstore.Record {
+	copied := *record
+	copied.Values = copyValues(record.Values)
+	return &copied
+}
+
+func (r *Repository) FindSession(id string) (*sessionstore.Record, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, ok := r.sessions[id]
+	if !ok {
+		return nil, domainuser.ErrSessionNotFound
+	}
+	return copyRecord(record), nil
+}
+
+func (r *Repository) FindSessionByToken(tokenHash string) (*sessionstore.Record, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, record := range r.sessions {
+		if record.TokenHash == tokenHash {
+			return copyRecord(record), nil
+		}
+	}
+	return nil, domainuser.ErrSessionNotFound
+}
+
+func (r *Repository) InsertSession(record sessionstore.Record) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Drop expired sessions here, as there is no TTL index to do it
+	now := time.Now()
+	for id, existing := range r.sessions {
+		if now.After(existing.ExpiresAt) {
+			delete(r.sessions, id)
+		}
+	}
+
+	record.ID = r.newID()
+	r.sessions[record.ID] = copyRecord(&record)
+	return record.ID, nil
+}
+
+func (r *Repository) UpdateSessionValues(id string, values map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.sessions[id]; ok {
+		record.Values = copyValues(values)
+	}
+	return nil
+}
+
+func (r *Repository) TouchSession(id, ip, userAgent string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.sessions[id]; ok {
+		record.LastSeen = now
+		record.IP = ip
+		record.UserAgent = userAgent
+	}
+	return nil
+}
+
+func (r *Repository) DeleteSession(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions, id)
+	return nil
+}
+
+// ListSessions returns the unexpired sessions of username, newest activity first
+func (r *Repository) ListSessions(username string) ([]domainuser.SessionDTO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var result []domainuser.SessionDTO
+	for _, record := range r.sessions {
+		if record.Username != username || !record.ExpiresAt.After(now) {
+			continue
+		}
+		result = append(result, domainuser.SessionDTO{
+			ID:        record.ID,
+			IP:        record.IP,
+			UserAgent: record.UserAgent,
+			CreatedAt: record.CreatedAt,
+			LastSeen:  record.LastSeen,
+			ExpiresAt: record.ExpiresAt,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeen.After(result[j].LastSeen)
+	})
+	return result, nil
+}
+
+// RevokeSession ends one session, only if it belongs to username
+func (r *Repository) RevokeSession(username, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.sessions[id]
+	if !ok || record.Username != username {
+		return domainuser.ErrSessionNotFound
+	}
+	delete(r.sessions, id)
+	return nil
+}
+
+// RevokeUserSessions ends every session of username except exceptID
+func (r *Repository) RevokeUserSessions(username, exceptID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, record := range r.sessions {
+		if record.Username == username && id != exceptID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	domainuser "expense-tracker/domain/user"
+)
+
+type userRecord struct {
+	Username  string
+	Password  string
+	Role      domainuser.Role
+	Disabled  bool
+	CreatedAt time.Time
+}
+
+func (u *userRecord) toDTO() domainuser.UserDTO {
+	return domainuser.UserDTO{
+		Username:  u.Username,
+		Role:      u.Role,
+		Disabled:  u.Disabled,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+func (r *Repository) CreateUser(username, password string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.users[username]; exists {
+		return domainuser.ErrUserExists
+	}
+	r.users[username] = &userRecord{
+		Username:  username,
+		Password:  password,
+		Role:      domainuser.DefaultRole(username),
+		CreatedAt: time.Now(),
+	}
+	return nil
+}
+
+func (r *Repository) GetUser(username string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[username]
+	if !ok {
+		return "", domainuser.ErrUserNotFound
+	}
+	if u.Disabled {
+		return "", domainuser.ErrUserDisabled
+	}
+	return u.Password, nil
+}
+
+func (r *Repository) InitDefaultUsers() error {
+	defaultUsers := map[string]string{
+		"admin": "admin123",
+		"linh":  "linh123",
+		"toan":  "toan123",
+	}
+
+	for username, password := range defaultUsers {
+		if err := r.CreateUser(username, password); err != nil && err != domainuser.ErrUserExists {
+			log.Printf("[MEMORY] Failed to create user %s: %v", username, err)
+		}
+	}
+	return nil
+}
+
+func (r *Repository) FindUser(username string) (*domainuser.UserDTO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[username]
+	if !ok {
+		return nil, domainuser.ErrUserNotFound
+	}
+	dto := u.toDTO()
+	return &dto, nil
+}
+
+func (r *Repository) ListUsers() ([]domainuser.UserDTO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []domainuser.UserDTO
+	for _, u := range r.users {
+		users = append(users, u.toDTO())
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+	return users, nil
+}
+
+func (r *Repository) updateUser(username string, update func(u *userRecord)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[username]
+	if !ok {
+		return domainuser.ErrUserNotFound
+	}
+	update(u)
+	return nil
+}
+
+func (r *Repository) SetUserRole(username string, role domainuser.Role) error {
+	return r.updateUser(username, func(u *userRecord) { u.Role = role })
+}
+
+func (r *Repository) SetUserDisabled(username string, disabled bool) error {
+	return r.updateUser(username, func(u *userRecord) { u.Disabled = disabled })
+}
+
+func (r *Repository) ResetPassword(username, password string) error {
+	return r.updateUser(username, func(u *userRecord) { u.Password = password })
+}
+
+func (r *Repository) DeleteUser(username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[username]; !ok {
+		return domainuser.ErrUserNotFound
+	}
+	delete(r.users, username)
+	return nil
+}
+
+func (r *Repository) GetRegistrationMode() (domainuser.RegistrationMode, error) {
+	r.mu.RLock()
+	value, ok := r.settings["registration_mode"]
+	r.mu.RUnlock()
+
+	if !ok {
+		return domainuser.RegistrationOpen, nil
+	}
+	return domainuser.ParseRegistrationMode(value)
+}
+
+func (r *Repository) SaveRegistrationMode(mode domainuser.RegistrationMode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settings["registration_mode"] = string(mode)
+	return nil
+}
+
+func (r *Repository) CreateInvite(createdBy string, ttl time.Duration) (*domainuser.InviteDTO, error) {
+	code, err := domainuser.NewInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invite := &domainuser.InviteDTO{
+		Code:      code,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	r.mu.Lock()
+	r.invites[code] = invite
+	r.mu.Unlock()
+
+	dto := *invite
+	return &dto, nil
+}
+
+func (r *Repository) ListInvites() ([]domainuser.InviteDTO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var invites []domainuser.InviteDTO
+	for _, invite := range r.invites {
+		invites = append(invites, *invite)
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		return invites[i].CreatedAt.After(invites[j].CreatedAt)
+	})
+	return invites, nil
+}
+
+// ConsumeInvite marks an unused, unexpired invite as used by username
+func (r *Repository) ConsumeInvite(code, username string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	invite, ok := r.invites[code]
+	if !ok || !invite.IsUsable(now) {
+		return domainuser.ErrInviteInvalid
+	}
+	invite.UsedBy = username
+	invite.UsedAt = &now
+	return nil
+}
+
+// ReleaseInvite undoes ConsumeInvite when the account could not be created
+func (r *Repository) ReleaseInvite(code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if invite, ok := r.invites[code]; ok {
+		invite.UsedBy = ""
+		invite.UsedAt = nil
+	}
+	return nil
+}
+
+func (r *Repository) DeleteInvite(code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.invites[code]; !ok {
+		return domainuser.ErrInviteInvalid
+	}
+	delete(r.invites, code)
+	return nil
+}
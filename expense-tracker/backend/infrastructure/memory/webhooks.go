@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"sort"
+
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/webhook"
+	"expense-tracker/infrastructure/secrets"
+)
+
+func (r *Repository) CreateWebhook(subscription webhook.Subscription) (string, error) {
+	if r.secrets == nil {
+		return "", secrets.ErrNoMasterKey
+	}
+	encrypted, err := r.secrets.Encrypt(subscription.Secret)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription.ID = r.newID()
+	subscription.Secret = encrypted
+	subscription.Events = append([]expense.EventType(nil), subscription.Events...)
+	r.webhooks[subscription.ID] = &subscription
+	return subscription.ID, nil
+}
+
+// decryptWebhook copies a stored webhook with its secret in plaintext
+func (r *Repository) decryptWebhook(stored *webhook.Subscription) (*webhook.Subscription, error) {
+	found := *stored
+	found.Events = append([]expense.EventType(nil), stored.Events...)
+	if r.secrets == nil {
+		return nil, secrets.ErrNoMasterKey
+	}
+	secret, err := r.secrets.Decrypt(stored.Secret)
+	if err != nil {
+		return nil, err
+	}
+	found.Secret = secret
+	return &found, nil
+}
+
+func (r *Repository) GetWebhook(id string) (*webhook.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.webhooks[id]
+	if !ok {
+		return nil, webhook.ErrNotFound
+	}
+	return r.decryptWebhook(stored)
+}
+
+func (r *Repository) ListWebhooks() ([]webhook.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]webhook.Subscription, 0, len(r.webhooks))
+	for _, stored := range r.webhooks {
+		found, err := r.decryptWebhook(stored)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *found)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (r *Repository) DeleteWebhook(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return webhook.ErrNotFound
+	}
+	delete(r.webhooks, id)
+	delete(r.deliveries, id)
+	return nil
+}
+
+func (r *Repository) RecordDelivery(delivery webhook.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delivery.ID = r.newID()
+	entries := append(r.deliveries[delivery.WebhookID], delivery)
+	if len(entries) > webhook.DeliveryLogSize {
+		entries = entries[len(entries)-webhook.DeliveryLogSize:]
+	}
+	r.deliveries[delivery.WebhookID] = entries
+	return nil
+}
+
+func (r *Repository) ListDeliveries(webhookID string) ([]webhook.Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := r.deliveries[webhookID]
+	deliveries := make([]webhook.Delivery, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		deliveries = append(deliveries, entries[i])
+	}
+	return deliveries, nil
+}
+
+// rotateWebhookSecrets re-encrypts webhook secrets written under a previous
+// master key; the caller holds r.mu
+func (r *Repository) rotateWebhookSecrets() (int, error) {
+	rotated := 0
+	for _, stored := range r.webhooks {
+		if !r.secrets.NeedsRotation(stored.Secret) {
+			continue
+		}
+		plaintext, err := r.secrets.Decrypt(stored.Secret)
+		if err != nil {
+			return rotated, err
+		}
+		encrypted, err := r.secrets.Encrypt(plaintext)
+		if err != nil {
+			return rotated, err
+		}
+		stored.Secret = encrypted
+		rotated++
+	}
+	return rotated, nil
+}
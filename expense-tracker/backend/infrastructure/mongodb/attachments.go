@@ -0,0 +1,224 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"time"
+
+	"expense-tracker/domain/attachment"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// attachmentBucket is the GridFS bucket holding expense attachments. The
+// attachment's details live in each file's metadata, so that there is no
+// second collection to keep in step with the files.
+const attachmentBucket = "attachments"
+
+// AttachmentFileDoc is a document of the bucket's files collection
+type AttachmentFileDoc struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	Length   int64              `bson:"length"`
+	Filename string             `bson:"filename"`
+	Metadata struct {
+		ExpenseID   string    `bson:"expense_id"`
+		ContentType string    `bson:"content_type"`
+		UploadedBy  string    `bson:"uploaded_by"`
+		UploadedAt  time.Time `bson:"uploaded_at"`
+	} `bson:"metadata"`
+}
+
+func (doc *AttachmentFileDoc) toAttachment() attachment.Attachment {
+	return attachment.Attachment{
+		ID:          doc.ID.Hex(),
+		ExpenseID:   doc.Metadata.ExpenseID,
+		Filename:    doc.Filename,
+		ContentType: doc.Metadata.ContentType,
+		Size:        doc.Length,
+		UploadedBy:  doc.Metadata.UploadedBy,
+		UploadedAt:  doc.Metadata.UploadedAt,
+	}
+}
+
+func (r *Repository) attachments() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(r.client.Database("expense_tracker"), options.GridFSBucket().SetName(attachmentBucket))
+}
+
+func (r *Repository) SaveAttachment(a *attachment.Attachment, data []byte) error {
+	bucket, err := r.attachments()
+	if err != nil {
+		return err
+	}
+	id, err := bucket.UploadFromStream(a.Filename, bytes.NewReader(data),
+		options.GridFSUpload().SetMetadata(bson.M{
+			"expense_id":   a.ExpenseID,
+			"content_type": a.ContentType,
+			"uploaded_by":  a.UploadedBy,
+			"uploaded_at":  a.UploadedAt,
+		}))
+	if err != nil {
+		return err
+	}
+	a.ID = id.Hex()
+	a.Size = int64(len(data))
+	return nil
+}
+
+func (r *Repository) GetAttachment(id string) (*attachment.Attachment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, attachment.ErrNotFound
+	}
+	list, err := r.findAttachments(bson.M{"_id": objectID})
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, attachment.ErrNotFound
+	}
+	return &list[0], nil
+}
+
+func (r *Repository) AttachmentData(id string) ([]byte, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, attachment.ErrNotFound
+	}
+	bucket, err := r.attachments()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := bucket.DownloadToStream(objectID, &buf); err != nil {
+		if err == gridfs.ErrFileNotFound {
+			return nil, attachment.ErrNotFound
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Repository) ListAttachments(expenseID string) ([]attachment.Attachment, error) {
+	return r.findAttachments(bson.M{"metadata.expense_id": expenseID})
+}
+
+func (r *Repository) ListAllAttachments() ([]attachment.Attachment, error) {
+	return r.findAttachments(bson.M{})
+}
+
+func (r *Repository) findAttachments(filter bson.M) ([]attachment.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "metadata.uploaded_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.attachmentFiles.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	list := []attachment.Attachment{}
+	for cursor.Next(ctx) {
+		var doc AttachmentFileDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		list = append(list, doc.toAttachment())
+	}
+	return list, cursor.Err()
+}
+
+func (r *Repository) DeleteAttachment(id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return attachment.ErrNotFound
+	}
+	bucket, err := r.attachments()
+	if err != nil {
+		return err
+	}
+	if err := bucket.Delete(objectID); err != nil {
+		if err == gridfs.ErrFileNotFound {
+			return attachment.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// deleteOrphanedAttachments removes the files of expenses that no longer
+// exist. It runs after expenses are purged or cleared; looking for every
+// orphan rather than the purged IDs also picks up files left behind by an
+// earlier purge that failed half way.
+func (r *Repository) deleteOrphanedAttachments() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	expenseIDs, err := r.attachmentFiles.Distinct(ctx, "metadata.expense_id", bson.M{})
+	if err != nil {
+		return err
+	}
+	if len(expenseIDs) == 0 {
+		return nil
+	}
+
+	var objectIDs []primitive.ObjectID
+	for _, id := range expenseIDs {
+		if hex, ok := id.(string); ok {
+			if objectID, err := primitive.ObjectIDFromHex(hex); err == nil {
+				objectIDs = append(objectIDs, objectID)
+			}
+		}
+	}
+	existing := map[string]bool{}
+	if len(objectIDs) > 0 {
+		cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": objectIDs}},
+			options.Find().SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+			var doc struct {
+				ID primitive.ObjectID `bson:"_id"`
+			}
+			if err := cursor.Decode(&doc); err != nil {
+				return err
+			}
+			existing[doc.ID.Hex()] = true
+		}
+		if err := cursor.Err(); err != nil {
+			return err
+		}
+	}
+
+	var orphaned []interface{}
+	for _, id := range expenseIDs {
+		if hex, _ := id.(string); !existing[hex] {
+			orphaned = append(orphaned, id)
+		}
+	}
+	if len(orphaned) == 0 {
+		return nil
+	}
+	files, err := r.findAttachments(bson.M{"metadata.expense_id": bson.M{"$in": orphaned}})
+	if err != nil {
+		return err
+	}
+
+	bucket, err := r.attachments()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		objectID, _ := primitive.ObjectIDFromHex(file.ID)
+		if err := bucket.Delete(objectID); err != nil && err != gridfs.ErrFileNotFound {
+			return err
+		}
+	}
+	log.Printf("[MONGO] Removed %d attachment(s) of %d purged expense(s)", len(files), len(orphaned))
+	return nil
+}
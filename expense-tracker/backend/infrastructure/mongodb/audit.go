@@ -0,0 +1,101 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditDoc struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty"`
+	Actor     string                 `bson:"actor"`
+	Action    string                 `bson:"action"`
+	TargetID  string                 `bson:"target_id,omitempty"`
+	Before    map[string]interface{} `bson:"before,omitempty"`
+	After     map[string]interface{} `bson:"after,omitempty"`
+	IP        string                 `bson:"ip,omitempty"`
+	Timestamp time.Time              `bson:"timestamp"`
+}
+
+func (r *Repository) AppendAudit(entry audit.Entry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := AuditDoc{
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		TargetID:  entry.TargetID,
+		Before:    entry.Before,
+		After:     entry.After,
+		IP:        entry.IP,
+		Timestamp: entry.Timestamp,
+	}
+	if _, err := r.audit.InsertOne(ctx, doc); err != nil {
+		log.Printf("[MONGO] Audit append error: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) FindAudit(filter audit.Filter) ([]audit.Entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.TargetID != "" {
+		query["target_id"] = filter.TargetID
+	}
+	timestamp := bson.M{}
+	if !filter.From.IsZero() {
+		timestamp["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		timestamp["$lt"] = filter.To
+	}
+	if len(timestamp) > 0 {
+		query["timestamp"] = timestamp
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = audit.DefaultLimit
+	}
+	opts := options.Find().SetSort(bson.M{"timestamp": -1}).SetLimit(int64(limit))
+
+	cursor, err := r.audit.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []audit.Entry
+	for cursor.Next(ctx) {
+		var doc AuditDoc
+		if err := cursor.Decode(&doc); err != nil {
+			log.Printf("[MONGO] Audit decode error: %v", err)
+			continue
+		}
+		entries = append(entries, audit.Entry{
+			ID:        doc.ID.Hex(),
+			Actor:     doc.Actor,
+			Action:    doc.Action,
+			TargetID:  doc.TargetID,
+			Before:    doc.Before,
+			After:     doc.After,
+			IP:        doc.IP,
+			Timestamp: doc.Timestamp,
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,107 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"expense-tracker/domain/backup"
+	"expense-tracker/domain/expense"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (r *Repository) ExportExpenses() ([]backup.Expense, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var expenses []backup.Expense
+	for cursor.Next(ctx) {
+		var doc ExpenseDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, backup.Expense{
+			ID:              doc.ID.Hex(),
+			Items:           doc.Items,
+			Amount:          doc.Amount,
+			Currency:        doc.Currency,
+			OriginalAmount:  doc.OriginalAmount,
+			Rate:            doc.Rate,
+			Quantity:        doc.Quantity,
+			Unit:            doc.Unit,
+			BaseQuantity:    doc.BaseQuantity,
+			BaseUnit:        doc.BaseUnit,
+			OriginalMessage: doc.OriginalMessage,
+			PaidDate:        doc.PaidDate,
+			PaidBy:          doc.PaidBy,
+			Kind:            doc.Kind,
+			PaidTo:          doc.PaidTo,
+			Tags:            doc.Tags,
+			Notes:           doc.Notes,
+			Status:          doc.Status,
+			DeletedDate:     doc.DeletedDate,
+			Version:         doc.Version,
+		})
+	}
+	return expenses, cursor.Err()
+}
+
+func (r *Repository) ImportExpense(exp backup.Expense) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := ExpenseDoc{
+		Items:           exp.Items,
+		Amount:          exp.Amount,
+		Currency:        exp.Currency,
+		OriginalAmount:  exp.OriginalAmount,
+		Rate:            exp.Rate,
+		Quantity:        exp.Quantity,
+		Unit:            exp.Unit,
+		BaseQuantity:    exp.BaseQuantity,
+		BaseUnit:        exp.BaseUnit,
+		OriginalMessage: exp.OriginalMessage,
+		PaidDate:        exp.PaidDate,
+		PaidBy:          exp.PaidBy,
+		Kind:            string(expense.KindOf(exp.Kind)),
+		PaidTo:          exp.PaidTo,
+		Tags:            exp.Tags,
+		Notes:           exp.Notes,
+		Status:          exp.Status,
+		DeletedDate:     exp.DeletedDate,
+		Version:         exp.Version,
+	}
+	if doc.Version < 1 {
+		doc.Version = 1
+	}
+
+	// Keep the archived ID when it is an ObjectID; an archive from another
+	// backend has IDs Mongo cannot use, and those get new ones
+	if objectID, err := primitive.ObjectIDFromHex(exp.ID); err == nil {
+		doc.ID = objectID
+		_, err := r.collection.InsertOne(ctx, doc)
+		if err == nil {
+			return objectID.Hex(), nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			log.Printf("[MONGO] Import error: %v", err)
+			return "", err
+		}
+		doc.ID = primitive.NilObjectID
+	}
+
+	result, err := r.collection.InsertOne(ctx, doc)
+	if err != nil {
+		log.Printf("[MONGO] Import error: %v", err)
+		return "", err
+	}
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
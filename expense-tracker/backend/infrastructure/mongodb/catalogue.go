@@ -0,0 +1,139 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"expense-tracker/domain/catalogue"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ItemDoc struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Name      string             `bson:"name"`
+	Aliases   []string           `bson:"aliases"`
+	Category  string             `bson:"category,omitempty"`
+	Unit      string             `bson:"unit,omitempty"`
+	UpdatedBy string             `bson:"updated_by,omitempty"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+func newItemDoc(item catalogue.Item) ItemDoc {
+	return ItemDoc{
+		Name:      item.Name,
+		Aliases:   append([]string{}, item.Aliases...),
+		Category:  item.Category,
+		Unit:      item.Unit,
+		UpdatedBy: item.UpdatedBy,
+		UpdatedAt: item.UpdatedAt.UTC(),
+	}
+}
+
+func (doc ItemDoc) toItem() catalogue.Item {
+	return catalogue.Item{
+		ID:        doc.ID.Hex(),
+		Name:      doc.Name,
+		Aliases:   append([]string{}, doc.Aliases...),
+		Category:  doc.Category,
+		Unit:      doc.Unit,
+		UpdatedBy: doc.UpdatedBy,
+		UpdatedAt: doc.UpdatedAt,
+	}
+}
+
+func (r *Repository) CreateItem(item catalogue.Item) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.items.InsertOne(ctx, newItemDoc(item))
+	if err != nil {
+		return "", err
+	}
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+func (r *Repository) GetItem(id string) (*catalogue.Item, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, catalogue.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var doc ItemDoc
+	err = r.items.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, catalogue.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	item := doc.toItem()
+	return &item, nil
+}
+
+func (r *Repository) ListItems() ([]catalogue.Item, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.items.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	items := []catalogue.Item{}
+	for cursor.Next(ctx) {
+		var doc ItemDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		items = append(items, doc.toItem())
+	}
+	return items, cursor.Err()
+}
+
+func (r *Repository) UpdateItem(item catalogue.Item) error {
+	objectID, err := primitive.ObjectIDFromHex(item.ID)
+	if err != nil {
+		return catalogue.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := newItemDoc(item)
+	doc.ID = objectID
+	result, err := r.items.ReplaceOne(ctx, bson.M{"_id": objectID}, doc)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return catalogue.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) DeleteItem(id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return catalogue.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.items.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return catalogue.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"expense-tracker/domain/chat"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LinkCodeDoc struct {
+	Code      string    `bson:"code"`
+	Username  string    `bson:"username"`
+	CreatedAt time.Time `bson:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+type ChatLinkDoc struct {
+	Provider   string    `bson:"provider"`
+	ChatUserID string    `bson:"chat_user_id"`
+	ChatName   string    `bson:"chat_name,omitempty"`
+	Username   string    `bson:"username"`
+	LinkedAt   time.Time `bson:"linked_at"`
+}
+
+func (doc ChatLinkDoc) toLink() chat.Link {
+	return chat.Link{
+		Provider:   doc.Provider,
+		ChatUserID: doc.ChatUserID,
+		ChatName:   doc.ChatName,
+		Username:   doc.Username,
+		LinkedAt:   doc.LinkedAt,
+	}
+}
+
+func (r *Repository) CreateLinkCode(code chat.LinkCode) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.linkCodes.InsertOne(ctx, LinkCodeDoc{
+		Code:      code.Code,
+		Username:  code.Username,
+		CreatedAt: code.CreatedAt,
+		ExpiresAt: code.ExpiresAt,
+	})
+	return err
+}
+
+func (r *Repository) ConsumeLinkCode(code string, now time.Time) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The TTL index removes expired codes only every minute or so, hence
+	// the expiry in the filter
+	var doc LinkCodeDoc
+	err := r.linkCodes.FindOneAndDelete(ctx, bson.M{"code": code, "expires_at": bson.M{"$gt": now}}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return "", chat.ErrLinkCodeInvalid
+	}
+	if err != nil {
+		return "", err
+	}
+	return doc.Username, nil
+}
+
+func (r *Repository) SaveChatLink(link chat.Link) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := ChatLinkDoc{
+		Provider:   link.Provider,
+		ChatUserID: link.ChatUserID,
+		ChatName:   link.ChatName,
+		Username:   link.Username,
+		LinkedAt:   link.LinkedAt,
+	}
+	filter := bson.M{"provider": link.Provider, "chat_user_id": link.ChatUserID}
+	_, err := r.chatLinks.ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *Repository) FindChatLink(provider, chatUserID string) (*chat.Link, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var doc ChatLinkDoc
+	err := r.chatLinks.FindOne(ctx, bson.M{"provider": provider, "chat_user_id": chatUserID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, chat.ErrNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	link := doc.toLink()
+	return &link, nil
+}
+
+func (r *Repository) ListChatLinks(username string) ([]chat.Link, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.chatLinks.Find(ctx, bson.M{"username": username}, options.Find().SetSort(bson.M{"linked_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var links []chat.Link
+	for cursor.Next(ctx) {
+		var doc ChatLinkDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		links = append(links, doc.toLink())
+	}
+	return links, cursor.Err()
+}
+
+func (r *Repository) DeleteChatLink(username, provider, chatUserID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.chatLinks.DeleteOne(ctx, bson.M{"username": username, "provider": provider, "chat_user_id": chatUserID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return chat.ErrNotLinked
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DismissalDoc struct {
+	PairKey     string    `bson:"pair_key"`
+	DismissedBy string    `bson:"dismissed_by"`
+	DismissedAt time.Time `bson:"dismissed_at"`
+}
+
+func (r *Repository) DismissDuplicate(pairKey, dismissedBy string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := DismissalDoc{PairKey: pairKey, DismissedBy: dismissedBy, DismissedAt: time.Now()}
+	_, err := r.dismissals.UpdateOne(ctx, bson.M{"pair_key": pairKey}, bson.M{"$setOnInsert": doc}, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *Repository) ListDismissedDuplicates() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.dismissals.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"pair_key": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []string
+	for cursor.Next(ctx) {
+		var doc DismissalDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		keys = append(keys, doc.PairKey)
+	}
+	return keys, cursor.Err()
+}
@@ -0,0 +1,104 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"expense-tracker/domain/idempotency"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IdempotencyDoc struct {
+	Username    string    `bson:"username"`
+	Key         string    `bson:"key"`
+	RequestHash string    `bson:"request_hash"`
+	Completed   bool      `bson:"completed"`
+	ExpenseID   string    `bson:"expense_id,omitempty"`
+	StatusCode  int       `bson:"status_code,omitempty"`
+	Response    []byte    `bson:"response,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+func (r *Repository) ClaimIdempotencyKey(record idempotency.Record, staleBefore time.Time) (*idempotency.Record, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := IdempotencyDoc{
+		Username:    record.Username,
+		Key:         record.Key,
+		RequestHash: record.RequestHash,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.ExpiresAt,
+	}
+	_, err := r.idempotency.InsertOne(ctx, doc)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	// The TTL monitor only runs once a minute, so an expired record can
+	// still be there; it and an abandoned pending one are taken over
+	filter := bson.M{
+		"username": record.Username,
+		"key":      record.Key,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lte": record.CreatedAt}},
+			bson.M{"completed": false, "created_at": bson.M{"$lt": staleBefore}},
+		},
+	}
+	result, err := r.idempotency.ReplaceOne(ctx, filter, doc)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount > 0 {
+		return nil, nil
+	}
+
+	var existing IdempotencyDoc
+	err = r.idempotency.FindOne(ctx, bson.M{"username": record.Username, "key": record.Key}).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		// Released in the meantime; report it as in progress so that the
+		// client retries
+		record.Completed = false
+		return &record, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &idempotency.Record{
+		Username:    existing.Username,
+		Key:         existing.Key,
+		RequestHash: existing.RequestHash,
+		Completed:   existing.Completed,
+		ExpenseID:   existing.ExpenseID,
+		StatusCode:  existing.StatusCode,
+		Response:    existing.Response,
+		CreatedAt:   existing.CreatedAt,
+		ExpiresAt:   existing.ExpiresAt,
+	}, nil
+}
+
+func (r *Repository) CompleteIdempotencyKey(username, key, expenseID string, statusCode int, response []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.idempotency.UpdateOne(ctx, bson.M{"username": username, "key": key}, bson.M{"$set": bson.M{
+		"completed":   true,
+		"expense_id":  expenseID,
+		"status_code": statusCode,
+		"response":    response,
+	}})
+	return err
+}
+
+func (r *Repository) ReleaseIdempotencyKey(username, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.idempotency.DeleteOne(ctx, bson.M{"username": username, "key": key, "completed": false})
+	return err
+}
@@ -0,0 +1,90 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ensureIndexes declares every index the repository relies on. CreateMany is
+// a no-op for indexes that already exist with the same definition.
+func (r *Repository) ensureIndexes() error {
+	declared := []struct {
+		collection *mongo.Collection
+		indexes    []mongo.IndexModel
+	}{
+		{r.users, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetName("username_unique").SetUnique(true)},
+		}},
+		{r.settings, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetName("key_unique").SetUnique(true)},
+		}},
+		{r.collection, []mongo.IndexModel{
+			// status filters with newest-first listing, and the per-payer summary
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "paid_date", Value: -1}}, Options: options.Index().SetName("status_paid_date")},
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "paid_by", Value: 1}, {Key: "paid_date", Value: -1}}, Options: options.Index().SetName("status_paid_by_paid_date")},
+		}},
+		{r.invites, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetName("code_unique").SetUnique(true)},
+		}},
+		{r.sessions, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetName("token_hash_unique").SetUnique(true)},
+			{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetName("username")},
+			// Lets MongoDB drop sessions once they pass expires_at
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		}},
+		{r.idempotency, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "username", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetName("username_key_unique").SetUnique(true)},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		}},
+		{r.dismissals, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "pair_key", Value: 1}}, Options: options.Index().SetName("pair_key_unique").SetUnique(true)},
+		}},
+		{r.deliveries, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("webhook_id_newest")},
+		}},
+		{r.linkCodes, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetName("code_unique").SetUnique(true)},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		}},
+		{r.chatLinks, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "provider", Value: 1}, {Key: "chat_user_id", Value: 1}}, Options: options.Index().SetName("provider_chat_user_unique").SetUnique(true)},
+			{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetName("username")},
+		}},
+		{r.receipts, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "expense_ids", Value: 1}}, Options: options.Index().SetName("expense_ids")},
+		}},
+		{r.attachmentFiles, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "metadata.expense_id", Value: 1}}, Options: options.Index().SetName("expense_id")},
+		}},
+		{r.rates, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "currency", Value: 1}, {Key: "date", Value: 1}}, Options: options.Index().SetName("currency_date_unique").SetUnique(true)},
+		}},
+		{r.prices, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "item", Value: 1}, {Key: "paid_date", Value: 1}}, Options: options.Index().SetName("item_paid_date")},
+		}},
+		{r.items, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetName("name")},
+		}},
+		{r.audit, []mongo.IndexModel{
+			{Keys: bson.D{{Key: "timestamp", Value: -1}}, Options: options.Index().SetName("timestamp")},
+			{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "timestamp", Value: -1}}, Options: options.Index().SetName("target_id_timestamp")},
+		}},
+	}
+
+	for _, d := range declared {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		names, err := d.collection.Indexes().CreateMany(ctx, d.indexes)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("create indexes on %s: %w", d.collection.Name(), err)
+		}
+		log.Printf("[MONGO] Indexes on %s: %v", d.collection.Name(), names)
+	}
+	return nil
+}
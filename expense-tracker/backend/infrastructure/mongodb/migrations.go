@@ -0,0 +1,285 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense-tracker/domain/expense"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migration is one ordered schema change. up must be idempotent: when two
+// instances start at the same time both may run it before either records it.
+type migration struct {
+	version     int
+	description string
+	up          func(ctx context.Context, r *Repository) error
+}
+
+type MigrationDoc struct {
+	Version     int       `bson:"version"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// migrations must stay sorted by version; never renumber or edit one that
+// has shipped, add a new one instead
+var migrations = []migration{
+	{1, "normalise expense status", normaliseStatus},
+	{2, "backfill deleted_date on deleted expenses", backfillDeletedDate},
+	{3, "backfill base_quantity and base_unit", backfillBaseQuantity},
+	{4, "remove duplicate usernames and setting keys", removeDuplicateKeys},
+	{5, "backfill expense version", backfillVersion},
+	{6, "backfill expense kind", backfillKind},
+}
+
+// Migrate applies every migration newer than the last recorded one, in
+// order, and returns how many ran
+func (r *Repository) Migrate() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := r.migrations.Indexes().CreateOne(ctx, index)
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	applied, err := r.appliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	ran := 0
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		log.Printf("[MONGO] Running migration %d: %s", m.version, m.description)
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		err := m.up(ctx, r)
+		cancel()
+		if err != nil {
+			return ran, fmt.Errorf("migration %d (%s): %w", m.version, m.description, err)
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		_, err = r.migrations.InsertOne(ctx, MigrationDoc{
+			Version:     m.version,
+			Description: m.description,
+			AppliedAt:   time.Now(),
+		})
+		cancel()
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return ran, err
+		}
+
+		log.Printf("[MONGO] Migration %d done in %s", m.version, time.Since(start).Round(time.Millisecond))
+		ran++
+	}
+	return ran, nil
+}
+
+func (r *Repository) appliedMigrations() (map[int]bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.migrations.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	applied := make(map[int]bool)
+	for cursor.Next(ctx) {
+		var doc MigrationDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		applied[doc.Version] = true
+	}
+	return applied, cursor.Err()
+}
+
+// normaliseStatus lower-cases and trims status, and marks documents without
+// one as active
+func normaliseStatus(ctx context.Context, r *Repository) error {
+	filter := bson.M{"status": bson.M{"$nin": bson.A{"active", "deleted"}}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"status": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID     primitive.ObjectID `bson:"_id"`
+			Status interface{}        `bson:"status"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+
+		status := "active"
+		if value, ok := doc.Status.(string); ok && strings.EqualFold(strings.TrimSpace(value), "deleted") {
+			status = "deleted"
+		}
+		if _, err := r.collection.UpdateByID(ctx, doc.ID, bson.M{"$set": bson.M{"status": status}}); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// backfillDeletedDate gives expenses deleted before deleted_date existed the
+// migration time, so they get a full retention period in the trash
+func backfillDeletedDate(ctx context.Context, r *Repository) error {
+	filter := bson.M{"status": "deleted", "deleted_date": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"deleted_date": time.Now()}})
+	if err != nil {
+		return err
+	}
+	log.Printf("[MONGO] Backfilled deleted_date on %d expenses", result.ModifiedCount)
+	return nil
+}
+
+// legacyBaseUnits converts the units older documents were recorded in to the
+// ISO base unit the parser now emits
+var legacyBaseUnits = map[string]struct {
+	unit   string
+	factor float64
+}{
+	"kg":  {"kg", 1},
+	"g":   {"kg", 0.001},
+	"gr":  {"kg", 0.001},
+	"l":   {"L", 1},
+	"lít": {"L", 1},
+	"lit": {"L", 1},
+	"ml":  {"L", 0.001},
+	"m":   {"m", 1},
+	"cm":  {"m", 0.01},
+	"pcs": {"pcs", 1},
+	"cái": {"pcs", 1},
+	"quả": {"pcs", 1},
+}
+
+// backfillBaseQuantity derives base_quantity and base_unit from quantity and
+// unit where the unit converts unambiguously; others are left empty
+func backfillBaseQuantity(ctx context.Context, r *Repository) error {
+	filter := bson.M{
+		"base_quantity": bson.M{"$exists": false},
+		"quantity":      bson.M{"$nin": bson.A{nil, ""}},
+	}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	for cursor.Next(ctx) {
+		var doc ExpenseDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+
+		base, ok := legacyBaseUnits[strings.ToLower(strings.TrimSpace(doc.Unit))]
+		if !ok {
+			continue
+		}
+		quantity, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(doc.Quantity), ",", "."), 64)
+		if err != nil {
+			continue
+		}
+
+		set := bson.M{
+			"base_quantity": strconv.FormatFloat(quantity*base.factor, 'f', -1, 64),
+			"base_unit":     base.unit,
+		}
+		if _, err := r.collection.UpdateByID(ctx, doc.ID, bson.M{"$set": set}); err != nil {
+			return err
+		}
+		updated++
+	}
+	log.Printf("[MONGO] Backfilled base quantity on %d expenses", updated)
+	return cursor.Err()
+}
+
+// backfillVersion starts every expense stored before versioning at 1
+func backfillVersion(ctx context.Context, r *Repository) error {
+	filter := bson.M{"version": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"version": int64(1)}})
+	if err != nil {
+		return err
+	}
+	log.Printf("[MONGO] Backfilled version on %d expenses", result.ModifiedCount)
+	return nil
+}
+
+// backfillKind marks every expense stored before kinds existed as an
+// ordinary expense
+func backfillKind(ctx context.Context, r *Repository) error {
+	filter := bson.M{"kind": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"kind": string(expense.KindExpense)}})
+	if err != nil {
+		return err
+	}
+	log.Printf("[MONGO] Backfilled kind on %d expenses", result.ModifiedCount)
+	return nil
+}
+
+// removeDuplicateKeys clears the way for the unique indexes on users.username
+// and settings.key. The check-then-insert in CreateUser and concurrent
+// upserts could create duplicates; the oldest user and the most recently
+// written setting are kept.
+func removeDuplicateKeys(ctx context.Context, r *Repository) error {
+	if err := removeDuplicates(ctx, r.users, "username", bson.D{{Key: "created_at", Value: 1}}); err != nil {
+		return err
+	}
+	return removeDuplicates(ctx, r.settings, "key", bson.D{{Key: "updated_at", Value: -1}})
+}
+
+func removeDuplicates(ctx context.Context, collection *mongo.Collection, field string, keepFirst bson.D) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: keepFirst}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$" + field,
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var group struct {
+			Value interface{}          `bson:"_id"`
+			IDs   []primitive.ObjectID `bson:"ids"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+
+		result, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[1:]}})
+		if err != nil {
+			return err
+		}
+		log.Printf("[MONGO] Removed %d duplicate %s document(s) for %s=%v", result.DeletedCount, collection.Name(), field, group.Value)
+	}
+	return cursor.Err()
+}
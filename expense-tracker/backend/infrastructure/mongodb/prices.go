@@ -0,0 +1,103 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"expense-tracker/domain/price"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type priceDoc struct {
+	ExpenseID string    `bson:"_id"`
+	Item      string    `bson:"item"`
+	Items     string    `bson:"items"`
+	PaidDate  time.Time `bson:"paid_date"`
+	Amount    int64     `bson:"amount"`
+	Quantity  string    `bson:"quantity"`
+	Unit      string    `bson:"unit"`
+	UnitPrice int64     `bson:"unit_price"`
+}
+
+func newPriceDoc(point price.Point) priceDoc {
+	return priceDoc{
+		ExpenseID: point.ExpenseID,
+		Item:      point.Item,
+		Items:     point.Items,
+		PaidDate:  point.PaidDate.UTC(),
+		Amount:    point.Amount,
+		Quantity:  point.Quantity,
+		Unit:      point.Unit,
+		UnitPrice: point.UnitPrice,
+	}
+}
+
+func (r *Repository) SavePrice(point price.Point) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := newPriceDoc(point)
+	_, err := r.prices.ReplaceOne(ctx, bson.M{"_id": doc.ExpenseID}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *Repository) DeletePrice(expenseID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.prices.DeleteOne(ctx, bson.M{"_id": expenseID})
+	return err
+}
+
+func (r *Repository) ListPrices(item string) ([]price.Point, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "paid_date", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.prices.Find(ctx, bson.M{"item": item}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	points := []price.Point{}
+	for cursor.Next(ctx) {
+		var doc priceDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		points = append(points, price.Point{
+			ExpenseID: doc.ExpenseID,
+			Item:      doc.Item,
+			Items:     doc.Items,
+			PaidDate:  doc.PaidDate.UTC(),
+			Amount:    doc.Amount,
+			Quantity:  doc.Quantity,
+			Unit:      doc.Unit,
+			UnitPrice: doc.UnitPrice,
+		})
+	}
+	return points, cursor.Err()
+}
+
+// ReplacePrices is not atomic: a failure halfway leaves some points out
+// until the next rebuild
+func (r *Repository) ReplacePrices(points []price.Point) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := r.prices.DeleteMany(ctx, bson.M{}); err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, len(points))
+	for i, point := range points {
+		models[i] = mongo.NewInsertOneModel().SetDocument(newPriceDoc(point))
+	}
+	_, err := r.prices.BulkWrite(ctx, models)
+	return err
+}
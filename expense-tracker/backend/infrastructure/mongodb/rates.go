@@ -0,0 +1,77 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/expense"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type rateDoc struct {
+	Currency  string    `bson:"currency"`
+	Date      time.Time `bson:"date"`
+	Rate      string    `bson:"rate"`
+	UpdatedBy string    `bson:"updated_by,omitempty"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+func (r *Repository) SaveRate(rate exchange.Rate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := rateDoc{
+		Currency:  string(rate.Currency),
+		Date:      rate.Date.UTC(),
+		Rate:      rate.Rate,
+		UpdatedBy: rate.UpdatedBy,
+		UpdatedAt: rate.UpdatedAt.UTC(),
+	}
+	filter := bson.M{"currency": doc.Currency, "date": doc.Date}
+	_, err := r.rates.ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *Repository) ListRates() ([]exchange.Rate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "currency", Value: 1}, {Key: "date", Value: 1}})
+	cursor, err := r.rates.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rates := []exchange.Rate{}
+	for cursor.Next(ctx) {
+		var doc rateDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		rates = append(rates, exchange.Rate{
+			Currency:  expense.Currency(doc.Currency),
+			Date:      doc.Date.UTC(),
+			Rate:      doc.Rate,
+			UpdatedBy: doc.UpdatedBy,
+			UpdatedAt: doc.UpdatedAt,
+		})
+	}
+	return rates, cursor.Err()
+}
+
+func (r *Repository) DeleteRate(currency expense.Currency, date time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.rates.DeleteOne(ctx, bson.M{"currency": string(currency), "date": date.UTC()})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return exchange.ErrNotFound
+	}
+	return nil
+}
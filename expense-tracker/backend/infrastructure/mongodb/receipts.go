@@ -0,0 +1,199 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"expense-tracker/domain/receipt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// receiptImageBucket is the GridFS bucket holding receipt images; the
+// receipts collection refers to them by image_id
+const receiptImageBucket = "receipt_images"
+
+type ReceiptLineItemDoc struct {
+	Description  string `bson:"description"`
+	Quantity     string `bson:"quantity,omitempty"`
+	Unit         string `bson:"unit,omitempty"`
+	BaseQuantity string `bson:"base_quantity,omitempty"`
+	BaseUnit     string `bson:"base_unit,omitempty"`
+	Amount       int64  `bson:"amount"`
+}
+
+type ReceiptDoc struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty"`
+	ImageID     primitive.ObjectID   `bson:"image_id"`
+	Filename    string               `bson:"filename"`
+	ContentType string               `bson:"content_type"`
+	Size        int64                `bson:"size"`
+	Merchant    string               `bson:"merchant"`
+	Date        string               `bson:"date,omitempty"`
+	Total       int64                `bson:"total"`
+	Items       []ReceiptLineItemDoc `bson:"items"`
+	ExpenseIDs  []string             `bson:"expense_ids"`
+	UploadedBy  string               `bson:"uploaded_by"`
+	UploadedAt  time.Time            `bson:"uploaded_at"`
+}
+
+func (doc *ReceiptDoc) toReceipt() *receipt.Receipt {
+	rec := &receipt.Receipt{
+		ID:          doc.ID.Hex(),
+		Filename:    doc.Filename,
+		ContentType: doc.ContentType,
+		Size:        doc.Size,
+		Merchant:    doc.Merchant,
+		Date:        doc.Date,
+		Total:       doc.Total,
+		ExpenseIDs:  doc.ExpenseIDs,
+		UploadedBy:  doc.UploadedBy,
+		UploadedAt:  doc.UploadedAt,
+	}
+	for _, item := range doc.Items {
+		rec.Items = append(rec.Items, receipt.LineItem{
+			Description:  item.Description,
+			Quantity:     item.Quantity,
+			Unit:         item.Unit,
+			BaseQuantity: item.BaseQuantity,
+			BaseUnit:     item.BaseUnit,
+			Amount:       item.Amount,
+		})
+	}
+	return rec
+}
+
+func (r *Repository) receiptImages() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(r.client.Database("expense_tracker"), options.GridFSBucket().SetName(receiptImageBucket))
+}
+
+// SaveReceipt uploads the image to GridFS first, so that a stored receipt
+// always has its image
+func (r *Repository) SaveReceipt(rec *receipt.Receipt, image []byte) error {
+	bucket, err := r.receiptImages()
+	if err != nil {
+		return err
+	}
+	imageID, err := bucket.UploadFromStream(rec.Filename, bytes.NewReader(image),
+		options.GridFSUpload().SetMetadata(bson.M{"content_type": rec.ContentType, "uploaded_by": rec.UploadedBy}))
+	if err != nil {
+		return err
+	}
+
+	doc := ReceiptDoc{
+		ImageID:     imageID,
+		Filename:    rec.Filename,
+		ContentType: rec.ContentType,
+		Size:        rec.Size,
+		Merchant:    rec.Merchant,
+		Date:        rec.Date,
+		Total:       rec.Total,
+		Items:       []ReceiptLineItemDoc{},
+		ExpenseIDs:  []string{},
+		UploadedBy:  rec.UploadedBy,
+		UploadedAt:  rec.UploadedAt,
+	}
+	for _, item := range rec.Items {
+		doc.Items = append(doc.Items, ReceiptLineItemDoc{
+			Description:  item.Description,
+			Quantity:     item.Quantity,
+			Unit:         item.Unit,
+			BaseQuantity: item.BaseQuantity,
+			BaseUnit:     item.BaseUnit,
+			Amount:       item.Amount,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.receipts.InsertOne(ctx, doc)
+	if err != nil {
+		bucket.Delete(imageID)
+		return err
+	}
+	rec.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *Repository) LinkReceiptExpenses(id string, expenseIDs []string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return receipt.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.receipts.UpdateByID(ctx, objectID, bson.M{"$set": bson.M{"expense_ids": expenseIDs}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return receipt.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) findReceiptDoc(filter bson.M) (*ReceiptDoc, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var doc ReceiptDoc
+	err := r.receipts.FindOne(ctx, filter).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, receipt.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *Repository) GetReceipt(id string) (*receipt.Receipt, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, receipt.ErrNotFound
+	}
+	doc, err := r.findReceiptDoc(bson.M{"_id": objectID})
+	if err != nil {
+		return nil, err
+	}
+	return doc.toReceipt(), nil
+}
+
+func (r *Repository) FindReceiptByExpense(expenseID string) (*receipt.Receipt, error) {
+	doc, err := r.findReceiptDoc(bson.M{"expense_ids": expenseID})
+	if err != nil {
+		return nil, err
+	}
+	return doc.toReceipt(), nil
+}
+
+func (r *Repository) ReceiptImage(id string) ([]byte, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, receipt.ErrNotFound
+	}
+	doc, err := r.findReceiptDoc(bson.M{"_id": objectID})
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := r.receiptImages()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := bucket.DownloadToStream(doc.ImageID, &buf); err != nil {
+		if err == gridfs.ErrFileNotFound {
+			return nil, receipt.ErrNotFound
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
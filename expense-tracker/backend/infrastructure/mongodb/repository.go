@@ -6,6 +6,8 @@ import (
 	"os"
 	"time"
 	"expense-tracker/domain/expense"
+	domainuser "expense-tracker/domain/user"
+	"expense-tracker/infrastructure/secrets"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -17,12 +19,37 @@ type Repository struct {
 	collection *mongo.Collection
 	settings   *mongo.Collection
 	users      *mongo.Collection
+	invites    *mongo.Collection
+	sessions   *mongo.Collection
+	audit      *mongo.Collection
+	migrations *mongo.Collection
+	// idempotency holds Idempotency-Key records until they expire
+	idempotency *mongo.Collection
+	// dismissals holds expense pairs marked as not duplicates
+	dismissals *mongo.Collection
+	webhooks   *mongo.Collection
+	// deliveries is the webhook delivery log
+	deliveries *mongo.Collection
+	linkCodes  *mongo.Collection
+	chatLinks  *mongo.Collection
+	// receipts refer to their images in the receipt_images GridFS bucket
+	receipts *mongo.Collection
+	// attachmentFiles is the files collection of the attachments GridFS
+	// bucket, queried directly to list an expense's attachments
+	attachmentFiles *mongo.Collection
+	rates           *mongo.Collection
+	prices          *mongo.Collection
+	items           *mongo.Collection
+	secrets         *secrets.Box
 }
 
 type ExpenseDoc struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty"`
 	Items           string             `bson:"items"`
 	Amount          int64              `bson:"amount"`
+	Currency        string             `bson:"currency,omitempty"`
+	OriginalAmount  int64              `bson:"original_amount,omitempty"`
+	Rate            string             `bson:"rate,omitempty"`
 	Quantity        string             `bson:"quantity,omitempty"`
 	Unit            string             `bson:"unit,omitempty"`
 	BaseQuantity    string             `bson:"base_quantity,omitempty"`
@@ -30,8 +57,13 @@ type ExpenseDoc struct {
 	OriginalMessage string             `bson:"original_message,omitempty"`
 	PaidDate        time.Time          `bson:"paid_date"`
 	PaidBy          string             `bson:"paid_by"`
+	Kind            string             `bson:"kind"`
+	PaidTo          string             `bson:"paid_to,omitempty"`
+	Tags            []string           `bson:"tags,omitempty"`
+	Notes           string             `bson:"notes,omitempty"`
 	Status          string             `bson:"status"`
 	DeletedDate     *time.Time         `bson:"deleted_date,omitempty"`
+	Version         int64              `bson:"version"`
 }
 
 type UserDoc struct {
@@ -39,6 +71,7 @@ type UserDoc struct {
 	Username  string             `bson:"username"`
 	Password  string             `bson:"password"`
 	Role      string             `bson:"role"`
+	Disabled  bool               `bson:"disabled,omitempty"`
 	CreatedAt time.Time          `bson:"created_at"`
 }
 
@@ -63,13 +96,65 @@ func NewRepository() (*Repository, error) {
 	collection := client.Database("expense_tracker").Collection("expenses")
 	settings := client.Database("expense_tracker").Collection("settings")
 	users := client.Database("expense_tracker").Collection("users")
+	invites := client.Database("expense_tracker").Collection("invites")
+	sessions := client.Database("expense_tracker").Collection("sessions")
+	audit := client.Database("expense_tracker").Collection("audit_log")
+	migrations := client.Database("expense_tracker").Collection("migrations")
+	idempotency := client.Database("expense_tracker").Collection("idempotency_keys")
+	dismissals := client.Database("expense_tracker").Collection("duplicate_dismissals")
+	webhooks := client.Database("expense_tracker").Collection("webhooks")
+	deliveries := client.Database("expense_tracker").Collection("webhook_deliveries")
+	linkCodes := client.Database("expense_tracker").Collection("chat_link_codes")
+	chatLinks := client.Database("expense_tracker").Collection("chat_links")
+	receipts := client.Database("expense_tracker").Collection("receipts")
+	attachmentFiles := client.Database("expense_tracker").Collection(attachmentBucket + ".files")
+	rates := client.Database("expense_tracker").Collection("exchange_rates")
+	prices := client.Database("expense_tracker").Collection("unit_prices")
+	items := client.Database("expense_tracker").Collection("catalogue_items")
+
+	box, err := secrets.NewBoxFromEnv()
+	if err == secrets.ErrNoMasterKey {
+		log.Printf("[MONGO] Warning: SECRETS_KEY not set, API keys cannot be saved from the settings page")
+	} else if err != nil {
+		return nil, err
+	}
 	
-	return &Repository{
+	repo := &Repository{
 		client:     client,
 		collection: collection,
 		settings:   settings,
 		users:      users,
-	}, nil
+		invites:    invites,
+		sessions:   sessions,
+		audit:      audit,
+		migrations: migrations,
+		secrets:    box,
+
+		idempotency: idempotency,
+		dismissals:  dismissals,
+		webhooks:    webhooks,
+		deliveries:  deliveries,
+		linkCodes:   linkCodes,
+		chatLinks:   chatLinks,
+		receipts:    receipts,
+
+		attachmentFiles: attachmentFiles,
+		rates:           rates,
+		prices:          prices,
+		items:           items,
+	}
+	if ran, err := repo.Migrate(); err != nil {
+		return nil, err
+	} else if ran > 0 {
+		log.Printf("[MONGO] Applied %d migration(s)", ran)
+	}
+
+	// Indexes come after migrations, which remove duplicates a unique
+	// index would reject
+	if err := repo.ensureIndexes(); err != nil {
+		return nil, err
+	}
+	return repo, nil
 }
 
 func (r *Repository) Save(exp *expense.Expense) error {
@@ -79,6 +164,9 @@ func (r *Repository) Save(exp *expense.Expense) error {
 	doc := ExpenseDoc{
 		Items:           exp.Items(),
 		Amount:          exp.Amount(),
+		Currency:        string(exp.Original().Currency()),
+		OriginalAmount:  exp.Original().Value(),
+		Rate:            exp.Rate(),
 		Quantity:        exp.Quantity(),
 		Unit:            exp.Unit(),
 		BaseQuantity:    exp.BaseQuantity(),
@@ -86,23 +174,98 @@ func (r *Repository) Save(exp *expense.Expense) error {
 		OriginalMessage: exp.OriginalMessage(),
 		PaidDate:        exp.PaidDate(),
 		PaidBy:          exp.PaidBy(),
+		Kind:            string(exp.Kind()),
+		PaidTo:          exp.PaidTo(),
+		Tags:            exp.Tags(),
+		Notes:           exp.Notes(),
 		Status:          "active",
+		Version:         1,
 	}
 
 	log.Printf("[MONGO] Saving expense: Items=%s, Quantity=%s, Unit=%s, BaseQuantity=%s, BaseUnit=%s", 
 		doc.Items, doc.Quantity, doc.Unit, doc.BaseQuantity, doc.BaseUnit)
 
-	_, err := r.collection.InsertOne(ctx, doc)
+	result, err := r.collection.InsertOne(ctx, doc)
 	if err != nil {
 		log.Printf("[MONGO] Save error: %v", err)
+		return err
 	}
-	return err
+	exp.SetID(result.InsertedID.(primitive.ObjectID).Hex())
+	exp.SetVersion(doc.Version)
+	return nil
 }
 
 func (r *Repository) FindByID(id int) (*expense.Expense, error) {
 	return nil, nil
 }
 
+func (r *Repository) GetByID(id string) (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, expense.ErrExpenseNotFound
+	}
+
+	var doc ExpenseDoc
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, expense.ErrExpenseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.toMap(), nil
+}
+
+// toMap is the shape GetByID returns
+func (doc *ExpenseDoc) toMap() map[string]interface{} {
+	result := map[string]interface{}{
+		"id":              doc.ID.Hex(),
+		"items":           doc.Items,
+		"amount":          doc.Amount,
+		"currency":        doc.Currency,
+		"originalAmount":  doc.original(),
+		"rate":            doc.Rate,
+		"quantity":        doc.Quantity,
+		"unit":            doc.Unit,
+		"baseQuantity":    doc.BaseQuantity,
+		"baseUnit":        doc.BaseUnit,
+		"originalMessage": doc.OriginalMessage,
+		"paidDate":        doc.PaidDate.Format("2006-01-02"),
+		"paidBy":          doc.PaidBy,
+		"kind":            string(expense.KindOf(doc.Kind)),
+		"paidTo":          doc.PaidTo,
+		"tags":            doc.tags(),
+		"notes":           doc.Notes,
+		"status":          doc.Status,
+		"version":         doc.Version,
+	}
+	if doc.DeletedDate != nil {
+		result["deletedDate"] = doc.DeletedDate.Format("2006-01-02")
+	}
+	return result
+}
+
+// original is the amount as paid; expenses stored before currencies existed
+// were paid in the base currency
+func (doc *ExpenseDoc) original() int64 {
+	if doc.Currency == "" {
+		return doc.Amount
+	}
+	return doc.OriginalAmount
+}
+
+// tags is never nil, as for the other backends: untagged expenses have no
+// tags field
+func (doc *ExpenseDoc) tags() []string {
+	if doc.Tags == nil {
+		return []string{}
+	}
+	return doc.Tags
+}
+
 func (r *Repository) FindAll() ([]*expense.Expense, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -121,6 +284,12 @@ func (r *Repository) FindAll() ([]*expense.Expense, error) {
 		}
 		
 		exp := expense.NewExpenseWithDate(doc.Items, doc.Amount, doc.PaidBy, doc.PaidDate)
+		exp.SetKind(expense.KindOf(doc.Kind), doc.PaidTo)
+		exp.SetTags(doc.Tags)
+		exp.SetNotes(doc.Notes)
+		if doc.Currency != "" {
+			exp.SetOriginal(expense.NewMoneyIn(doc.OriginalAmount, expense.Currency(doc.Currency)), doc.Rate)
+		}
 		expenses = append(expenses, exp)
 	}
 
@@ -146,6 +315,12 @@ func (r *Repository) FindActiveExpenses() ([]*expense.Expense, error) {
 		}
 		
 		exp := expense.NewExpenseWithDate(doc.Items, doc.Amount, doc.PaidBy, doc.PaidDate)
+		exp.SetKind(expense.KindOf(doc.Kind), doc.PaidTo)
+		exp.SetTags(doc.Tags)
+		exp.SetNotes(doc.Notes)
+		if doc.Currency != "" {
+			exp.SetOriginal(expense.NewMoneyIn(doc.OriginalAmount, expense.Currency(doc.Currency)), doc.Rate)
+		}
 		expenses = append(expenses, exp)
 	}
 
@@ -176,6 +351,9 @@ func (r *Repository) GetAll() ([]map[string]interface{}, error) {
 			"no":              doc.ID.Hex(),
 			"items":           doc.Items,
 			"amount":          doc.Amount,
+			"currency":        doc.Currency,
+			"originalAmount":  doc.original(),
+			"rate":            doc.Rate,
 			"quantity":        doc.Quantity,
 			"unit":            doc.Unit,
 			"baseQuantity":    doc.BaseQuantity,
@@ -183,6 +361,11 @@ func (r *Repository) GetAll() ([]map[string]interface{}, error) {
 			"originalMessage": doc.OriginalMessage,
 			"paidDate":        doc.PaidDate.Format("2006-01-02"),
 			"paidBy":          doc.PaidBy,
+			"kind":            string(expense.KindOf(doc.Kind)),
+			"paidTo":          doc.PaidTo,
+			"tags":            doc.tags(),
+			"notes":           doc.Notes,
+			"version":         doc.Version,
 		})
 		counter++
 	}
@@ -195,22 +378,36 @@ func (r *Repository) GetSummaryByPaidBy() (map[string]int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	summary := make(map[string]int64)
+	if err := r.sumAmounts(ctx, summary, bson.M{"status": bson.M{"$ne": "deleted"}}, "$paid_by", 1); err != nil {
+		return nil, err
+	}
+	// A transfer credits its payer and debits its recipient by the same amount
+	transfers := bson.M{"status": bson.M{"$ne": "deleted"}, "kind": string(expense.KindTransfer)}
+	if err := r.sumAmounts(ctx, summary, transfers, "$paid_to", -1); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// sumAmounts adds sign times the total amount of the expenses matching filter,
+// grouped by the member in field, to summary
+func (r *Repository) sumAmounts(ctx context.Context, summary map[string]int64, filter bson.M, field string, sign int64) error {
 	pipeline := []bson.M{
-		{"$match": bson.M{"status": bson.M{"$ne": "deleted"}}},
+		{"$match": filter},
 		{"$group": bson.M{
-			"_id":   "$paid_by",
+			"_id":   field,
 			"total": bson.M{"$sum": "$amount"},
 		}},
-		{"$sort": bson.M{"_id": 1}},
 	}
 
 	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer cursor.Close(ctx)
 
-	summary := make(map[string]int64)
 	for cursor.Next(ctx) {
 		var result struct {
 			ID    string `bson:"_id"`
@@ -219,36 +416,123 @@ func (r *Repository) GetSummaryByPaidBy() (map[string]int64, error) {
 		if err := cursor.Decode(&result); err != nil {
 			continue
 		}
-		summary[result.ID] = result.Total
+		summary[result.ID] += sign * result.Total
 	}
-
-	return summary, nil
+	return cursor.Err()
 }
 
-func (r *Repository) Delete(id string) error {
+// updateVersion applies update to the expense with id if it is at version
+// and has status, bumping the version. When nothing matches it looks the
+// expense up to return the reason.
+func (r *Repository) updateVersion(id string, version int64, status expense.Status, update bson.M) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if id == "" {
-		return nil
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return expense.ErrExpenseNotFound
 	}
 
-	objectID, err := primitive.ObjectIDFromHex(id)
+	update["$inc"] = bson.M{"version": 1}
+	filter := bson.M{"_id": objectID, "version": version, "status": string(status)}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		log.Printf("[MONGO] Invalid ObjectID: %s, error: %v", id, err)
 		return err
 	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	var doc ExpenseDoc
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return expense.ErrExpenseNotFound
+	case err != nil:
+		return err
+	case doc.Version != version:
+		return expense.ErrVersionConflict
+	case status == expense.StatusDeleted:
+		return expense.ErrExpenseNotDeleted
+	default:
+		return expense.ErrExpenseDeleted
+	}
+}
+
+func (r *Repository) Update(id string, version int64, changes expense.Changes) error {
+	set := bson.M{}
+	if changes.Items != nil {
+		set["items"] = *changes.Items
+	}
+	if changes.Amount != nil {
+		set["amount"] = *changes.Amount
+	}
+	if changes.Currency != nil {
+		set["currency"] = string(*changes.Currency)
+	}
+	if changes.OriginalAmount != nil {
+		set["original_amount"] = *changes.OriginalAmount
+	}
+	if changes.Rate != nil {
+		set["rate"] = *changes.Rate
+	}
+	if changes.Quantity != nil {
+		set["quantity"] = *changes.Quantity
+	}
+	if changes.Unit != nil {
+		set["unit"] = *changes.Unit
+	}
+	if changes.BaseQuantity != nil {
+		set["base_quantity"] = *changes.BaseQuantity
+	}
+	if changes.BaseUnit != nil {
+		set["base_unit"] = *changes.BaseUnit
+	}
+	if changes.PaidDate != nil {
+		set["paid_date"] = *changes.PaidDate
+	}
+	if changes.PaidBy != nil {
+		set["paid_by"] = *changes.PaidBy
+	}
+	if changes.Kind != nil {
+		set["kind"] = string(*changes.Kind)
+	}
+	if changes.PaidTo != nil {
+		set["paid_to"] = *changes.PaidTo
+	}
+	if changes.Tags != nil {
+		set["tags"] = *changes.Tags
+	}
+	if changes.Notes != nil {
+		set["notes"] = *changes.Notes
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	log.Printf("[MONGO] Updating expense %s at version %d", id, version)
+	return r.updateVersion(id, version, expense.StatusActive, update)
+}
+
+func (r *Repository) Delete(id string, version int64) error {
+	if id == "" {
+		return nil
+	}
 
 	log.Printf("[MONGO] Soft deleting expense with ObjectID: %s", id)
-	filter := bson.M{"_id": objectID}
-	now := time.Now()
-	update := bson.M{"$set": bson.M{
-		"status": "deleted",
-		"deleted_date": now,
-	}}
-	result, err := r.collection.UpdateOne(ctx, filter, update)
-	log.Printf("[MONGO] Soft delete result: %+v, error: %v", result, err)
-	return err
+	return r.updateVersion(id, version, expense.StatusActive, bson.M{"$set": bson.M{
+		"status":       "deleted",
+		"deleted_date": time.Now(),
+	}})
+}
+
+func (r *Repository) Restore(id string, version int64) error {
+	log.Printf("[MONGO] Restoring expense with ObjectID: %s", id)
+	return r.updateVersion(id, version, expense.StatusDeleted, bson.M{
+		"$set":   bson.M{"status": "active"},
+		"$unset": bson.M{"deleted_date": ""},
+	})
 }
 
 func (r *Repository) GetDeleted() ([]map[string]interface{}, error) {
@@ -284,6 +568,9 @@ func (r *Repository) GetDeleted() ([]map[string]interface{}, error) {
 			"id":              doc.ID.Hex(),
 			"items":           doc.Items,
 			"amount":          doc.Amount,
+			"currency":        doc.Currency,
+			"originalAmount":  doc.original(),
+			"rate":            doc.Rate,
 			"quantity":        doc.Quantity,
 			"unit":            doc.Unit,
 			"baseQuantity":    doc.BaseQuantity,
@@ -291,7 +578,12 @@ func (r *Repository) GetDeleted() ([]map[string]interface{}, error) {
 			"originalMessage": doc.OriginalMessage,
 			"paidDate":        doc.PaidDate.Format("2006-01-02"),
 			"paidBy":          doc.PaidBy,
+			"kind":            string(expense.KindOf(doc.Kind)),
+			"paidTo":          doc.PaidTo,
+			"tags":            doc.tags(),
+			"notes":           doc.Notes,
 			"deletedDate":     deletedDate,
+			"version":         doc.Version,
 		})
 	}
 
@@ -311,29 +603,64 @@ func (r *Repository) ClearAll() error {
 	}
 
 	log.Printf("[MONGO] Successfully deleted %d documents", result.DeletedCount)
-	return nil
+	return r.deleteOrphanedAttachments()
 }
 
-func (r *Repository) Close() error {
+func (r *Repository) Purge(id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	return r.client.Disconnect(ctx)
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return expense.ErrExpenseNotFound
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID, "status": "deleted"})
+	if err != nil {
+		log.Printf("[MONGO] Purge error: %v", err)
+		return err
+	}
+	if result.DeletedCount == 0 {
+		// Either unknown or not in the trash; tell the two apart
+		if count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objectID}); err == nil && count > 0 {
+			return expense.ErrExpenseNotDeleted
+		}
+		return expense.ErrExpenseNotFound
+	}
+	log.Printf("[MONGO] Purged expense %s", id)
+	return r.deleteOrphanedAttachments()
 }
 
-func (r *Repository) SaveAPIKey(apiKey string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *Repository) PurgeDeleted(before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
 
-	filter := bson.M{"key": "gemini_api_key"}
-	update := bson.M{"$set": bson.M{
-		"key":        "gemini_api_key",
-		"value":      apiKey,
-		"updated_at": time.Now(),
-	}}
-	opts := options.Update().SetUpsert(true)
-	
-	_, err := r.settings.UpdateOne(ctx, filter, update, opts)
+	filter := bson.M{"status": "deleted"}
+	if !before.IsZero() {
+		filter["deleted_date"] = bson.M{"$lt": before}
+	}
+
+	result, err := r.collection.DeleteMany(ctx, filter)
 	if err != nil {
+		log.Printf("[MONGO] Purge error: %v", err)
+		return 0, err
+	}
+	if result.DeletedCount > 0 {
+		if err := r.deleteOrphanedAttachments(); err != nil {
+			return int(result.DeletedCount), err
+		}
+	}
+	return int(result.DeletedCount), nil
+}
+
+func (r *Repository) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return r.client.Disconnect(ctx)
+}
+
+func (r *Repository) SaveAPIKey(apiKey string) error {
+	if err := r.saveSecret("gemini_api_key", apiKey); err != nil {
 		log.Printf("[MONGO] Save API key error: %v", err)
 		return err
 	}
@@ -343,51 +670,31 @@ func (r *Repository) SaveAPIKey(apiKey string) error {
 }
 
 func (r *Repository) GetAPIKey() (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	var result struct {
-		Value string `bson:"value"`
-	}
-	
-	filter := bson.M{"key": "gemini_api_key"}
-	err := r.settings.FindOne(ctx, filter).Decode(&result)
+	apiKey, err := r.getSecret("gemini_api_key")
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return "", nil
-		}
 		log.Printf("[MONGO] Get API key error: %v", err)
 		return "", err
 	}
-	
-	return result.Value, nil
+	return apiKey, nil
 }
 
 func (r *Repository) CreateUser(username, password string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Check if user exists
-	var existing UserDoc
-	err := r.users.FindOne(ctx, bson.M{"username": username}).Decode(&existing)
-	if err == nil {
-		return mongo.ErrNoDocuments // User exists
-	}
-
-	// Determine role
-	role := "supervisor"
-	if username == "admin" {
-		role = "admin"
-	}
-
 	doc := UserDoc{
 		Username:  username,
 		Password:  password,
-		Role:      role,
+		Role:      string(domainuser.DefaultRole(username)),
 		CreatedAt: time.Now(),
 	}
 
-	_, err = r.users.InsertOne(ctx, doc)
+	// The unique username index rejects duplicates, even between
+	// concurrent registrations
+	_, err := r.users.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return domainuser.ErrUserExists
+	}
 	return err
 }
 
@@ -397,9 +704,15 @@ func (r *Repository) GetUser(username string) (string, error) {
 
 	var user UserDoc
 	err := r.users.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return "", domainuser.ErrUserNotFound
+	}
 	if err != nil {
 		return "", err
 	}
+	if user.Disabled {
+		return "", domainuser.ErrUserDisabled
+	}
 	return user.Password, nil
 }
 
@@ -412,7 +725,7 @@ func (r *Repository) InitDefaultUsers() error {
 
 	for username, password := range defaultUsers {
 		err := r.CreateUser(username, password)
-		if err != nil && err != mongo.ErrNoDocuments {
+		if err != nil && err != domainuser.ErrUserExists {
 			log.Printf("[MONGO] Failed to create user %s: %v", username, err)
 		}
 	}
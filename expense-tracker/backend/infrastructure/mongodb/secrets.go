@@ -0,0 +1,108 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"expense-tracker/infrastructure/secrets"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// secretSettingKeys lists the settings whose values are encrypted at rest
+var secretSettingKeys = []string{"gemini_api_key"}
+
+func (r *Repository) saveSecret(key, value string) error {
+	if r.secrets == nil {
+		return secrets.ErrNoMasterKey
+	}
+
+	encrypted, err := r.secrets.Encrypt(value)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"key": key}
+	update := bson.M{"$set": bson.M{
+		"key":        key,
+		"value":      encrypted,
+		"updated_at": time.Now(),
+	}}
+	opts := options.Update().SetUpsert(true)
+
+	_, err = r.settings.UpdateOne(ctx, filter, update, opts)
+	return err
+}
+
+func (r *Repository) getSecret(key string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var result struct {
+		Value string `bson:"value"`
+	}
+
+	err := r.settings.FindOne(ctx, bson.M{"key": key}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !secrets.IsEncrypted(result.Value) {
+		// Legacy plaintext value, encrypted by RotateSecrets at next startup
+		return result.Value, nil
+	}
+	if r.secrets == nil {
+		return "", secrets.ErrNoMasterKey
+	}
+	return r.secrets.Decrypt(result.Value)
+}
+
+// RotateSecrets re-encrypts stored secrets that are still plaintext or were
+// encrypted with a previous master key (SECRETS_KEY_PREVIOUS), including
+// webhook secrets. It returns the number of secrets rewritten.
+func (r *Repository) RotateSecrets() (int, error) {
+	if r.secrets == nil {
+		return 0, secrets.ErrNoMasterKey
+	}
+
+	rotated := 0
+	for _, key := range secretSettingKeys {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		var result struct {
+			Value string `bson:"value"`
+		}
+		err := r.settings.FindOne(ctx, bson.M{"key": key}).Decode(&result)
+		cancel()
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return rotated, err
+		}
+		if !r.secrets.NeedsRotation(result.Value) {
+			continue
+		}
+
+		plaintext, err := r.secrets.Decrypt(result.Value)
+		if err != nil {
+			log.Printf("[MONGO] Cannot decrypt secret %s for rotation: %v", key, err)
+			return rotated, err
+		}
+		if err := r.saveSecret(key, plaintext); err != nil {
+			return rotated, err
+		}
+		log.Printf("[MONGO] Re-encrypted secret %s with current master key", key)
+		rotated++
+	}
+
+	webhooks, err := r.rotateWebhookSecrets()
+	return rotated + webhooks, err
+}
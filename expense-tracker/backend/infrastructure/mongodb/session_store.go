@@ -0,0 +1,196 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	domainuser "expense-tracker/domain/user"
+	"expense-tracker/infrastructure/sessionstore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionDoc struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty"`
+	TokenHash string                 `bson:"token_hash"`
+	Username  string                 `bson:"username,omitempty"`
+	Values    map[string]interface{} `bson:"values"`
+	IP        string                 `bson:"ip"`
+	UserAgent string                 `bson:"user_agent"`
+	CreatedAt time.Time              `bson:"created_at"`
+	LastSeen  time.Time              `bson:"last_seen"`
+	ExpiresAt time.Time              `bson:"expires_at"`
+}
+
+func toSessionRecord(doc SessionDoc) *sessionstore.Record {
+	return &sessionstore.Record{
+		ID:        doc.ID.Hex(),
+		TokenHash: doc.TokenHash,
+		Username:  doc.Username,
+		Values:    doc.Values,
+		IP:        doc.IP,
+		UserAgent: doc.UserAgent,
+		CreatedAt: doc.CreatedAt,
+		LastSeen:  doc.LastSeen,
+		ExpiresAt: doc.ExpiresAt,
+	}
+}
+
+func (r *Repository) FindSession(id string) (*sessionstore.Record, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domainuser.ErrSessionNotFound
+	}
+	return r.findSession(bson.M{"_id": objectID})
+}
+
+func (r *Repository) FindSessionByToken(tokenHash string) (*sessionstore.Record, error) {
+	return r.findSession(bson.M{"token_hash": tokenHash})
+}
+
+func (r *Repository) findSession(filter bson.M) (*sessionstore.Record, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var doc SessionDoc
+	err := r.sessions.FindOne(ctx, filter).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, domainuser.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toSessionRecord(doc), nil
+}
+
+func (r *Repository) InsertSession(record sessionstore.Record) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := SessionDoc{
+		TokenHash: record.TokenHash,
+		Username:  record.Username,
+		Values:    record.Values,
+		IP:        record.IP,
+		UserAgent: record.UserAgent,
+		CreatedAt: record.CreatedAt,
+		LastSeen:  record.LastSeen,
+		ExpiresAt: record.ExpiresAt,
+	}
+	result, err := r.sessions.InsertOne(ctx, doc)
+	if err != nil {
+		log.Printf("[MONGO] Insert session error: %v", err)
+		return "", err
+	}
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+func (r *Repository) UpdateSessionValues(id string, values map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domainuser.ErrSessionNotFound
+	}
+	_, err = r.sessions.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"values": values}})
+	return err
+}
+
+func (r *Repository) TouchSession(id, ip, userAgent string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domainuser.ErrSessionNotFound
+	}
+	update := bson.M{"$set": bson.M{"last_seen": now, "ip": ip, "user_agent": userAgent}}
+	_, err = r.sessions.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	return err
+}
+
+func (r *Repository) DeleteSession(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil
+	}
+	_, err = r.sessions.DeleteOne(ctx, bson.M{"_id": objectID})
+	return err
+}
+
+// ListSessions returns the unexpired sessions of username, newest activity first
+func (r *Repository) ListSessions(username string) ([]domainuser.SessionDTO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"username": username, "expires_at": bson.M{"$gt": time.Now()}}
+	opts := options.Find().SetSort(bson.M{"last_seen": -1})
+	cursor, err := r.sessions.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []domainuser.SessionDTO
+	for cursor.Next(ctx) {
+		var doc SessionDoc
+		if err := cursor.Decode(&doc); err != nil {
+			continue
+		}
+		result = append(result, domainuser.SessionDTO{
+			ID:        doc.ID.Hex(),
+			IP:        doc.IP,
+			UserAgent: doc.UserAgent,
+			CreatedAt: doc.CreatedAt,
+			LastSeen:  doc.LastSeen,
+			ExpiresAt: doc.ExpiresAt,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession ends one session, only if it belongs to username
+func (r *Repository) RevokeSession(username, id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domainuser.ErrSessionNotFound
+	}
+
+	result, err := r.sessions.DeleteOne(ctx, bson.M{"_id": objectID, "username": username})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domainuser.ErrSessionNotFound
+	}
+	log.Printf("[MONGO] Revoked session %s of %s", id, username)
+	return nil
+}
+
+// RevokeUserSessions ends every session of username except exceptID
+func (r *Repository) RevokeUserSessions(username, exceptID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"username": username}
+	if objectID, err := primitive.ObjectIDFromHex(exceptID); err == nil {
+		filter["_id"] = bson.M{"$ne": objectID}
+	}
+
+	result, err := r.sessions.DeleteMany(ctx, filter)
+	if err != nil {
+		return err
+	}
+	log.Printf("[MONGO] Revoked %d session(s) of %s", result.DeletedCount, username)
+	return nil
+}
@@ -0,0 +1,264 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	domainuser "expense-tracker/domain/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InviteDoc struct {
+	Code      string     `bson:"code"`
+	CreatedBy string     `bson:"created_by"`
+	CreatedAt time.Time  `bson:"created_at"`
+	ExpiresAt time.Time  `bson:"expires_at"`
+	UsedBy    string     `bson:"used_by,omitempty"`
+	UsedAt    *time.Time `bson:"used_at,omitempty"`
+}
+
+func toUserDTO(doc UserDoc) domainuser.UserDTO {
+	return domainuser.UserDTO{
+		Username:  doc.Username,
+		Role:      domainuser.Role(doc.Role),
+		Disabled:  doc.Disabled,
+		CreatedAt: doc.CreatedAt,
+	}
+}
+
+func toInviteDTO(doc InviteDoc) domainuser.InviteDTO {
+	return domainuser.InviteDTO{
+		Code:      doc.Code,
+		CreatedBy: doc.CreatedBy,
+		CreatedAt: doc.CreatedAt,
+		ExpiresAt: doc.ExpiresAt,
+		UsedBy:    doc.UsedBy,
+		UsedAt:    doc.UsedAt,
+	}
+}
+
+func (r *Repository) FindUser(username string) (*domainuser.UserDTO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var doc UserDoc
+	err := r.users.FindOne(ctx, bson.M{"username": username}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, domainuser.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dto := toUserDTO(doc)
+	return &dto, nil
+}
+
+func (r *Repository) ListUsers() ([]domainuser.UserDTO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.users.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []domainuser.UserDTO
+	for cursor.Next(ctx) {
+		var doc UserDoc
+		if err := cursor.Decode(&doc); err != nil {
+			log.Printf("[MONGO] ListUsers - Decode error: %v", err)
+			continue
+		}
+		users = append(users, toUserDTO(doc))
+	}
+
+	return users, nil
+}
+
+func (r *Repository) updateUser(username string, set bson.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.users.UpdateOne(ctx, bson.M{"username": username}, bson.M{"$set": set})
+	if err != nil {
+		log.Printf("[MONGO] Update user %s error: %v", username, err)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domainuser.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *Repository) SetUserRole(username string, role domainuser.Role) error {
+	log.Printf("[MONGO] Setting role of %s to %s", username, role)
+	return r.updateUser(username, bson.M{"role": string(role)})
+}
+
+func (r *Repository) SetUserDisabled(username string, disabled bool) error {
+	log.Printf("[MONGO] Setting disabled=%t for %s", disabled, username)
+	return r.updateUser(username, bson.M{"disabled": disabled})
+}
+
+func (r *Repository) ResetPassword(username, password string) error {
+	log.Printf("[MONGO] Resetting password for %s", username)
+	return r.updateUser(username, bson.M{"password": password})
+}
+
+func (r *Repository) DeleteUser(username string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	log.Printf("[MONGO] Deleting user %s", username)
+	result, err := r.users.DeleteOne(ctx, bson.M{"username": username})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domainuser.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetRegistrationMode() (domainuser.RegistrationMode, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var result struct {
+		Value string `bson:"value"`
+	}
+
+	err := r.settings.FindOne(ctx, bson.M{"key": "registration_mode"}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return domainuser.RegistrationOpen, nil
+	}
+	if err != nil {
+		log.Printf("[MONGO] Get registration mode error: %v", err)
+		return "", err
+	}
+
+	return domainuser.ParseRegistrationMode(result.Value)
+}
+
+func (r *Repository) SaveRegistrationMode(mode domainuser.RegistrationMode) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"key": "registration_mode"}
+	update := bson.M{"$set": bson.M{
+		"key":        "registration_mode",
+		"value":      string(mode),
+		"updated_at": time.Now(),
+	}}
+	opts := options.Update().SetUpsert(true)
+
+	if _, err := r.settings.UpdateOne(ctx, filter, update, opts); err != nil {
+		log.Printf("[MONGO] Save registration mode error: %v", err)
+		return err
+	}
+
+	log.Printf("[MONGO] Registration mode set to %s", mode)
+	return nil
+}
+
+func (r *Repository) CreateInvite(createdBy string, ttl time.Duration) (*domainuser.InviteDTO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	code, err := domainuser.NewInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	doc := InviteDoc{
+		Code:      code,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if _, err := r.invites.InsertOne(ctx, doc); err != nil {
+		log.Printf("[MONGO] Create invite error: %v", err)
+		return nil, err
+	}
+
+	dto := toInviteDTO(doc)
+	return &dto, nil
+}
+
+func (r *Repository) ListInvites() ([]domainuser.InviteDTO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := r.invites.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var invites []domainuser.InviteDTO
+	for cursor.Next(ctx) {
+		var doc InviteDoc
+		if err := cursor.Decode(&doc); err != nil {
+			log.Printf("[MONGO] ListInvites - Decode error: %v", err)
+			continue
+		}
+		invites = append(invites, toInviteDTO(doc))
+	}
+
+	return invites, nil
+}
+
+// ConsumeInvite atomically marks an unused, unexpired invite as used by username
+func (r *Repository) ConsumeInvite(code, username string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"code":       code,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"used_by": username, "used_at": now}}
+
+	result, err := r.invites.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domainuser.ErrInviteInvalid
+	}
+	return nil
+}
+
+// ReleaseInvite undoes ConsumeInvite when the account could not be created
+func (r *Repository) ReleaseInvite(code string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"used_by": "", "used_at": ""}}
+	_, err := r.invites.UpdateOne(ctx, bson.M{"code": code}, update)
+	return err
+}
+
+func (r *Repository) DeleteInvite(code string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.invites.DeleteOne(ctx, bson.M{"code": code})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domainuser.ErrInviteInvalid
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"expense-tracker/domain/expense"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// expenseChange is the part of a change stream event WatchExpenses reads
+type expenseChange struct {
+	OperationType     string      `bson:"operationType"`
+	FullDocument      *ExpenseDoc `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.M `bson:"updatedFields"`
+	} `bson:"updateDescription"`
+}
+
+// WatchExpenses publishes every expense insert and update made by any
+// instance sharing the database, until ctx is done. Change streams need a
+// replica set; the error for a standalone server is returned before
+// anything runs. A stream that breaks later is resumed where it stopped.
+func (r *Repository) WatchExpenses(ctx context.Context, publish func(expense.Event)) error {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{
+		"operationType": bson.M{"$in": bson.A{"insert", "update", "replace"}},
+	}}}}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	stream, err := r.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return err
+	}
+	log.Printf("[MONGO] Watching expense changes")
+
+	go func() {
+		for {
+			for stream.Next(ctx) {
+				var change expenseChange
+				if err := stream.Decode(&change); err != nil {
+					log.Printf("[MONGO] Change stream decode error: %v", err)
+					continue
+				}
+				if change.FullDocument == nil {
+					// Removed before the lookup; there is nothing to show
+					continue
+				}
+				publish(expense.Event{
+					Type:      changeType(change),
+					ExpenseID: change.FullDocument.ID.Hex(),
+					PaidBy:    change.FullDocument.PaidBy,
+					Expense:   change.FullDocument.toMap(),
+					Time:      time.Now(),
+				})
+			}
+
+			resumeToken := stream.ResumeToken()
+			streamErr := stream.Err()
+			stream.Close(context.Background())
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Printf("[MONGO] Change stream stopped (%v), resuming in 5s", streamErr)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			if resumeToken != nil {
+				opts.SetResumeAfter(resumeToken)
+			}
+			for {
+				stream, err = r.collection.Watch(ctx, pipeline, opts)
+				if err == nil {
+					break
+				}
+				log.Printf("[MONGO] Change stream resume failed: %v", err)
+				// The token may have left the oplog; start afresh, and
+				// subscribers that missed events resync on reconnect
+				opts.ResumeAfter = nil
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(30 * time.Second):
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// changeType tells a soft delete and a restore, which are status updates,
+// apart from an edit
+func changeType(change expenseChange) expense.EventType {
+	if change.OperationType == "insert" {
+		return expense.ExpenseCreated
+	}
+	switch change.UpdateDescription.UpdatedFields["status"] {
+	case string(expense.StatusDeleted):
+		return expense.ExpenseDeleted
+	case string(expense.StatusActive):
+		return expense.ExpenseRestored
+	}
+	return expense.ExpenseUpdated
+}
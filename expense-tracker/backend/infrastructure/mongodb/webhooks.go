@@ -0,0 +1,253 @@
+package mongodb
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/webhook"
+	"expense-tracker/infrastructure/secrets"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookDoc struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	URL       string             `bson:"url"`
+	Events    []string           `bson:"events"`
+	Secret    string             `bson:"secret"`
+	CreatedBy string             `bson:"created_by"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+type DeliveryDoc struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	WebhookID      string             `bson:"webhook_id"`
+	DeliveryID     string             `bson:"delivery_id"`
+	Event          string             `bson:"event"`
+	Attempt        int                `bson:"attempt"`
+	StatusCode     int                `bson:"status_code,omitempty"`
+	Error          string             `bson:"error,omitempty"`
+	DurationMillis int64              `bson:"duration_ms"`
+	Time           time.Time          `bson:"time"`
+}
+
+func (r *Repository) CreateWebhook(subscription webhook.Subscription) (string, error) {
+	if r.secrets == nil {
+		return "", secrets.ErrNoMasterKey
+	}
+	encrypted, err := r.secrets.Encrypt(subscription.Secret)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := WebhookDoc{
+		URL:       subscription.URL,
+		Secret:    encrypted,
+		CreatedBy: subscription.CreatedBy,
+		CreatedAt: subscription.CreatedAt,
+	}
+	for _, event := range subscription.Events {
+		doc.Events = append(doc.Events, string(event))
+	}
+	result, err := r.webhooks.InsertOne(ctx, doc)
+	if err != nil {
+		return "", err
+	}
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+func (r *Repository) toWebhook(doc WebhookDoc) (*webhook.Subscription, error) {
+	if r.secrets == nil {
+		return nil, secrets.ErrNoMasterKey
+	}
+	secret, err := r.secrets.Decrypt(doc.Secret)
+	if err != nil {
+		return nil, err
+	}
+	found := &webhook.Subscription{
+		ID:        doc.ID.Hex(),
+		URL:       doc.URL,
+		Secret:    secret,
+		CreatedBy: doc.CreatedBy,
+		CreatedAt: doc.CreatedAt,
+	}
+	for _, event := range doc.Events {
+		found.Events = append(found.Events, expense.EventType(event))
+	}
+	return found, nil
+}
+
+func (r *Repository) GetWebhook(id string) (*webhook.Subscription, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, webhook.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var doc WebhookDoc
+	err = r.webhooks.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, webhook.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toWebhook(doc)
+}
+
+func (r *Repository) ListWebhooks() ([]webhook.Subscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.webhooks.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []webhook.Subscription
+	for cursor.Next(ctx) {
+		var doc WebhookDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		found, err := r.toWebhook(doc)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *found)
+	}
+	return list, cursor.Err()
+}
+
+func (r *Repository) DeleteWebhook(id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return webhook.ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.webhooks.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return webhook.ErrNotFound
+	}
+	_, err = r.deliveries.DeleteMany(ctx, bson.M{"webhook_id": id})
+	return err
+}
+
+func (r *Repository) RecordDelivery(delivery webhook.Delivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc := DeliveryDoc{
+		WebhookID:      delivery.WebhookID,
+		DeliveryID:     delivery.DeliveryID,
+		Event:          string(delivery.Event),
+		Attempt:        delivery.Attempt,
+		StatusCode:     delivery.StatusCode,
+		Error:          delivery.Error,
+		DurationMillis: delivery.DurationMillis,
+		Time:           delivery.Time,
+	}
+	if _, err := r.deliveries.InsertOne(ctx, doc); err != nil {
+		return err
+	}
+
+	// Trim the log to the newest DeliveryLogSize entries. ObjectIDs grow
+	// with insertion time, so the oldest kept one marks the cut.
+	var oldest DeliveryDoc
+	opts := options.FindOne().SetSort(bson.M{"_id": -1}).SetSkip(webhook.DeliveryLogSize - 1).SetProjection(bson.M{"_id": 1})
+	err := r.deliveries.FindOne(ctx, bson.M{"webhook_id": delivery.WebhookID}, opts).Decode(&oldest)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = r.deliveries.DeleteMany(ctx, bson.M{"webhook_id": delivery.WebhookID, "_id": bson.M{"$lt": oldest.ID}})
+	return err
+}
+
+func (r *Repository) ListDeliveries(webhookID string) ([]webhook.Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetLimit(webhook.DeliveryLogSize)
+	cursor, err := r.deliveries.Find(ctx, bson.M{"webhook_id": webhookID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []webhook.Delivery
+	for cursor.Next(ctx) {
+		var doc DeliveryDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, webhook.Delivery{
+			ID:             doc.ID.Hex(),
+			WebhookID:      doc.WebhookID,
+			DeliveryID:     doc.DeliveryID,
+			Event:          expense.EventType(doc.Event),
+			Attempt:        doc.Attempt,
+			StatusCode:     doc.StatusCode,
+			Error:          doc.Error,
+			DurationMillis: doc.DurationMillis,
+			Time:           doc.Time,
+		})
+	}
+	return deliveries, cursor.Err()
+}
+
+// rotateWebhookSecrets re-encrypts webhook secrets written under a previous
+// master key
+func (r *Repository) rotateWebhookSecrets() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := r.webhooks.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"secret": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	rotated := 0
+	for cursor.Next(ctx) {
+		var doc WebhookDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return rotated, err
+		}
+		if !r.secrets.NeedsRotation(doc.Secret) {
+			continue
+		}
+		plaintext, err := r.secrets.Decrypt(doc.Secret)
+		if err != nil {
+			log.Printf("[MONGO] Cannot decrypt secret of webhook %s for rotation: %v", doc.ID.Hex(), err)
+			return rotated, err
+		}
+		encrypted, err := r.secrets.Encrypt(plaintext)
+		if err != nil {
+			return rotated, err
+		}
+		if _, err := r.webhooks.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": bson.M{"secret": encrypted}}); err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+	return rotated, cursor.Err()
+}
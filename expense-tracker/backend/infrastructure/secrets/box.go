@@ -0,0 +1,153 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+)
+
+// Encrypted values are stored as "enc:v1:<key id>:<base64 nonce+ciphertext>"
+const prefix = "enc:v1:"
+
+var (
+	ErrNoMasterKey = errors.New("SECRETS_KEY is not configured")
+	ErrUnknownKey  = errors.New("secret was encrypted with an unknown master key")
+	ErrMalformed   = errors.New("malformed encrypted secret")
+)
+
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// Box encrypts secrets with the current master key and can still decrypt
+// values written with any of the previous keys, so they can be rotated.
+type Box struct {
+	current  masterKey
+	previous []masterKey
+}
+
+func newMasterKey(passphrase string) (masterKey, error) {
+	sum := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return masterKey{}, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return masterKey{}, err
+	}
+	idSum := sha256.Sum256(append([]byte("key-id:"), sum[:]...))
+	return masterKey{id: hex.EncodeToString(idSum[:4]), aead: aead}, nil
+}
+
+func NewBox(current string, previous ...string) (*Box, error) {
+	if current == "" {
+		return nil, ErrNoMasterKey
+	}
+
+	key, err := newMasterKey(current)
+	if err != nil {
+		return nil, err
+	}
+
+	box := &Box{current: key}
+	for _, passphrase := range previous {
+		if passphrase == "" {
+			continue
+		}
+		old, err := newMasterKey(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		box.previous = append(box.previous, old)
+	}
+	return box, nil
+}
+
+// NewBoxFromEnv reads SECRETS_KEY and the optional comma-separated
+// SECRETS_KEY_PREVIOUS used while rotating to a new master key
+func NewBoxFromEnv() (*Box, error) {
+	var previous []string
+	if value := os.Getenv("SECRETS_KEY_PREVIOUS"); value != "" {
+		previous = strings.Split(value, ",")
+	}
+	return NewBox(os.Getenv("SECRETS_KEY"), previous...)
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+func (b *Box) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, b.current.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.current.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + b.current.id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *Box) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, prefix), ":", 2)
+	if len(parts) != 2 {
+		return "", ErrMalformed
+	}
+
+	key, ok := b.keyByID(parts[0])
+	if !ok {
+		return "", ErrUnknownKey
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil || len(sealed) < key.aead.NonceSize() {
+		return "", ErrMalformed
+	}
+
+	nonceSize := key.aead.NonceSize()
+	plaintext, err := key.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether value is plaintext or encrypted with an old key
+func (b *Box) NeedsRotation(value string) bool {
+	return !strings.HasPrefix(value, prefix+b.current.id+":")
+}
+
+func (b *Box) keyByID(id string) (masterKey, bool) {
+	if b.current.id == id {
+		return b.current, true
+	}
+	for _, key := range b.previous {
+		if key.id == id {
+			return key, true
+		}
+	}
+	return masterKey{}, false
+}
+
+// Fingerprint returns a masked form of a secret that is safe to display
+func Fingerprint(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	tail := ""
+	if len(secret) > 8 {
+		tail = secret[len(secret)-4:]
+	}
+	return "••••" + tail + " (sha256:" + hex.EncodeToString(sum[:4]) + ")"
+}
@@ -0,0 +1,218 @@
+package sessionstore
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+)
+
+// touchInterval limits how often last_seen is written for an active session
+const touchInterval = time.Minute
+
+// Record is a persisted session. Only the hash of the cookie token is kept.
+type Record struct {
+	ID        string
+	TokenHash string
+	Username  string
+	Values    map[string]interface{}
+	IP        string
+	UserAgent string
+	CreatedAt time.Time
+	LastSeen  time.Time
+	ExpiresAt time.Time
+}
+
+// Backend persists session records. FindSession and FindSessionByToken
+// return user.ErrSessionNotFound when there is no such session.
+type Backend interface {
+	FindSession(id string) (*Record, error)
+	FindSessionByToken(tokenHash string) (*Record, error)
+	InsertSession(record Record) (string, error)
+	UpdateSessionValues(id string, values map[string]interface{}) error
+	TouchSession(id, ip, userAgent string, now time.Time) error
+	DeleteSession(id string) error
+}
+
+// Store keeps session data in a Backend. The cookie only carries a signed
+// random token; the record is looked up by its hash, so sessions can be
+// listed and revoked server-side. A session ends after idle time without
+// requests or after its absolute lifetime, whichever is first.
+type Store struct {
+	backend  Backend
+	codecs   []securecookie.Codec
+	options  *gsessions.Options
+	idle     time.Duration
+	absolute time.Duration
+}
+
+func New(backend Backend, secret []byte, idle, absolute time.Duration) *Store {
+	codecs := securecookie.CodecsFromPairs(secret)
+	for _, codec := range codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(int(absolute.Seconds()))
+		}
+	}
+
+	return &Store{
+		backend:  backend,
+		codecs:   codecs,
+		options:  &gsessions.Options{Path: "/", HttpOnly: true},
+		idle:     idle,
+		absolute: absolute,
+	}
+}
+
+func (s *Store) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+}
+
+func (s *Store) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *Store) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	opts := *s.options
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	var token string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &token, s.codecs...); err != nil {
+		return session, nil
+	}
+
+	record, err := s.backend.FindSessionByToken(HashToken(token))
+	if err != nil {
+		return session, nil
+	}
+
+	now := time.Now()
+	if now.After(record.ExpiresAt) || now.Sub(record.LastSeen) > s.idle {
+		log.Printf("[SESSION] Session of %s expired", record.Username)
+		s.delete(record.ID)
+		return session, nil
+	}
+
+	session.ID = record.ID
+	for key, value := range record.Values {
+		session.Values[key] = value
+	}
+	session.IsNew = false
+
+	ip, userAgent := requestIP(r), r.UserAgent()
+	if now.Sub(record.LastSeen) > touchInterval || ip != record.IP || userAgent != record.UserAgent {
+		if err := s.backend.TouchSession(record.ID, ip, userAgent, now); err != nil {
+			log.Printf("[SESSION] Touch session error: %v", err)
+		}
+	}
+	return session, nil
+}
+
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			s.delete(session.ID)
+		}
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(session.Values))
+	for key, value := range session.Values {
+		if name, ok := key.(string); ok {
+			values[name] = value
+		}
+	}
+	username, _ := values["username"].(string)
+
+	if session.ID != "" {
+		existing, err := s.backend.FindSession(session.ID)
+		if err == nil && existing.Username == username {
+			return s.backend.UpdateSessionValues(session.ID, values)
+		}
+		// The session changed hands (login or logout): issue a new token
+		// so a token known before login cannot be reused afterwards.
+		s.delete(session.ID)
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	tokenString := base64.RawURLEncoding.EncodeToString(token)
+
+	now := time.Now()
+	id, err := s.backend.InsertSession(Record{
+		TokenHash: HashToken(tokenString),
+		Username:  username,
+		Values:    values,
+		IP:        requestIP(r),
+		UserAgent: r.UserAgent(),
+		CreatedAt: now,
+		LastSeen:  now,
+		ExpiresAt: now.Add(s.absolute),
+	})
+	if err != nil {
+		return err
+	}
+	session.ID = id
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), tokenString, s.codecs...)
+	if err != nil {
+		return err
+	}
+	opts := *session.Options
+	opts.MaxAge = int(s.absolute.Seconds())
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, &opts))
+	return nil
+}
+
+func (s *Store) delete(id string) {
+	if err := s.backend.DeleteSession(id); err != nil {
+		log.Printf("[SESSION] Delete session error: %v", err)
+	}
+}
+
+// HashToken is the form in which a cookie token is stored by a Backend
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type clientIPKey struct{}
+
+// WithClientIP hands the client address the router resolved to the sessions
+// of r. The store does not read X-Forwarded-For itself: only the router
+// knows which proxies to believe.
+func WithClientIP(r *http.Request, ip string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip))
+}
+
+func requestIP(r *http.Request) string {
+	if ip, _ := r.Context().Value(clientIPKey{}).(string); ip != "" {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
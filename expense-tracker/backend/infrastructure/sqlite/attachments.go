@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"expense-tracker/domain/attachment"
+)
+
+const attachmentColumns = `id, expense_id, filename, content_type, size, uploaded_by, uploaded_at`
+
+func (r *Repository) SaveAttachment(a *attachment.Attachment, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	a.Size = int64(len(data))
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO attachments (expense_id, filename, content_type, size, uploaded_by, uploaded_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		a.ExpenseID, a.Filename, a.ContentType, a.Size, a.UploadedBy, a.UploadedAt.UTC())
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO attachment_data (attachment_id, data) VALUES (?, ?)", id, data); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	a.ID = formatID(id)
+	return nil
+}
+
+func (r *Repository) GetAttachment(id string) (*attachment.Attachment, error) {
+	list, err := r.queryAttachments("WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, attachment.ErrNotFound
+	}
+	return &list[0], nil
+}
+
+func (r *Repository) AttachmentData(id string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, attachment.ErrNotFound
+	}
+	var data []byte
+	err = r.db.QueryRowContext(ctx, "SELECT data FROM attachment_data WHERE attachment_id = ?", n).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, attachment.ErrNotFound
+	}
+	return data, err
+}
+
+func (r *Repository) ListAttachments(expenseID string) ([]attachment.Attachment, error) {
+	return r.queryAttachments("WHERE expense_id = ?", expenseID)
+}
+
+func (r *Repository) ListAllAttachments() ([]attachment.Attachment, error) {
+	return r.queryAttachments("")
+}
+
+func (r *Repository) queryAttachments(where string, args ...interface{}) ([]attachment.Attachment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+attachmentColumns+" FROM attachments "+where+" ORDER BY uploaded_at, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []attachment.Attachment{}
+	for rows.Next() {
+		var (
+			a  attachment.Attachment
+			id int64
+		)
+		if err := rows.Scan(&id, &a.ExpenseID, &a.Filename, &a.ContentType, &a.Size, &a.UploadedBy, &a.UploadedAt); err != nil {
+			return nil, err
+		}
+		a.ID = formatID(id)
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+func (r *Repository) DeleteAttachment(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM attachments WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return attachment.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM attachment_data WHERE attachment_id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteOrphanedAttachments removes the attachments whose expense is gone,
+// in the transaction that removed it
+func deleteOrphanedAttachments(ctx context.Context, tx *sql.Tx) error {
+	const orphans = `SELECT id FROM attachments WHERE expense_id NOT IN (SELECT CAST(id AS TEXT) FROM expenses)`
+	if _, err := tx.ExecContext(ctx, "DELETE FROM attachment_data WHERE attachment_id IN ("+orphans+")"); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "DELETE FROM attachments WHERE id IN ("+orphans+")")
+	return err
+}
@@ -0,0 +1,123 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"expense-tracker/domain/catalogue"
+)
+
+// Aliases are stored as a JSON array, since they may contain commas
+
+func (r *Repository) CreateItem(item catalogue.Item) (string, error) {
+	aliases, err := json.Marshal(item.Aliases)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO catalogue_items (name, aliases, category, unit, updated_by, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		item.Name, string(aliases), item.Category, item.Unit, item.UpdatedBy, item.UpdatedAt.UTC())
+	if err != nil {
+		return "", err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+func scanItem(row scanner) (*catalogue.Item, error) {
+	var (
+		item    catalogue.Item
+		id      int64
+		aliases string
+	)
+	if err := row.Scan(&id, &item.Name, &aliases, &item.Category, &item.Unit, &item.UpdatedBy, &item.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(aliases), &item.Aliases); err != nil {
+		return nil, err
+	}
+	if item.Aliases == nil {
+		item.Aliases = []string{}
+	}
+	item.ID = strconv.FormatInt(id, 10)
+	return &item, nil
+}
+
+func (r *Repository) GetItem(id string) (*catalogue.Item, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	item, err := scanItem(r.db.QueryRowContext(ctx,
+		"SELECT id, name, aliases, category, unit, updated_by, updated_at FROM catalogue_items WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, catalogue.ErrNotFound
+	}
+	return item, err
+}
+
+func (r *Repository) ListItems() ([]catalogue.Item, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT id, name, aliases, category, unit, updated_by, updated_at FROM catalogue_items ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []catalogue.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+func (r *Repository) UpdateItem(item catalogue.Item) error {
+	aliases, err := json.Marshal(item.Aliases)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE catalogue_items SET name = ?, aliases = ?, category = ?, unit = ?, updated_by = ?, updated_at = ? WHERE id = ?",
+		item.Name, string(aliases), item.Category, item.Unit, item.UpdatedBy, item.UpdatedAt.UTC(), item.ID)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return catalogue.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) DeleteItem(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM catalogue_items WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return catalogue.ErrNotFound
+	}
+	return nil
+}
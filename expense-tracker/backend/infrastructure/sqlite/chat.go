@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"expense-tracker/domain/chat"
+)
+
+func (r *Repository) CreateLinkCode(code chat.LinkCode) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Drop expired codes here, as SQLite has no TTL index to do it
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM chat_link_codes WHERE expires_at <= ?", code.CreatedAt.UTC()); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO chat_link_codes (code, username, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		code.Code, code.Username, code.CreatedAt.UTC(), code.ExpiresAt.UTC())
+	return err
+}
+
+func (r *Repository) ConsumeLinkCode(code string, now time.Time) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var (
+		username  string
+		expiresAt time.Time
+	)
+	err = tx.QueryRowContext(ctx, "SELECT username, expires_at FROM chat_link_codes WHERE code = ?", code).Scan(&username, &expiresAt)
+	if err == sql.ErrNoRows {
+		return "", chat.ErrLinkCodeInvalid
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM chat_link_codes WHERE code = ?", code); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	if !expiresAt.After(now) {
+		return "", chat.ErrLinkCodeInvalid
+	}
+	return username, nil
+}
+
+func (r *Repository) SaveChatLink(link chat.Link) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO chat_links (provider, chat_user_id, chat_name, username, linked_at) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (provider, chat_user_id) DO UPDATE SET
+			chat_name = excluded.chat_name, username = excluded.username, linked_at = excluded.linked_at`,
+		link.Provider, link.ChatUserID, link.ChatName, link.Username, link.LinkedAt.UTC())
+	return err
+}
+
+func (r *Repository) FindChatLink(provider, chatUserID string) (*chat.Link, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	link := chat.Link{Provider: provider, ChatUserID: chatUserID}
+	err := r.db.QueryRowContext(ctx,
+		"SELECT chat_name, username, linked_at FROM chat_links WHERE provider = ? AND chat_user_id = ?",
+		provider, chatUserID).Scan(&link.ChatName, &link.Username, &link.LinkedAt)
+	if err == sql.ErrNoRows {
+		return nil, chat.ErrNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *Repository) ListChatLinks(username string) ([]chat.Link, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT provider, chat_user_id, chat_name, linked_at FROM chat_links WHERE username = ? ORDER BY linked_at",
+		username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []chat.Link
+	for rows.Next() {
+		link := chat.Link{Username: username}
+		if err := rows.Scan(&link.Provider, &link.ChatUserID, &link.ChatName, &link.LinkedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+func (r *Repository) DeleteChatLink(username, provider, chatUserID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM chat_links WHERE username = ? AND provider = ? AND chat_user_id = ?",
+		username, provider, chatUserID)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return chat.ErrNotLinked
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !cgo
+
+package sqlite
+
+// Without cgo the driver cannot open a database, so no statement ever fails
+// on a constraint
+func isConstraintError(err error) bool {
+	return false
+}
@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+)
+
+func (r *Repository) DismissDuplicate(pairKey, dismissedBy string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT OR IGNORE INTO duplicate_dismissals (pair_key, dismissed_by, dismissed_at) VALUES (?, ?, ?)",
+		pairKey, dismissedBy, time.Now().UTC())
+	return err
+}
+
+func (r *Repository) ListDismissedDuplicates() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT pair_key FROM duplicate_dismissals ORDER BY pair_key")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
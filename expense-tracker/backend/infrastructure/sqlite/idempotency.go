@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"expense-tracker/domain/idempotency"
+)
+
+func (r *Repository) ClaimIdempotencyKey(record idempotency.Record, staleBefore time.Time) (*idempotency.Record, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Drop expired keys here, as SQLite has no TTL index to do it
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= ?", record.CreatedAt.UTC()); err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (username, key, request_hash, created_at, expires_at)
+		 VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (username, key) DO UPDATE SET
+			request_hash = excluded.request_hash, completed = 0, expense_id = '', status_code = 0,
+			response = NULL, created_at = excluded.created_at, expires_at = excluded.expires_at
+		 WHERE completed = 0 AND created_at < ?`,
+		record.Username, record.Key, record.RequestHash, record.CreatedAt.UTC(), record.ExpiresAt.UTC(), staleBefore.UTC())
+	if err != nil {
+		return nil, err
+	}
+	if claimed, err := result.RowsAffected(); err != nil || claimed > 0 {
+		return nil, err
+	}
+
+	existing := idempotency.Record{Username: record.Username, Key: record.Key}
+	var response []byte
+	err = r.db.QueryRowContext(ctx,
+		`SELECT request_hash, completed, expense_id, status_code, response, created_at, expires_at
+		 FROM idempotency_keys WHERE username = ? AND key = ?`, record.Username, record.Key).Scan(
+		&existing.RequestHash, &existing.Completed, &existing.ExpenseID, &existing.StatusCode,
+		&response, &existing.CreatedAt, &existing.ExpiresAt)
+	if err == sql.ErrNoRows {
+		// Released between the two statements; report it as in progress so
+		// that the client retries
+		record.Completed = false
+		return &record, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	existing.Response = response
+	return &existing, nil
+}
+
+func (r *Repository) CompleteIdempotencyKey(username, key, expenseID string, statusCode int, response []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET completed = 1, expense_id = ?, status_code = ?, response = ?
+		 WHERE username = ? AND key = ?`, expenseID, statusCode, response, username, key)
+	return err
+}
+
+func (r *Repository) ReleaseIdempotencyKey(username, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE username = ? AND key = ? AND completed = 0", username, key)
+	return err
+}
@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/price"
+)
+
+// Paid dates are stored as YYYY-MM-DD text, as exchange rate dates are
+
+const insertPrice = `INSERT INTO unit_prices (expense_id, item, items, paid_date, amount, quantity, unit, unit_price)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (expense_id) DO UPDATE SET
+		item = excluded.item, items = excluded.items, paid_date = excluded.paid_date, amount = excluded.amount,
+		quantity = excluded.quantity, unit = excluded.unit, unit_price = excluded.unit_price`
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func savePrice(ctx context.Context, db execer, point price.Point) error {
+	_, err := db.ExecContext(ctx, insertPrice, point.ExpenseID, point.Item, point.Items,
+		point.PaidDate.Format(exchange.DateLayout), point.Amount, point.Quantity, point.Unit, point.UnitPrice)
+	return err
+}
+
+func (r *Repository) SavePrice(point price.Point) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return savePrice(ctx, r.db, point)
+}
+
+func (r *Repository) DeletePrice(expenseID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM unit_prices WHERE expense_id = ?", expenseID)
+	return err
+}
+
+func (r *Repository) ListPrices(item string) ([]price.Point, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT expense_id, item, items, paid_date, amount, quantity, unit, unit_price FROM unit_prices
+		 WHERE item = ? ORDER BY paid_date, expense_id`, item)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []price.Point{}
+	for rows.Next() {
+		var (
+			point    price.Point
+			paidDate string
+		)
+		if err := rows.Scan(&point.ExpenseID, &point.Item, &point.Items, &paidDate, &point.Amount,
+			&point.Quantity, &point.Unit, &point.UnitPrice); err != nil {
+			return nil, err
+		}
+		if point.PaidDate, err = exchange.ParseDate(paidDate); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+func (r *Repository) ReplacePrices(points []price.Point) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM unit_prices"); err != nil {
+		return err
+	}
+	for _, point := range points {
+		if err := savePrice(ctx, tx, point); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/expense"
+)
+
+// Rate dates are stored as YYYY-MM-DD text so that the primary key compares
+// days rather than timestamps
+
+func (r *Repository) SaveRate(rate exchange.Rate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO exchange_rates (currency, date, rate, updated_by, updated_at) VALUES (?, ?, ?, ?, ?)
+		 ON CONFLICT (currency, date) DO UPDATE SET
+			rate = excluded.rate, updated_by = excluded.updated_by, updated_at = excluded.updated_at`,
+		string(rate.Currency), rate.Date.Format(exchange.DateLayout), rate.Rate, rate.UpdatedBy, rate.UpdatedAt.UTC())
+	return err
+}
+
+func (r *Repository) ListRates() ([]exchange.Rate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT currency, date, rate, updated_by, updated_at FROM exchange_rates ORDER BY currency, date")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []exchange.Rate{}
+	for rows.Next() {
+		var (
+			rate     exchange.Rate
+			currency string
+			date     string
+		)
+		if err := rows.Scan(&currency, &date, &rate.Rate, &rate.UpdatedBy, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rate.Currency = expense.Currency(currency)
+		if rate.Date, err = exchange.ParseDate(date); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+func (r *Repository) DeleteRate(currency expense.Currency, date time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM exchange_rates WHERE currency = ? AND date = ?",
+		string(currency), date.Format(exchange.DateLayout))
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return exchange.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"expense-tracker/domain/receipt"
+)
+
+const receiptColumns = `id, filename, content_type, size, merchant, date, total, items, uploaded_by, uploaded_at`
+
+func (r *Repository) SaveReceipt(rec *receipt.Receipt, image []byte) error {
+	items, err := json.Marshal(rec.Items)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO receipts (filename, content_type, size, merchant, date, total, items, uploaded_by, uploaded_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.Filename, rec.ContentType, rec.Size, rec.Merchant, rec.Date, rec.Total, string(items),
+		rec.UploadedBy, rec.UploadedAt.UTC())
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO receipt_images (receipt_id, data) VALUES (?, ?)", id, image); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	rec.ID = formatID(id)
+	return nil
+}
+
+func (r *Repository) LinkReceiptExpenses(id string, expenseIDs []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM receipts WHERE id = ?", id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return receipt.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM receipt_expenses WHERE receipt_id = ?", id); err != nil {
+		return err
+	}
+	for position, expenseID := range expenseIDs {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO receipt_expenses (receipt_id, expense_id, position) VALUES (?, ?, ?)", id, expenseID, position)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) GetReceipt(id string) (*receipt.Receipt, error) {
+	return r.findReceipt("SELECT "+receiptColumns+" FROM receipts WHERE id = ?", id)
+}
+
+func (r *Repository) FindReceiptByExpense(expenseID string) (*receipt.Receipt, error) {
+	return r.findReceipt(
+		"SELECT "+receiptColumns+" FROM receipts WHERE id = (SELECT receipt_id FROM receipt_expenses WHERE expense_id = ? LIMIT 1)",
+		expenseID)
+}
+
+func (r *Repository) findReceipt(query string, args ...interface{}) (*receipt.Receipt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var (
+		rec   receipt.Receipt
+		id    int64
+		items string
+	)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&id, &rec.Filename, &rec.ContentType, &rec.Size,
+		&rec.Merchant, &rec.Date, &rec.Total, &items, &rec.UploadedBy, &rec.UploadedAt)
+	if err == sql.ErrNoRows {
+		return nil, receipt.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec.ID = formatID(id)
+	if err := json.Unmarshal([]byte(items), &rec.Items); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT expense_id FROM receipt_expenses WHERE receipt_id = ? ORDER BY position", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var expenseID string
+		if err := rows.Scan(&expenseID); err != nil {
+			return nil, err
+		}
+		rec.ExpenseIDs = append(rec.ExpenseIDs, expenseID)
+	}
+	return &rec, rows.Err()
+}
+
+func (r *Repository) ReceiptImage(id string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, receipt.ErrNotFound
+	}
+	var data []byte
+	err = r.db.QueryRowContext(ctx, "SELECT data FROM receipt_images WHERE receipt_id = ?", n).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, receipt.ErrNotFound
+	}
+	return data, err
+}
@@ -0,0 +1,940 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/backup"
+	"expense-tracker/domain/expense"
+	"expense-tracker/infrastructure/secrets"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Repository stores everything in a single SQLite file, for single-household
+// deployments that do not want to run a MongoDB server. Times are stored in
+// UTC so that they compare correctly as text. The driver needs cgo; a binary
+// built with CGO_ENABLED=0 fails to open the database.
+type Repository struct {
+	db      *sql.DB
+	secrets *secrets.Box
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS expenses (
+	id               INTEGER PRIMARY KEY AUTOINCREMENT,
+	items            TEXT NOT NULL,
+	amount           INTEGER NOT NULL,
+	currency         TEXT NOT NULL DEFAULT '',
+	original_amount  INTEGER NOT NULL DEFAULT 0,
+	rate             TEXT NOT NULL DEFAULT '',
+	quantity         TEXT NOT NULL DEFAULT '',
+	unit             TEXT NOT NULL DEFAULT '',
+	base_quantity    TEXT NOT NULL DEFAULT '',
+	base_unit        TEXT NOT NULL DEFAULT '',
+	original_message TEXT NOT NULL DEFAULT '',
+	paid_date        TIMESTAMP NOT NULL,
+	paid_by          TEXT NOT NULL,
+	status           TEXT NOT NULL DEFAULT 'active',
+	deleted_date     TIMESTAMP,
+	version          INTEGER NOT NULL DEFAULT 1,
+	kind             TEXT NOT NULL DEFAULT 'expense',
+	paid_to          TEXT NOT NULL DEFAULT '',
+	tags             TEXT NOT NULL DEFAULT '[]',
+	notes            TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS settings (
+	key        TEXT PRIMARY KEY,
+	value      TEXT NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS users (
+	username   TEXT PRIMARY KEY,
+	password   TEXT NOT NULL,
+	role       TEXT NOT NULL,
+	disabled   INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS invites (
+	code       TEXT PRIMARY KEY,
+	created_by TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	used_by    TEXT NOT NULL DEFAULT '',
+	used_at    TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS sessions (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	token_hash TEXT NOT NULL UNIQUE,
+	username   TEXT NOT NULL DEFAULT '',
+	vals       TEXT NOT NULL,
+	ip         TEXT NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL,
+	last_seen  TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sessions_username ON sessions (username);
+CREATE TABLE IF NOT EXISTS audit_log (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	actor     TEXT NOT NULL,
+	action    TEXT NOT NULL,
+	target_id TEXT NOT NULL DEFAULT '',
+	before    TEXT,
+	after     TEXT,
+	ip        TEXT NOT NULL DEFAULT '',
+	timestamp TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_log_timestamp ON audit_log (timestamp);
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	username     TEXT NOT NULL,
+	key          TEXT NOT NULL,
+	request_hash TEXT NOT NULL,
+	completed    INTEGER NOT NULL DEFAULT 0,
+	expense_id   TEXT NOT NULL DEFAULT '',
+	status_code  INTEGER NOT NULL DEFAULT 0,
+	response     BLOB,
+	created_at   TIMESTAMP NOT NULL,
+	expires_at   TIMESTAMP NOT NULL,
+	PRIMARY KEY (username, key)
+);
+CREATE TABLE IF NOT EXISTS duplicate_dismissals (
+	pair_key     TEXT PRIMARY KEY,
+	dismissed_by TEXT NOT NULL,
+	dismissed_at TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS webhooks (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	url        TEXT NOT NULL,
+	events     TEXT NOT NULL,
+	secret     TEXT NOT NULL,
+	created_by TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id  TEXT NOT NULL,
+	delivery_id TEXT NOT NULL,
+	event       TEXT NOT NULL,
+	attempt     INTEGER NOT NULL,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	error       TEXT NOT NULL DEFAULT '',
+	duration_ms INTEGER NOT NULL DEFAULT 0,
+	time        TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id);
+CREATE TABLE IF NOT EXISTS chat_link_codes (
+	code       TEXT PRIMARY KEY,
+	username   TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS chat_links (
+	provider     TEXT NOT NULL,
+	chat_user_id TEXT NOT NULL,
+	chat_name    TEXT NOT NULL DEFAULT '',
+	username     TEXT NOT NULL,
+	linked_at    TIMESTAMP NOT NULL,
+	PRIMARY KEY (provider, chat_user_id)
+);
+CREATE INDEX IF NOT EXISTS chat_links_username ON chat_links (username);
+CREATE TABLE IF NOT EXISTS receipts (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	filename     TEXT NOT NULL DEFAULT '',
+	content_type TEXT NOT NULL,
+	size         INTEGER NOT NULL,
+	merchant     TEXT NOT NULL DEFAULT '',
+	date         TEXT NOT NULL DEFAULT '',
+	total        INTEGER NOT NULL DEFAULT 0,
+	items        TEXT NOT NULL,
+	uploaded_by  TEXT NOT NULL,
+	uploaded_at  TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS receipt_images (
+	receipt_id INTEGER PRIMARY KEY,
+	data       BLOB NOT NULL
+);
+CREATE TABLE IF NOT EXISTS receipt_expenses (
+	receipt_id INTEGER NOT NULL,
+	expense_id TEXT NOT NULL,
+	position   INTEGER NOT NULL,
+	PRIMARY KEY (receipt_id, expense_id)
+);
+CREATE INDEX IF NOT EXISTS receipt_expenses_expense ON receipt_expenses (expense_id);
+CREATE TABLE IF NOT EXISTS attachments (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	expense_id   TEXT NOT NULL,
+	filename     TEXT NOT NULL DEFAULT '',
+	content_type TEXT NOT NULL,
+	size         INTEGER NOT NULL,
+	uploaded_by  TEXT NOT NULL,
+	uploaded_at  TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS attachments_expense ON attachments (expense_id);
+CREATE TABLE IF NOT EXISTS attachment_data (
+	attachment_id INTEGER PRIMARY KEY,
+	data          BLOB NOT NULL
+);
+CREATE TABLE IF NOT EXISTS exchange_rates (
+	currency   TEXT NOT NULL,
+	date       TEXT NOT NULL,
+	rate       TEXT NOT NULL,
+	updated_by TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (currency, date)
+);
+CREATE TABLE IF NOT EXISTS unit_prices (
+	expense_id TEXT PRIMARY KEY,
+	item       TEXT NOT NULL,
+	items      TEXT NOT NULL,
+	paid_date  TEXT NOT NULL,
+	amount     INTEGER NOT NULL,
+	quantity   TEXT NOT NULL,
+	unit       TEXT NOT NULL,
+	unit_price INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS unit_prices_item ON unit_prices (item, paid_date);
+CREATE TABLE IF NOT EXISTS catalogue_items (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT NOT NULL,
+	aliases    TEXT NOT NULL DEFAULT '[]',
+	category   TEXT NOT NULL DEFAULT '',
+	unit       TEXT NOT NULL DEFAULT '',
+	updated_by TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMP NOT NULL
+);
+`
+
+// NewRepository opens (creating if needed) the database at path. Secrets such
+// as the API key are encrypted with box; a nil box means they cannot be saved.
+func NewRepository(path string, box *secrets.Box) (*Repository, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer at a time; a single connection avoids
+	// "database is locked" errors and keeps :memory: databases shared
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := addMissingColumns(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Printf("[SQLITE] Using database %s", path)
+	return &Repository{db: db, secrets: box}, nil
+}
+
+// addedColumns were introduced after their table; CREATE TABLE IF NOT EXISTS
+// leaves an existing database without them
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{"expenses", "version", "INTEGER NOT NULL DEFAULT 1"},
+	{"expenses", "kind", "TEXT NOT NULL DEFAULT 'expense'"},
+	{"expenses", "paid_to", "TEXT NOT NULL DEFAULT ''"},
+	{"expenses", "currency", "TEXT NOT NULL DEFAULT ''"},
+	{"expenses", "original_amount", "INTEGER NOT NULL DEFAULT 0"},
+	{"expenses", "rate", "TEXT NOT NULL DEFAULT ''"},
+	{"expenses", "tags", "TEXT NOT NULL DEFAULT '[]'"},
+	{"expenses", "notes", "TEXT NOT NULL DEFAULT ''"},
+}
+
+func addMissingColumns(ctx context.Context, db *sql.DB) error {
+	for _, added := range addedColumns {
+		var exists int
+		err := db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", added.table, added.column).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
+		log.Printf("[SQLITE] Adding column %s.%s", added.table, added.column)
+		if _, err := db.ExecContext(ctx, "ALTER TABLE "+added.table+" ADD COLUMN "+added.column+" "+added.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) Close() error {
+	return r.db.Close()
+}
+
+func formatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+const expenseColumns = `id, items, amount, quantity, unit, base_quantity, base_unit, original_message, paid_date, paid_by, status, deleted_date, version, kind, paid_to, currency, original_amount, rate, tags, notes`
+
+type expenseRow struct {
+	ID              int64
+	Items           string
+	Amount          int64
+	Quantity        string
+	Unit            string
+	BaseQuantity    string
+	BaseUnit        string
+	OriginalMessage string
+	PaidDate        time.Time
+	PaidBy          string
+	Status          string
+	DeletedDate     sql.NullTime
+	Version         int64
+	Kind            string
+	PaidTo          string
+	Currency        string
+	OriginalAmount  int64
+	Rate            string
+	Tags            []string
+	Notes           string
+}
+
+func (row *expenseRow) toMap(idKey string) map[string]interface{} {
+	return map[string]interface{}{
+		idKey:             formatID(row.ID),
+		"items":           row.Items,
+		"amount":          row.Amount,
+		"currency":        row.Currency,
+		"originalAmount":  row.original(),
+		"rate":            row.Rate,
+		"quantity":        row.Quantity,
+		"unit":            row.Unit,
+		"baseQuantity":    row.BaseQuantity,
+		"baseUnit":        row.BaseUnit,
+		"originalMessage": row.OriginalMessage,
+		"paidDate":        row.PaidDate.Format("2006-01-02"),
+		"paidBy":          row.PaidBy,
+		"kind":            string(expense.KindOf(row.Kind)),
+		"paidTo":          row.PaidTo,
+		"tags":            row.Tags,
+		"notes":           row.Notes,
+		"version":         row.Version,
+	}
+}
+
+func (row *expenseRow) toExpense() *expense.Expense {
+	exp := expense.NewExpenseWithDate(row.Items, row.Amount, row.PaidBy, row.PaidDate)
+	exp.SetID(formatID(row.ID))
+	exp.SetVersion(row.Version)
+	exp.SetQuantityUnit(row.Quantity, row.Unit)
+	exp.SetBaseQuantityUnit(row.BaseQuantity, row.BaseUnit)
+	exp.SetOriginalMessage(row.OriginalMessage)
+	exp.SetKind(expense.KindOf(row.Kind), row.PaidTo)
+	exp.SetTags(row.Tags)
+	exp.SetNotes(row.Notes)
+	if row.Currency != "" {
+		exp.SetOriginal(expense.NewMoneyIn(row.OriginalAmount, expense.Currency(row.Currency)), row.Rate)
+	}
+	if row.Status == string(expense.StatusDeleted) {
+		exp.Delete()
+	}
+	return exp
+}
+
+// original is the amount as paid; expenses stored before currencies existed
+// were paid in the base currency
+func (row *expenseRow) original() int64 {
+	if row.Currency == "" {
+		return row.Amount
+	}
+	return row.OriginalAmount
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExpense(s scanner) (*expenseRow, error) {
+	var row expenseRow
+	var tags string
+	err := s.Scan(&row.ID, &row.Items, &row.Amount, &row.Quantity, &row.Unit, &row.BaseQuantity,
+		&row.BaseUnit, &row.OriginalMessage, &row.PaidDate, &row.PaidBy, &row.Status, &row.DeletedDate, &row.Version,
+		&row.Kind, &row.PaidTo, &row.Currency, &row.OriginalAmount, &row.Rate, &tags, &row.Notes)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tags), &row.Tags); err != nil {
+		return nil, err
+	}
+	if row.Tags == nil {
+		row.Tags = []string{}
+	}
+	return &row, nil
+}
+
+// encodeTags is how the tags column holds a list
+func encodeTags(tags []string) string {
+	if tags == nil {
+		tags = []string{}
+	}
+	data, _ := json.Marshal(tags)
+	return string(data)
+}
+
+func (r *Repository) queryExpenses(where string, args ...interface{}) ([]*expenseRow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+expenseColumns+" FROM expenses "+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*expenseRow
+	for rows.Next() {
+		row, err := scanExpense(rows)
+		if err != nil {
+			log.Printf("[SQLITE] Expense scan error: %v", err)
+			continue
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func (r *Repository) Save(exp *expense.Expense) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO expenses (items, amount, quantity, unit, base_quantity, base_unit, original_message, paid_date, paid_by, status, version, kind, paid_to, currency, original_amount, rate, tags, notes)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?, ?, ?)`,
+		exp.Items(), exp.Amount(), exp.Quantity(), exp.Unit(), exp.BaseQuantity(), exp.BaseUnit(),
+		exp.OriginalMessage(), exp.PaidDate().UTC(), exp.PaidBy(), string(expense.StatusActive),
+		string(exp.Kind()), exp.PaidTo(), string(exp.Original().Currency()), exp.Original().Value(), exp.Rate(),
+		encodeTags(exp.Tags()), exp.Notes())
+	if err != nil {
+		log.Printf("[SQLITE] Save error: %v", err)
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	exp.SetID(formatID(id))
+	exp.SetVersion(1)
+	return nil
+}
+
+func (r *Repository) FindByID(id int) (*expense.Expense, error) {
+	rows, err := r.queryExpenses("WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, expense.ErrExpenseNotFound
+	}
+	return rows[0].toExpense(), nil
+}
+
+func (r *Repository) GetByID(id string) (map[string]interface{}, error) {
+	rows, err := r.queryExpenses("WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, expense.ErrExpenseNotFound
+	}
+
+	row := rows[0]
+	result := row.toMap("id")
+	result["status"] = row.Status
+	if row.DeletedDate.Valid {
+		result["deletedDate"] = row.DeletedDate.Time.Format("2006-01-02")
+	}
+	return result, nil
+}
+
+func (r *Repository) FindAll() ([]*expense.Expense, error) {
+	rows, err := r.queryExpenses("")
+	if err != nil {
+		return nil, err
+	}
+
+	var expenses []*expense.Expense
+	for _, row := range rows {
+		expenses = append(expenses, row.toExpense())
+	}
+	return expenses, nil
+}
+
+func (r *Repository) FindActiveExpenses() ([]*expense.Expense, error) {
+	rows, err := r.queryExpenses("WHERE status <> ?", string(expense.StatusDeleted))
+	if err != nil {
+		return nil, err
+	}
+
+	var expenses []*expense.Expense
+	for _, row := range rows {
+		expenses = append(expenses, row.toExpense())
+	}
+	return expenses, nil
+}
+
+func (r *Repository) GetAll() ([]map[string]interface{}, error) {
+	rows, err := r.queryExpenses("WHERE status <> ?", string(expense.StatusDeleted))
+	if err != nil {
+		return nil, err
+	}
+
+	var expenses []map[string]interface{}
+	for _, row := range rows {
+		expenses = append(expenses, row.toMap("no"))
+	}
+	return expenses, nil
+}
+
+func (r *Repository) GetSummaryByPaidBy() (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A transfer credits its payer and debits its recipient by the same amount
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT member, SUM(amount) FROM (
+			SELECT paid_by AS member, amount FROM expenses WHERE status <> ?
+			UNION ALL
+			SELECT paid_to AS member, -amount FROM expenses WHERE status <> ? AND kind = ?
+		) GROUP BY member ORDER BY member`,
+		string(expense.StatusDeleted), string(expense.StatusDeleted), string(expense.KindTransfer))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := make(map[string]int64)
+	for rows.Next() {
+		var paidBy string
+		var total int64
+		if err := rows.Scan(&paidBy, &total); err != nil {
+			continue
+		}
+		summary[paidBy] = total
+	}
+	return summary, rows.Err()
+}
+
+// updateVersion applies set (an SQL SET list) to the expense with id if it
+// is at version and has status, bumping the version. When nothing matches it
+// looks the expense up to return the reason.
+func (r *Repository) updateVersion(id string, version int64, status expense.Status, set string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	args = append(args, id, version, string(status))
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE expenses SET "+set+", version = version + 1 WHERE id = ? AND version = ? AND status = ?", args...)
+	if err != nil {
+		return err
+	}
+	if count, err := result.RowsAffected(); err != nil || count > 0 {
+		return err
+	}
+
+	var current int64
+	var currentStatus string
+	err = r.db.QueryRowContext(ctx, "SELECT version, status FROM expenses WHERE id = ?", id).Scan(&current, &currentStatus)
+	switch {
+	case err == sql.ErrNoRows:
+		return expense.ErrExpenseNotFound
+	case err != nil:
+		return err
+	case current != version:
+		return expense.ErrVersionConflict
+	case status == expense.StatusDeleted:
+		return expense.ErrExpenseNotDeleted
+	default:
+		return expense.ErrExpenseDeleted
+	}
+}
+
+func (r *Repository) Update(id string, version int64, changes expense.Changes) error {
+	var set []string
+	var args []interface{}
+	add := func(column string, value interface{}) {
+		set = append(set, column+" = ?")
+		args = append(args, value)
+	}
+	if changes.Items != nil {
+		add("items", *changes.Items)
+	}
+	if changes.Amount != nil {
+		add("amount", *changes.Amount)
+	}
+	if changes.Currency != nil {
+		add("currency", string(*changes.Currency))
+	}
+	if changes.OriginalAmount != nil {
+		add("original_amount", *changes.OriginalAmount)
+	}
+	if changes.Rate != nil {
+		add("rate", *changes.Rate)
+	}
+	if changes.Quantity != nil {
+		add("quantity", *changes.Quantity)
+	}
+	if changes.Unit != nil {
+		add("unit", *changes.Unit)
+	}
+	if changes.BaseQuantity != nil {
+		add("base_quantity", *changes.BaseQuantity)
+	}
+	if changes.BaseUnit != nil {
+		add("base_unit", *changes.BaseUnit)
+	}
+	if changes.PaidDate != nil {
+		add("paid_date", changes.PaidDate.UTC())
+	}
+	if changes.PaidBy != nil {
+		add("paid_by", *changes.PaidBy)
+	}
+	if changes.Kind != nil {
+		add("kind", string(*changes.Kind))
+	}
+	if changes.PaidTo != nil {
+		add("paid_to", *changes.PaidTo)
+	}
+	if changes.Tags != nil {
+		add("tags", encodeTags(*changes.Tags))
+	}
+	if changes.Notes != nil {
+		add("notes", *changes.Notes)
+	}
+	if len(set) == 0 {
+		// Still check the version so that an empty update is not a way
+		// around a conflict
+		set = append(set, "id = id")
+	}
+
+	log.Printf("[SQLITE] Updating expense %s at version %d", id, version)
+	return r.updateVersion(id, version, expense.StatusActive, strings.Join(set, ", "), args...)
+}
+
+func (r *Repository) Delete(id string, version int64) error {
+	if id == "" {
+		return nil
+	}
+
+	log.Printf("[SQLITE] Soft deleting expense %s", id)
+	return r.updateVersion(id, version, expense.StatusActive, "status = ?, deleted_date = ?",
+		string(expense.StatusDeleted), time.Now().UTC())
+}
+
+func (r *Repository) Restore(id string, version int64) error {
+	log.Printf("[SQLITE] Restoring expense %s", id)
+	return r.updateVersion(id, version, expense.StatusDeleted, "status = ?, deleted_date = NULL",
+		string(expense.StatusActive))
+}
+
+func (r *Repository) GetDeleted() ([]map[string]interface{}, error) {
+	rows, err := r.queryExpenses("WHERE status = ?", string(expense.StatusDeleted))
+	if err != nil {
+		return nil, err
+	}
+
+	var expenses []map[string]interface{}
+	for _, row := range rows {
+		item := row.toMap("id")
+		item["deletedDate"] = "N/A"
+		if row.DeletedDate.Valid {
+			item["deletedDate"] = row.DeletedDate.Time.Format("2006-01-02")
+		}
+		expenses = append(expenses, item)
+	}
+	return expenses, nil
+}
+
+func (r *Repository) ClearAll() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM expenses")
+	if err != nil {
+		log.Printf("[SQLITE] Clear all error: %v", err)
+		return err
+	}
+	if err := deleteOrphanedAttachments(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	count, _ := result.RowsAffected()
+	log.Printf("[SQLITE] Successfully deleted %d expenses", count)
+	return nil
+}
+
+func (r *Repository) Purge(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var status string
+	err := r.db.QueryRowContext(ctx, "SELECT status FROM expenses WHERE id = ?", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return expense.ErrExpenseNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != string(expense.StatusDeleted) {
+		return expense.ErrExpenseNotDeleted
+	}
+
+	log.Printf("[SQLITE] Purging expense %s", id)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM expenses WHERE id = ? AND status = ?", id, string(expense.StatusDeleted)); err != nil {
+		return err
+	}
+	if err := deleteOrphanedAttachments(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) PurgeDeleted(before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	query := "DELETE FROM expenses WHERE status = ?"
+	args := []interface{}{string(expense.StatusDeleted)}
+	if !before.IsZero() {
+		query += " AND deleted_date < ?"
+		args = append(args, before.UTC())
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("[SQLITE] Purge error: %v", err)
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		if err := deleteOrphanedAttachments(ctx, tx); err != nil {
+			return 0, err
+		}
+	}
+	return int(count), tx.Commit()
+}
+
+func (r *Repository) ExportExpenses() ([]backup.Expense, error) {
+	rows, err := r.queryExpenses("")
+	if err != nil {
+		return nil, err
+	}
+
+	expenses := make([]backup.Expense, 0, len(rows))
+	for _, row := range rows {
+		exp := backup.Expense{
+			ID:              formatID(row.ID),
+			Items:           row.Items,
+			Amount:          row.Amount,
+			Currency:        row.Currency,
+			OriginalAmount:  row.OriginalAmount,
+			Rate:            row.Rate,
+			Quantity:        row.Quantity,
+			Unit:            row.Unit,
+			BaseQuantity:    row.BaseQuantity,
+			BaseUnit:        row.BaseUnit,
+			OriginalMessage: row.OriginalMessage,
+			PaidDate:        row.PaidDate,
+			PaidBy:          row.PaidBy,
+			Kind:            row.Kind,
+			PaidTo:          row.PaidTo,
+			Tags:            row.Tags,
+			Notes:           row.Notes,
+			Status:          row.Status,
+			Version:         row.Version,
+		}
+		if row.DeletedDate.Valid {
+			deleted := row.DeletedDate.Time
+			exp.DeletedDate = &deleted
+		}
+		expenses = append(expenses, exp)
+	}
+	return expenses, nil
+}
+
+func (r *Repository) ImportExpense(exp backup.Expense) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Keep the archived ID when it is one of ours and still free
+	var id interface{}
+	if n, err := strconv.ParseInt(exp.ID, 10, 64); err == nil && n > 0 {
+		var taken int
+		err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM expenses WHERE id = ?", n).Scan(&taken)
+		if err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			id = n
+		}
+	}
+
+	var deletedDate interface{}
+	if exp.DeletedDate != nil {
+		deletedDate = exp.DeletedDate.UTC()
+	}
+	version := exp.Version
+	if version < 1 {
+		version = 1
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO expenses (id, items, amount, quantity, unit, base_quantity, base_unit, original_message, paid_date, paid_by, status, deleted_date, version, kind, paid_to, currency, original_amount, rate, tags, notes)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, exp.Items, exp.Amount, exp.Quantity, exp.Unit, exp.BaseQuantity, exp.BaseUnit,
+		exp.OriginalMessage, exp.PaidDate.UTC(), exp.PaidBy, exp.Status, deletedDate, version,
+		string(expense.KindOf(exp.Kind)), exp.PaidTo, exp.Currency, exp.OriginalAmount, exp.Rate,
+		encodeTags(exp.Tags), exp.Notes)
+	if err != nil {
+		log.Printf("[SQLITE] Import error: %v", err)
+		return "", err
+	}
+
+	inserted, err := result.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	return formatID(inserted), nil
+}
+
+func (r *Repository) AppendAudit(entry audit.Entry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	before, err := marshalSnapshot(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalSnapshot(entry.After)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		"INSERT INTO audit_log (actor, action, target_id, before, after, ip, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		entry.Actor, entry.Action, entry.TargetID, before, after, entry.IP, entry.Timestamp.UTC())
+	if err != nil {
+		log.Printf("[SQLITE] Audit append error: %v", err)
+	}
+	return err
+}
+
+func (r *Repository) FindAudit(filter audit.Filter) ([]audit.Entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := "SELECT id, actor, action, target_id, before, after, ip, timestamp FROM audit_log WHERE 1 = 1"
+	var args []interface{}
+	if filter.Actor != "" {
+		query += " AND actor = ?"
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		query += " AND action = ?"
+		args = append(args, filter.Action)
+	}
+	if filter.TargetID != "" {
+		query += " AND target_id = ?"
+		args = append(args, filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query += " AND timestamp < ?"
+		args = append(args, filter.To.UTC())
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = audit.DefaultLimit
+	}
+	query += " ORDER BY timestamp DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []audit.Entry
+	for rows.Next() {
+		var (
+			id            int64
+			entry         audit.Entry
+			before, after sql.NullString
+		)
+		if err := rows.Scan(&id, &entry.Actor, &entry.Action, &entry.TargetID, &before, &after, &entry.IP, &entry.Timestamp); err != nil {
+			log.Printf("[SQLITE] Audit scan error: %v", err)
+			continue
+		}
+		entry.ID = formatID(id)
+		entry.Before = unmarshalSnapshot(before)
+		entry.After = unmarshalSnapshot(after)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func marshalSnapshot(snapshot map[string]interface{}) (sql.NullString, error) {
+	if snapshot == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func unmarshalSnapshot(value sql.NullString) map[string]interface{} {
+	if !value.Valid {
+		return nil
+	}
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal([]byte(value.String), &snapshot); err != nil {
+		log.Printf("[SQLITE] Audit snapshot decode error: %v", err)
+		return nil
+	}
+	return snapshot
+}
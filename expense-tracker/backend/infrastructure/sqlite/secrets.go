@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"expense-tracker/infrastructure/secrets"
+)
+
+// secretSettingKeys lists the settings whose values are encrypted at rest
+var secretSettingKeys = []string{"gemini_api_key"}
+
+func (r *Repository) getSetting(key string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var value string
+	err := r.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func (r *Repository) saveSetting(key, value string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		 ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		key, value, time.Now().UTC())
+	return err
+}
+
+func (r *Repository) saveSecret(key, value string) error {
+	if r.secrets == nil {
+		return secrets.ErrNoMasterKey
+	}
+
+	encrypted, err := r.secrets.Encrypt(value)
+	if err != nil {
+		return err
+	}
+	return r.saveSetting(key, encrypted)
+}
+
+func (r *Repository) getSecret(key string) (string, error) {
+	value, _, err := r.getSetting(key)
+	if err != nil {
+		return "", err
+	}
+
+	if !secrets.IsEncrypted(value) {
+		// Legacy plaintext value, encrypted by RotateSecrets at next startup
+		return value, nil
+	}
+	if r.secrets == nil {
+		return "", secrets.ErrNoMasterKey
+	}
+	return r.secrets.Decrypt(value)
+}
+
+func (r *Repository) SaveAPIKey(apiKey string) error {
+	if err := r.saveSecret("gemini_api_key", apiKey); err != nil {
+		log.Printf("[SQLITE] Save API key error: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) GetAPIKey() (string, error) {
+	return r.getSecret("gemini_api_key")
+}
+
+// RotateSecrets re-encrypts stored secrets that are still plaintext or were
+// encrypted with a previous master key (SECRETS_KEY_PREVIOUS), including
+// webhook secrets. It returns the number of secrets rewritten.
+func (r *Repository) RotateSecrets() (int, error) {
+	if r.secrets == nil {
+		return 0, secrets.ErrNoMasterKey
+	}
+
+	rotated := 0
+	for _, key := range secretSettingKeys {
+		value, found, err := r.getSetting(key)
+		if err != nil {
+			return rotated, err
+		}
+		if !found || !r.secrets.NeedsRotation(value) {
+			continue
+		}
+
+		plaintext, err := r.secrets.Decrypt(value)
+		if err != nil {
+			log.Printf("[SQLITE] Cannot decrypt secret %s for rotation: %v", key, err)
+			return rotated, err
+		}
+		if err := r.saveSecret(key, plaintext); err != nil {
+			return rotated, err
+		}
+		log.Printf("[SQLITE] Re-encrypted secret %s with current master key", key)
+		rotated++
+	}
+
+	webhooks, err := r.rotateWebhookSecrets()
+	return rotated + webhooks, err
+}
@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	domainuser "expense-tracker/domain/user"
+	"expense-tracker/infrastructure/sessionstore"
+)
+
+const sessionColumns = `id, token_hash, username, vals, ip, user_agent, created_at, last_seen, expires_at`
+
+func (r *Repository) findSession(where string, arg interface{}) (*sessionstore.Record, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var (
+		id     int64
+		values string
+		record sessionstore.Record
+	)
+	err := r.db.QueryRowContext(ctx, "SELECT "+sessionColumns+" FROM sessions WHERE "+where, arg).Scan(
+		&id, &record.TokenHash, &record.Username, &values, &record.IP, &record.UserAgent,
+		&record.CreatedAt, &record.LastSeen, &record.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, domainuser.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record.ID = formatID(id)
+	if err := json.Unmarshal([]byte(values), &record.Values); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *Repository) FindSession(id string) (*sessionstore.Record, error) {
+	return r.findSession("id = ?", id)
+}
+
+func (r *Repository) FindSessionByToken(tokenHash string) (*sessionstore.Record, error) {
+	return r.findSession("token_hash = ?", tokenHash)
+}
+
+func (r *Repository) InsertSession(record sessionstore.Record) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	values, err := json.Marshal(record.Values)
+	if err != nil {
+		return "", err
+	}
+
+	// Drop expired sessions here, as SQLite has no TTL index to do it
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= ?", time.Now().UTC()); err != nil {
+		log.Printf("[SQLITE] Expired session cleanup error: %v", err)
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO sessions (token_hash, username, vals, ip, user_agent, created_at, last_seen, expires_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.TokenHash, record.Username, string(values), record.IP, record.UserAgent,
+		record.CreatedAt.UTC(), record.LastSeen.UTC(), record.ExpiresAt.UTC())
+	if err != nil {
+		log.Printf("[SQLITE] Insert session error: %v", err)
+		return "", err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	return formatID(id), nil
+}
+
+func (r *Repository) UpdateSessionValues(id string, values map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, "UPDATE sessions SET vals = ? WHERE id = ?", string(data), id)
+	return err
+}
+
+func (r *Repository) TouchSession(id, ip, userAgent string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE sessions SET last_seen = ?, ip = ?, user_agent = ? WHERE id = ?",
+		now.UTC(), ip, userAgent, id)
+	return err
+}
+
+func (r *Repository) DeleteSession(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM sessions WHERE id = ?", id)
+	return err
+}
+
+// ListSessions returns the unexpired sessions of username, newest activity first
+func (r *Repository) ListSessions(username string) ([]domainuser.SessionDTO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, ip, user_agent, created_at, last_seen, expires_at FROM sessions
+		 WHERE username = ? AND expires_at > ? ORDER BY last_seen DESC`,
+		username, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []domainuser.SessionDTO
+	for rows.Next() {
+		var id int64
+		var dto domainuser.SessionDTO
+		if err := rows.Scan(&id, &dto.IP, &dto.UserAgent, &dto.CreatedAt, &dto.LastSeen, &dto.ExpiresAt); err != nil {
+			continue
+		}
+		dto.ID = formatID(id)
+		result = append(result, dto)
+	}
+	return result, rows.Err()
+}
+
+// RevokeSession ends one session, only if it belongs to username
+func (r *Repository) RevokeSession(username, id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM sessions WHERE id = ? AND username = ?", id, username)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return domainuser.ErrSessionNotFound
+	}
+	log.Printf("[SQLITE] Revoked session %s of %s", id, username)
+	return nil
+}
+
+// RevokeUserSessions ends every session of username except exceptID
+func (r *Repository) RevokeUserSessions(username, exceptID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM sessions WHERE username = ? AND id <> ?", username, exceptID)
+	if err != nil {
+		return err
+	}
+	count, _ := result.RowsAffected()
+	log.Printf("[SQLITE] Revoked %d session(s) of %s", count, username)
+	return nil
+}
@@ -0,0 +1,259 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	domainuser "expense-tracker/domain/user"
+)
+
+func (r *Repository) CreateUser(username, password string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO users (username, password, role, disabled, created_at) VALUES (?, ?, ?, 0, ?)",
+		username, password, string(domainuser.DefaultRole(username)), time.Now().UTC())
+	if isConstraintError(err) {
+		return domainuser.ErrUserExists
+	}
+	return err
+}
+
+func (r *Repository) GetUser(username string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var password string
+	var disabled bool
+	err := r.db.QueryRowContext(ctx, "SELECT password, disabled FROM users WHERE username = ?", username).
+		Scan(&password, &disabled)
+	if err == sql.ErrNoRows {
+		return "", domainuser.ErrUserNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if disabled {
+		return "", domainuser.ErrUserDisabled
+	}
+	return password, nil
+}
+
+func (r *Repository) InitDefaultUsers() error {
+	defaultUsers := map[string]string{
+		"admin": "admin123",
+		"linh":  "linh123",
+		"toan":  "toan123",
+	}
+
+	for username, password := range defaultUsers {
+		if err := r.CreateUser(username, password); err != nil && err != domainuser.ErrUserExists {
+			log.Printf("[SQLITE] Failed to create user %s: %v", username, err)
+		}
+	}
+	return nil
+}
+
+func scanUser(s scanner) (domainuser.UserDTO, error) {
+	var dto domainuser.UserDTO
+	var role string
+	err := s.Scan(&dto.Username, &role, &dto.Disabled, &dto.CreatedAt)
+	dto.Role = domainuser.Role(role)
+	return dto, err
+}
+
+func (r *Repository) FindUser(username string) (*domainuser.UserDTO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	row := r.db.QueryRowContext(ctx, "SELECT username, role, disabled, created_at FROM users WHERE username = ?", username)
+	dto, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return nil, domainuser.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dto, nil
+}
+
+func (r *Repository) ListUsers() ([]domainuser.UserDTO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT username, role, disabled, created_at FROM users ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domainuser.UserDTO
+	for rows.Next() {
+		dto, err := scanUser(rows)
+		if err != nil {
+			log.Printf("[SQLITE] ListUsers - Scan error: %v", err)
+			continue
+		}
+		users = append(users, dto)
+	}
+	return users, rows.Err()
+}
+
+func (r *Repository) updateUser(username, column string, value interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET "+column+" = ? WHERE username = ?", value, username)
+	if err != nil {
+		log.Printf("[SQLITE] Update user %s error: %v", username, err)
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return domainuser.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *Repository) SetUserRole(username string, role domainuser.Role) error {
+	return r.updateUser(username, "role", string(role))
+}
+
+func (r *Repository) SetUserDisabled(username string, disabled bool) error {
+	return r.updateUser(username, "disabled", disabled)
+}
+
+func (r *Repository) ResetPassword(username, password string) error {
+	return r.updateUser(username, "password", password)
+}
+
+func (r *Repository) DeleteUser(username string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE username = ?", username)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return domainuser.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetRegistrationMode() (domainuser.RegistrationMode, error) {
+	value, found, err := r.getSetting("registration_mode")
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return domainuser.RegistrationOpen, nil
+	}
+	return domainuser.ParseRegistrationMode(value)
+}
+
+func (r *Repository) SaveRegistrationMode(mode domainuser.RegistrationMode) error {
+	return r.saveSetting("registration_mode", string(mode))
+}
+
+func scanInvite(s scanner) (domainuser.InviteDTO, error) {
+	var dto domainuser.InviteDTO
+	var usedAt sql.NullTime
+	err := s.Scan(&dto.Code, &dto.CreatedBy, &dto.CreatedAt, &dto.ExpiresAt, &dto.UsedBy, &usedAt)
+	if usedAt.Valid {
+		dto.UsedAt = &usedAt.Time
+	}
+	return dto, err
+}
+
+func (r *Repository) CreateInvite(createdBy string, ttl time.Duration) (*domainuser.InviteDTO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	code, err := domainuser.NewInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	dto := domainuser.InviteDTO{
+		Code:      code,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	_, err = r.db.ExecContext(ctx,
+		"INSERT INTO invites (code, created_by, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		dto.Code, dto.CreatedBy, dto.CreatedAt, dto.ExpiresAt)
+	if err != nil {
+		log.Printf("[SQLITE] Create invite error: %v", err)
+		return nil, err
+	}
+	return &dto, nil
+}
+
+func (r *Repository) ListInvites() ([]domainuser.InviteDTO, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT code, created_by, created_at, expires_at, used_by, used_at FROM invites ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []domainuser.InviteDTO
+	for rows.Next() {
+		dto, err := scanInvite(rows)
+		if err != nil {
+			log.Printf("[SQLITE] ListInvites - Scan error: %v", err)
+			continue
+		}
+		invites = append(invites, dto)
+	}
+	return invites, rows.Err()
+}
+
+// ConsumeInvite atomically marks an unused, unexpired invite as used by username
+func (r *Repository) ConsumeInvite(code, username string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE invites SET used_by = ?, used_at = ? WHERE code = ? AND used_at IS NULL AND expires_at > ?",
+		username, now, code, now)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return domainuser.ErrInviteInvalid
+	}
+	return nil
+}
+
+// ReleaseInvite undoes ConsumeInvite when the account could not be created
+func (r *Repository) ReleaseInvite(code string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE invites SET used_by = '', used_at = NULL WHERE code = ?", code)
+	return err
+}
+
+func (r *Repository) DeleteInvite(code string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM invites WHERE code = ?", code)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return domainuser.ErrInviteInvalid
+	}
+	return nil
+}
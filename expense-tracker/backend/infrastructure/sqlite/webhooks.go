@@ -0,0 +1,243 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/webhook"
+	"expense-tracker/infrastructure/secrets"
+)
+
+// Events are stored comma-separated; event names never contain commas
+func joinEvents(events []expense.EventType) string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+	return strings.Join(names, ",")
+}
+
+func splitEvents(joined string) []expense.EventType {
+	var events []expense.EventType
+	for _, name := range strings.Split(joined, ",") {
+		if name != "" {
+			events = append(events, expense.EventType(name))
+		}
+	}
+	return events
+}
+
+func (r *Repository) CreateWebhook(subscription webhook.Subscription) (string, error) {
+	if r.secrets == nil {
+		return "", secrets.ErrNoMasterKey
+	}
+	encrypted, err := r.secrets.Encrypt(subscription.Secret)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO webhooks (url, events, secret, created_by, created_at) VALUES (?, ?, ?, ?, ?)",
+		subscription.URL, joinEvents(subscription.Events), encrypted, subscription.CreatedBy, subscription.CreatedAt.UTC())
+	if err != nil {
+		return "", err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+func (r *Repository) scanWebhook(row interface{ Scan(...interface{}) error }) (*webhook.Subscription, error) {
+	var (
+		found          webhook.Subscription
+		id             int64
+		events, secret string
+	)
+	if err := row.Scan(&id, &found.URL, &events, &secret, &found.CreatedBy, &found.CreatedAt); err != nil {
+		return nil, err
+	}
+	if r.secrets == nil {
+		return nil, secrets.ErrNoMasterKey
+	}
+	plaintext, err := r.secrets.Decrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+	found.ID = strconv.FormatInt(id, 10)
+	found.Events = splitEvents(events)
+	found.Secret = plaintext
+	return &found, nil
+}
+
+func (r *Repository) GetWebhook(id string) (*webhook.Subscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	found, err := r.scanWebhook(r.db.QueryRowContext(ctx,
+		"SELECT id, url, events, secret, created_by, created_at FROM webhooks WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, webhook.ErrNotFound
+	}
+	return found, err
+}
+
+func (r *Repository) ListWebhooks() ([]webhook.Subscription, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, url, events, secret, created_by, created_at FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []webhook.Subscription
+	for rows.Next() {
+		found, err := r.scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *found)
+	}
+	return list, rows.Err()
+}
+
+func (r *Repository) DeleteWebhook(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return webhook.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE webhook_id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) RecordDelivery(delivery webhook.Delivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, delivery_id, event, attempt, status_code, error, duration_ms, time)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		delivery.WebhookID, delivery.DeliveryID, string(delivery.Event), delivery.Attempt,
+		delivery.StatusCode, delivery.Error, delivery.DurationMillis, delivery.Time.UTC())
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM webhook_deliveries WHERE webhook_id = ? AND id NOT IN
+		 (SELECT id FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?)`,
+		delivery.WebhookID, delivery.WebhookID, webhook.DeliveryLogSize)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) ListDeliveries(webhookID string) ([]webhook.Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, delivery_id, event, attempt, status_code, error, duration_ms, time
+		 FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC`, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []webhook.Delivery
+	for rows.Next() {
+		var (
+			delivery webhook.Delivery
+			id       int64
+			event    string
+		)
+		if err := rows.Scan(&id, &delivery.DeliveryID, &event, &delivery.Attempt, &delivery.StatusCode,
+			&delivery.Error, &delivery.DurationMillis, &delivery.Time); err != nil {
+			return nil, err
+		}
+		delivery.ID = strconv.FormatInt(id, 10)
+		delivery.WebhookID = webhookID
+		delivery.Event = expense.EventType(event)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// rotateWebhookSecrets re-encrypts webhook secrets written under a previous
+// master key
+func (r *Repository) rotateWebhookSecrets() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, secret FROM webhooks")
+	if err != nil {
+		return 0, err
+	}
+	stale := make(map[int64]string)
+	for rows.Next() {
+		var (
+			id     int64
+			secret string
+		)
+		if err := rows.Scan(&id, &secret); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if r.secrets.NeedsRotation(secret) {
+			stale[id] = secret
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for id, secret := range stale {
+		plaintext, err := r.secrets.Decrypt(secret)
+		if err != nil {
+			log.Printf("[SQLITE] Cannot decrypt secret of webhook %d for rotation: %v", id, err)
+			return rotated, err
+		}
+		encrypted, err := r.secrets.Encrypt(plaintext)
+		if err != nil {
+			return rotated, err
+		}
+		if _, err := r.db.ExecContext(ctx, "UPDATE webhooks SET secret = ? WHERE id = ?", encrypted, id); err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+	return rotated, nil
+}
@@ -0,0 +1,319 @@
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/user"
+	"expense-tracker/infrastructure/sessionstore"
+	"expense-tracker/infrastructure/storage"
+)
+
+// Accounts checks settings, users, invites, sessions and the audit log
+var Accounts = Suite{"accounts", []Check{
+	{"settings", checkSettings},
+	{"users", checkUsers},
+	{"invites", checkInvites},
+	{"sessions", checkSessions},
+	{"audit", checkAudit},
+}}
+
+func checkSettings(store storage.Store) error {
+	key, err := store.GetAPIKey()
+	if err != nil || key != "" {
+		return fmt.Errorf("GetAPIKey on empty store = %q, %v", key, err)
+	}
+	if err := store.SaveAPIKey("AIza-first"); err != nil {
+		return fmt.Errorf("SaveAPIKey: %w", err)
+	}
+	if err := store.SaveAPIKey("AIza-second"); err != nil {
+		return fmt.Errorf("SaveAPIKey overwrite: %w", err)
+	}
+	if key, err := store.GetAPIKey(); err != nil || key != "AIza-second" {
+		return fmt.Errorf("GetAPIKey = %q, %v; want AIza-second", key, err)
+	}
+	if _, err := store.RotateSecrets(); err != nil {
+		return fmt.Errorf("RotateSecrets: %w", err)
+	}
+
+	mode, err := store.GetRegistrationMode()
+	if err != nil || mode != user.RegistrationOpen {
+		return fmt.Errorf("default registration mode = %q, %v; want open", mode, err)
+	}
+	if err := store.SaveRegistrationMode(user.RegistrationInvite); err != nil {
+		return err
+	}
+	if mode, err := store.GetRegistrationMode(); err != nil || mode != user.RegistrationInvite {
+		return fmt.Errorf("registration mode = %q, %v; want invite", mode, err)
+	}
+	return nil
+}
+
+func checkUsers(store storage.Store) error {
+	if err := store.CreateUser("admin", "secret"); err != nil {
+		return fmt.Errorf("CreateUser: %w", err)
+	}
+	if err := expectErr(store.CreateUser("admin", "other"), user.ErrUserExists, "CreateUser duplicate"); err != nil {
+		return err
+	}
+	if err := store.CreateUser("linh", "linh123"); err != nil {
+		return err
+	}
+
+	if password, err := store.GetUser("admin"); err != nil || password != "secret" {
+		return fmt.Errorf("GetUser = %q, %v", password, err)
+	}
+	_, err := store.GetUser("nobody")
+	if err := expectErr(err, user.ErrUserNotFound, "GetUser unknown"); err != nil {
+		return err
+	}
+
+	admin, err := store.FindUser("admin")
+	if err != nil || admin.Role != user.RoleAdmin {
+		return fmt.Errorf("FindUser admin = %+v, %v; want admin role", admin, err)
+	}
+	linh, err := store.FindUser("linh")
+	if err != nil || linh.Role != user.RoleSupervisor || linh.Disabled {
+		return fmt.Errorf("FindUser linh = %+v, %v; want enabled supervisor", linh, err)
+	}
+	_, err = store.FindUser("nobody")
+	if err := expectErr(err, user.ErrUserNotFound, "FindUser unknown"); err != nil {
+		return err
+	}
+
+	users, err := store.ListUsers()
+	if err != nil {
+		return err
+	}
+	if len(users) != 2 || users[0].Username != "admin" {
+		return fmt.Errorf("ListUsers = %+v, want admin then linh", users)
+	}
+
+	if err := store.SetUserRole("linh", user.RoleAdmin); err != nil {
+		return err
+	}
+	if linh, _ := store.FindUser("linh"); linh == nil || linh.Role != user.RoleAdmin {
+		return errors.New("SetUserRole did not change the role")
+	}
+
+	if err := store.SetUserDisabled("linh", true); err != nil {
+		return err
+	}
+	_, err = store.GetUser("linh")
+	if err := expectErr(err, user.ErrUserDisabled, "GetUser disabled"); err != nil {
+		return err
+	}
+	if err := store.SetUserDisabled("linh", false); err != nil {
+		return err
+	}
+
+	if err := store.ResetPassword("linh", "new-password"); err != nil {
+		return err
+	}
+	if password, _ := store.GetUser("linh"); password != "new-password" {
+		return fmt.Errorf("ResetPassword: GetUser = %q", password)
+	}
+
+	if err := store.DeleteUser("linh"); err != nil {
+		return err
+	}
+	for name, err := range map[string]error{
+		"DeleteUser twice":      store.DeleteUser("linh"),
+		"SetUserRole unknown":   store.SetUserRole("linh", user.RoleAdmin),
+		"SetUserDisabled":       store.SetUserDisabled("linh", true),
+		"ResetPassword unknown": store.ResetPassword("linh", "x"),
+	} {
+		if e := expectErr(err, user.ErrUserNotFound, name); e != nil {
+			return e
+		}
+	}
+
+	if err := store.InitDefaultUsers(); err != nil {
+		return err
+	}
+	if password, _ := store.GetUser("admin"); password != "secret" {
+		return errors.New("InitDefaultUsers must not overwrite an existing user")
+	}
+	return nil
+}
+
+func checkInvites(store storage.Store) error {
+	invite, err := store.CreateInvite("admin", time.Hour)
+	if err != nil {
+		return err
+	}
+	if invite.Code == "" || !invite.IsUsable(time.Now()) {
+		return fmt.Errorf("CreateInvite returned %+v", invite)
+	}
+	expired, err := store.CreateInvite("admin", -time.Minute)
+	if err != nil {
+		return err
+	}
+
+	invites, err := store.ListInvites()
+	if err != nil {
+		return err
+	}
+	if len(invites) != 2 {
+		return fmt.Errorf("ListInvites returned %d, want 2", len(invites))
+	}
+
+	if err := expectErr(store.ConsumeInvite(expired.Code, "late"), user.ErrInviteInvalid, "ConsumeInvite expired"); err != nil {
+		return err
+	}
+	if err := store.ConsumeInvite(invite.Code, "linh"); err != nil {
+		return fmt.Errorf("ConsumeInvite: %w", err)
+	}
+	if err := expectErr(store.ConsumeInvite(invite.Code, "toan"), user.ErrInviteInvalid, "ConsumeInvite twice"); err != nil {
+		return err
+	}
+
+	invites, _ = store.ListInvites()
+	for _, listed := range invites {
+		if listed.Code == invite.Code && (listed.UsedBy != "linh" || listed.UsedAt == nil) {
+			return fmt.Errorf("consumed invite listed as %+v", listed)
+		}
+	}
+
+	if err := store.ReleaseInvite(invite.Code); err != nil {
+		return err
+	}
+	if err := store.ConsumeInvite(invite.Code, "toan"); err != nil {
+		return fmt.Errorf("ConsumeInvite after ReleaseInvite: %w", err)
+	}
+
+	if err := store.DeleteInvite(invite.Code); err != nil {
+		return err
+	}
+	return expectErr(store.DeleteInvite(invite.Code), user.ErrInviteInvalid, "DeleteInvite twice")
+}
+
+func checkSessions(store storage.Store) error {
+	now := time.Now()
+	record := sessionstore.Record{
+		TokenHash: sessionstore.HashToken("token-1"),
+		Username:  "linh",
+		Values:    map[string]interface{}{"username": "linh", "user_id": "linh"},
+		IP:        "10.0.0.1",
+		UserAgent: "phone",
+		CreatedAt: now,
+		LastSeen:  now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+	first, err := store.InsertSession(record)
+	if err != nil {
+		return fmt.Errorf("InsertSession: %w", err)
+	}
+
+	record.TokenHash = sessionstore.HashToken("token-2")
+	record.UserAgent = "laptop"
+	record.LastSeen = now.Add(time.Minute)
+	second, err := store.InsertSession(record)
+	if err != nil {
+		return err
+	}
+
+	record.TokenHash = sessionstore.HashToken("token-3")
+	record.Username = "toan"
+	other, err := store.InsertSession(record)
+	if err != nil {
+		return err
+	}
+
+	found, err := store.FindSessionByToken(sessionstore.HashToken("token-1"))
+	if err != nil || found.ID != first || found.Values["username"] != "linh" || found.UserAgent != "phone" {
+		return fmt.Errorf("FindSessionByToken = %+v, %v", found, err)
+	}
+	_, err = store.FindSessionByToken(sessionstore.HashToken("unknown"))
+	if err := expectErr(err, user.ErrSessionNotFound, "FindSessionByToken unknown"); err != nil {
+		return err
+	}
+
+	if err := store.UpdateSessionValues(first, map[string]interface{}{"username": "linh", "csrf_token": "abc"}); err != nil {
+		return err
+	}
+	if err := store.TouchSession(first, "10.0.0.9", "phone", now.Add(2*time.Minute)); err != nil {
+		return err
+	}
+	found, err = store.FindSession(first)
+	if err != nil || found.Values["csrf_token"] != "abc" || found.IP != "10.0.0.9" {
+		return fmt.Errorf("FindSession after update = %+v, %v", found, err)
+	}
+
+	sessions, err := store.ListSessions("linh")
+	if err != nil {
+		return err
+	}
+	if len(sessions) != 2 || sessions[0].ID != first {
+		return fmt.Errorf("ListSessions = %+v, want 2 sessions with the most recently seen first", sessions)
+	}
+
+	if err := expectErr(store.RevokeSession("linh", other), user.ErrSessionNotFound, "RevokeSession of another user"); err != nil {
+		return err
+	}
+	if err := store.RevokeUserSessions("linh", first); err != nil {
+		return err
+	}
+	if _, err := store.FindSession(second); err == nil {
+		return errors.New("RevokeUserSessions kept a session it should have ended")
+	}
+	if _, err := store.FindSession(first); err != nil {
+		return errors.New("RevokeUserSessions ended the excepted session")
+	}
+	if err := store.RevokeSession("linh", first); err != nil {
+		return err
+	}
+
+	if err := store.DeleteSession(other); err != nil {
+		return err
+	}
+	if _, err := store.FindSession(other); err == nil {
+		return errors.New("DeleteSession did not delete")
+	}
+	return nil
+}
+
+func checkAudit(store storage.Store) error {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	entries := []audit.Entry{
+		{Actor: "admin", Action: audit.ActionUserRole, TargetID: "linh", Before: map[string]interface{}{"role": "supervisor"}, After: map[string]interface{}{"role": "admin"}, IP: "10.0.0.1", Timestamp: base},
+		{Actor: "linh", Action: audit.ActionExpenseCreate, TargetID: "e1", Timestamp: base.Add(time.Hour)},
+		{Actor: "admin", Action: audit.ActionExpenseDelete, TargetID: "e1", Timestamp: base.Add(2 * time.Hour)},
+	}
+	for _, entry := range entries {
+		if err := store.AppendAudit(entry); err != nil {
+			return err
+		}
+	}
+
+	all, err := store.FindAudit(audit.Filter{})
+	if err != nil {
+		return err
+	}
+	if len(all) != 3 || all[0].Action != audit.ActionExpenseDelete || all[0].ID == "" {
+		return fmt.Errorf("FindAudit should return every entry newest first, got %+v", all)
+	}
+	if role := all[2].After["role"]; role != "admin" {
+		return fmt.Errorf("FindAudit after snapshot role = %v", role)
+	}
+
+	filtered, err := store.FindAudit(audit.Filter{Actor: "admin"})
+	if err != nil || len(filtered) != 2 {
+		return fmt.Errorf("FindAudit by actor returned %d, %v; want 2", len(filtered), err)
+	}
+	filtered, err = store.FindAudit(audit.Filter{TargetID: "e1", Action: audit.ActionExpenseCreate})
+	if err != nil || len(filtered) != 1 {
+		return fmt.Errorf("FindAudit by target and action returned %d, %v; want 1", len(filtered), err)
+	}
+	filtered, err = store.FindAudit(audit.Filter{From: base.Add(30 * time.Minute), To: base.Add(2 * time.Hour)})
+	if err != nil || len(filtered) != 1 || filtered[0].Actor != "linh" {
+		return fmt.Errorf("FindAudit by time range = %+v, %v; want only the linh entry", filtered, err)
+	}
+	filtered, err = store.FindAudit(audit.Filter{Limit: 2})
+	if err != nil || len(filtered) != 2 {
+		return fmt.Errorf("FindAudit with limit returned %d, %v; want 2", len(filtered), err)
+	}
+	return nil
+}
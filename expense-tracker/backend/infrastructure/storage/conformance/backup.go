@@ -0,0 +1,86 @@
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"expense-tracker/domain/backup"
+	"expense-tracker/infrastructure/storage"
+)
+
+// Backup checks exporting a whole store to an archive and restoring it
+var Backup = Suite{"backup", []Check{
+	{"backup", checkBackup},
+}}
+
+func checkBackup(store storage.Store) error {
+	paidDate := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	rice, err := saveExpense(store, "gạo", 50000, "linh", paidDate)
+	if err != nil {
+		return err
+	}
+	meat, err := saveExpense(store, "thịt", 120000, "toan", paidDate)
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(meat.ID(), meat.Version()); err != nil {
+		return err
+	}
+
+	exported, err := store.ExportExpenses()
+	if err != nil {
+		return fmt.Errorf("ExportExpenses: %w", err)
+	}
+	if len(exported) != 2 {
+		return fmt.Errorf("ExportExpenses returned %d expenses, want 2 including the deleted one", len(exported))
+	}
+	byID := make(map[string]backup.Expense)
+	for _, exp := range exported {
+		byID[exp.ID] = exp
+	}
+	if exp := byID[rice.ID()]; exp.Items != "gạo" || exp.BaseUnit != "g" || exp.Status != "active" || !exp.PaidDate.Equal(paidDate) {
+		return fmt.Errorf("ExportExpenses %s = %+v", rice.ID(), exp)
+	}
+	if exp := byID[meat.ID()]; exp.Status != "deleted" || exp.DeletedDate == nil || exp.Version != 2 {
+		return fmt.Errorf("ExportExpenses %s: status=%q deletedDate=%v version=%d, want deleted with a date at version 2",
+			meat.ID(), exp.Status, exp.DeletedDate, exp.Version)
+	}
+
+	if err := store.ClearAll(); err != nil {
+		return err
+	}
+	for _, exp := range exported {
+		id, err := store.ImportExpense(exp)
+		if err != nil {
+			return fmt.Errorf("ImportExpense: %w", err)
+		}
+		if id != exp.ID {
+			return fmt.Errorf("ImportExpense into an empty store changed ID %s to %s", exp.ID, id)
+		}
+	}
+	got, err := store.GetByID(meat.ID())
+	if err != nil {
+		return fmt.Errorf("GetByID after import: %w", err)
+	}
+	if got["status"] != "deleted" || got["items"] != "thịt" || got["version"] != int64(2) {
+		return fmt.Errorf("GetByID after import = %v", got)
+	}
+
+	// A taken ID must not overwrite the stored expense
+	id, err := store.ImportExpense(byID[rice.ID()])
+	if err != nil {
+		return fmt.Errorf("ImportExpense duplicate: %w", err)
+	}
+	if id == rice.ID() {
+		return errors.New("ImportExpense reused an ID that is already stored")
+	}
+	fresh, err := saveExpense(store, "rau", 20000, "linh", paidDate)
+	if err != nil {
+		return err
+	}
+	if fresh.ID() == rice.ID() || fresh.ID() == meat.ID() || fresh.ID() == id {
+		return fmt.Errorf("Save after import reused ID %s", fresh.ID())
+	}
+	return nil
+}
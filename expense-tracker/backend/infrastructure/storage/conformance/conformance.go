@@ -0,0 +1,95 @@
+// Package conformance checks that a storage.Store behaves the way the
+// application expects, so that every backend can be held to the same rules.
+// The checks are grouped by area into suites, one file each. go test runs
+// every suite against the memory and SQLite backends, and so does
+// cmd/storecheck.
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"expense-tracker/domain/expense"
+	"expense-tracker/infrastructure/storage"
+)
+
+// Factory opens an empty store. The store must be able to save secrets,
+// i.e. it needs a master key.
+type Factory func() (storage.Store, error)
+
+type Check struct {
+	Name string
+	Run  func(store storage.Store) error
+}
+
+// Suite is the checks of one area of the store. Each suite lives in a file
+// of its own, and a new check goes into the suite of its area.
+type Suite struct {
+	Name   string
+	Checks []Check
+}
+
+// Failure reports a check that did not pass
+type Failure struct {
+	Check string
+	Err   error
+}
+
+func (f Failure) Error() string {
+	return f.Check + ": " + f.Err.Error()
+}
+
+// Suites is what every backend must pass
+var Suites = []Suite{Expenses, Backup, Pricing, Accounts, Integrations}
+
+// Checks lists the checks of every suite
+func Checks() []Check {
+	var checks []Check
+	for _, suite := range Suites {
+		checks = append(checks, suite.Checks...)
+	}
+	return checks
+}
+
+// Run executes every check against a fresh store from newStore
+func Run(newStore Factory) []Failure {
+	var failures []Failure
+	for _, check := range Checks() {
+		if err := RunCheck(newStore, check); err != nil {
+			failures = append(failures, Failure{check.Name, err})
+		}
+	}
+	return failures
+}
+
+// RunCheck executes check against a fresh store from newStore
+func RunCheck(newStore Factory, check Check) error {
+	store, err := newStore()
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	defer store.Close()
+	return check.Run(store)
+}
+
+func expectErr(got, want error, what string) error {
+	if !errors.Is(got, want) {
+		return fmt.Errorf("%s: got error %v, want %v", what, got, want)
+	}
+	return nil
+}
+
+func saveExpense(store storage.Store, items string, amount int64, paidBy string, paidDate time.Time) (*expense.Expense, error) {
+	exp := expense.NewExpenseWithDate(items, amount, paidBy, paidDate)
+	exp.SetQuantityUnit("2", "kg")
+	exp.SetBaseQuantityUnit("2000", "g")
+	exp.SetOriginalMessage(items + " " + paidBy)
+	if err := store.Save(exp); err != nil {
+		return nil, err
+	}
+	if exp.ID() == "" {
+		return nil, errors.New("Save did not assign an ID")
+	}
+	return exp, nil
+}
@@ -0,0 +1,65 @@
+package conformance_test
+
+import (
+	"os"
+	"testing"
+
+	"expense-tracker/infrastructure/memory"
+	"expense-tracker/infrastructure/secrets"
+	"expense-tracker/infrastructure/sqlite"
+	"expense-tracker/infrastructure/storage"
+	"expense-tracker/infrastructure/storage/conformance"
+)
+
+func TestExpenses(t *testing.T)     { runSuite(t, conformance.Expenses) }
+func TestBackup(t *testing.T)       { runSuite(t, conformance.Backup) }
+func TestPricing(t *testing.T)      { runSuite(t, conformance.Pricing) }
+func TestAccounts(t *testing.T)     { runSuite(t, conformance.Accounts) }
+func TestIntegrations(t *testing.T) { runSuite(t, conformance.Integrations) }
+
+// TestSuites keeps the per-area tests above in step with conformance.Suites
+func TestSuites(t *testing.T) {
+	tested := map[string]bool{"expenses": true, "backup": true, "pricing": true, "accounts": true, "integrations": true}
+	for _, suite := range conformance.Suites {
+		if !tested[suite.Name] {
+			t.Errorf("suite %q has no test", suite.Name)
+		}
+	}
+}
+
+// runSuite runs every check of suite against the memory and SQLite backends.
+// The MongoDB backend is left out for the reason cmd/storecheck gives: the
+// suite needs an empty database for every check.
+func runSuite(t *testing.T, suite conformance.Suite) {
+	box, err := secrets.NewBox("conformance-test-master-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	factories := map[string]conformance.Factory{
+		storage.BackendMemory: func() (storage.Store, error) {
+			return memory.NewRepository(box), nil
+		},
+		storage.BackendSQLite: func() (storage.Store, error) {
+			file, err := os.CreateTemp(dir, "*.db")
+			if err != nil {
+				return nil, err
+			}
+			file.Close()
+			return sqlite.NewRepository(file.Name(), box)
+		},
+	}
+
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			for _, check := range suite.Checks {
+				t.Run(check.Name, func(t *testing.T) {
+					if err := conformance.RunCheck(factory, check); err != nil {
+						t.Error(err)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,688 @@
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"expense-tracker/domain/attachment"
+	"expense-tracker/domain/backup"
+	"expense-tracker/domain/expense"
+	"expense-tracker/infrastructure/storage"
+)
+
+// Expenses checks the expenses themselves: saving, finding and changing them, the trash, versions, kinds, currencies, tags, attachments and duplicate dismissals
+var Expenses = Suite{"expenses", []Check{
+	{"expenses", checkExpenses},
+	{"clear all", checkClearAll},
+	{"trash", checkTrash},
+	{"versions", checkVersions},
+	{"kinds", checkKinds},
+	{"currencies", checkCurrencies},
+	{"tags", checkTags},
+	{"attachments", checkAttachments},
+	{"duplicate dismissals", checkDismissals},
+}}
+
+func checkExpenses(store storage.Store) error {
+	paidDate := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	rice, err := saveExpense(store, "gạo", 50000, "linh", paidDate)
+	if err != nil {
+		return err
+	}
+	meat, err := saveExpense(store, "thịt", 120000, "toan", paidDate)
+	if err != nil {
+		return err
+	}
+	if _, err := saveExpense(store, "rau", 20000, "linh", paidDate); err != nil {
+		return err
+	}
+	if rice.ID() == meat.ID() {
+		return errors.New("Save assigned the same ID twice")
+	}
+
+	got, err := store.GetByID(rice.ID())
+	if err != nil {
+		return fmt.Errorf("GetByID: %w", err)
+	}
+	want := map[string]interface{}{
+		"id": rice.ID(), "items": "gạo", "amount": int64(50000), "quantity": "2", "unit": "kg",
+		"baseQuantity": "2000", "baseUnit": "g", "originalMessage": "gạo linh",
+		"paidDate": "2024-03-15", "paidBy": "linh", "status": "active", "version": int64(1),
+	}
+	for key, value := range want {
+		if got[key] != value {
+			return fmt.Errorf("GetByID %s = %v, want %v", key, got[key], value)
+		}
+	}
+	_, err = store.GetByID("does-not-exist")
+	if err := expectErr(err, expense.ErrExpenseNotFound, "GetByID unknown"); err != nil {
+		return err
+	}
+
+	if err := store.Delete("", 1); err != nil {
+		return fmt.Errorf("Delete(\"\") should be a no-op, got %v", err)
+	}
+	if err := store.Delete(meat.ID(), meat.Version()); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+
+	all, err := store.GetAll()
+	if err != nil {
+		return err
+	}
+	if len(all) != 2 {
+		return fmt.Errorf("GetAll returned %d expenses, want 2 active", len(all))
+	}
+	if all[0]["no"] != rice.ID() {
+		return fmt.Errorf("GetAll should list in insertion order with the ID under \"no\", got %v", all[0]["no"])
+	}
+
+	active, err := store.FindActiveExpenses()
+	if err != nil {
+		return err
+	}
+	if len(active) != 2 {
+		return fmt.Errorf("FindActiveExpenses returned %d, want 2", len(active))
+	}
+	everything, err := store.FindAll()
+	if err != nil {
+		return err
+	}
+	if len(everything) != 3 {
+		return fmt.Errorf("FindAll returned %d, want 3", len(everything))
+	}
+
+	summary, err := store.GetSummaryByPaidBy()
+	if err != nil {
+		return err
+	}
+	if summary["linh"] != 70000 || summary["toan"] != 0 {
+		return fmt.Errorf("GetSummaryByPaidBy = %v, want linh=70000 and no active toan", summary)
+	}
+
+	deleted, err := store.GetDeleted()
+	if err != nil {
+		return err
+	}
+	if len(deleted) != 1 || deleted[0]["id"] != meat.ID() {
+		return fmt.Errorf("GetDeleted = %v, want only %s", deleted, meat.ID())
+	}
+	if deleted[0]["deletedDate"] == "N/A" {
+		return errors.New("GetDeleted: deletedDate not set")
+	}
+
+	got, err = store.GetByID(meat.ID())
+	if err != nil {
+		return err
+	}
+	if got["status"] != "deleted" || got["deletedDate"] == nil {
+		return fmt.Errorf("GetByID after Delete: status=%v deletedDate=%v", got["status"], got["deletedDate"])
+	}
+	return nil
+}
+
+func checkClearAll(store storage.Store) error {
+	paidDate := time.Now()
+	for _, items := range []string{"gạo", "thịt"} {
+		if _, err := saveExpense(store, items, 10000, "linh", paidDate); err != nil {
+			return err
+		}
+	}
+	if err := store.ClearAll(); err != nil {
+		return err
+	}
+	all, err := store.FindAll()
+	if err != nil {
+		return err
+	}
+	if len(all) != 0 {
+		return fmt.Errorf("FindAll after ClearAll returned %d expenses", len(all))
+	}
+	return nil
+}
+
+func checkTrash(store storage.Store) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	old, recent := now.AddDate(0, 0, -40), now.AddDate(0, 0, -2)
+	importDeleted := func(items string, deletedDate time.Time) (string, error) {
+		return store.ImportExpense(backup.Expense{
+			Items: items, Amount: 10000, PaidBy: "linh", PaidDate: deletedDate,
+			Status: "deleted", DeletedDate: &deletedDate,
+		})
+	}
+
+	active, err := saveExpense(store, "gạo", 50000, "linh", now)
+	if err != nil {
+		return err
+	}
+	oldID, err := importDeleted("thịt", old)
+	if err != nil {
+		return err
+	}
+	if _, err := importDeleted("rau", recent); err != nil {
+		return err
+	}
+	if _, err := importDeleted("cá", old); err != nil {
+		return err
+	}
+
+	if err := expectErr(store.Purge(active.ID()), expense.ErrExpenseNotDeleted, "Purge active"); err != nil {
+		return err
+	}
+	if err := expectErr(store.Purge("does-not-exist"), expense.ErrExpenseNotFound, "Purge unknown"); err != nil {
+		return err
+	}
+	if err := store.Purge(oldID); err != nil {
+		return fmt.Errorf("Purge: %w", err)
+	}
+	if _, err := store.GetByID(oldID); !errors.Is(err, expense.ErrExpenseNotFound) {
+		return fmt.Errorf("GetByID after Purge: %v, want not found", err)
+	}
+
+	purged, err := store.PurgeDeleted(now.AddDate(0, 0, -30))
+	if err != nil {
+		return fmt.Errorf("PurgeDeleted: %w", err)
+	}
+	if purged != 1 {
+		return fmt.Errorf("PurgeDeleted(30 days ago) removed %d, want 1", purged)
+	}
+	if purged, err := store.PurgeDeleted(time.Time{}); err != nil || purged != 1 {
+		return fmt.Errorf("PurgeDeleted(zero) = %d, %v; want 1", purged, err)
+	}
+
+	all, err := store.FindAll()
+	if err != nil {
+		return err
+	}
+	if len(all) != 1 {
+		return fmt.Errorf("after purging, %d expenses remain, want only the active one", len(all))
+	}
+	if _, err := store.GetByID(active.ID()); err != nil {
+		return fmt.Errorf("GetByID active after purging: %w", err)
+	}
+	return nil
+}
+
+func checkVersions(store storage.Store) error {
+	paidDate := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	rice, err := saveExpense(store, "gạo", 50000, "linh", paidDate)
+	if err != nil {
+		return err
+	}
+	if rice.Version() != 1 {
+		return fmt.Errorf("Save set version %d, want 1", rice.Version())
+	}
+	id := rice.ID()
+
+	items, amount := "gạo nếp", int64(65000)
+	changes := expense.Changes{Items: &items, Amount: &amount}
+	if err := expectErr(store.Update(id, 2, changes), expense.ErrVersionConflict, "Update stale"); err != nil {
+		return err
+	}
+	if err := expectErr(store.Update("does-not-exist", 1, changes), expense.ErrExpenseNotFound, "Update unknown"); err != nil {
+		return err
+	}
+	if err := store.Update(id, 1, changes); err != nil {
+		return fmt.Errorf("Update: %w", err)
+	}
+	got, err := store.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if got["items"] != items || got["amount"] != amount || got["paidBy"] != "linh" || got["version"] != int64(2) {
+		return fmt.Errorf("GetByID after Update = %v", got)
+	}
+
+	if err := expectErr(store.Delete(id, 1), expense.ErrVersionConflict, "Delete stale"); err != nil {
+		return err
+	}
+	if err := expectErr(store.Restore(id, 2), expense.ErrExpenseNotDeleted, "Restore active"); err != nil {
+		return err
+	}
+	if err := store.Delete(id, 2); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	if err := expectErr(store.Update(id, 3, changes), expense.ErrExpenseDeleted, "Update deleted"); err != nil {
+		return err
+	}
+	if err := expectErr(store.Restore(id, 2), expense.ErrVersionConflict, "Restore stale"); err != nil {
+		return err
+	}
+	if err := store.Restore(id, 3); err != nil {
+		return fmt.Errorf("Restore: %w", err)
+	}
+
+	got, err = store.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if got["status"] != "active" || got["version"] != int64(4) {
+		return fmt.Errorf("GetByID after Restore: status=%v version=%v, want active at version 4", got["status"], got["version"])
+	}
+	if deleted, err := store.GetDeleted(); err != nil || len(deleted) != 0 {
+		return fmt.Errorf("GetDeleted after Restore = %v, %v; want none", deleted, err)
+	}
+	return nil
+}
+
+func saveKind(store storage.Store, items string, amount int64, paidBy string, kind expense.Kind, paidTo string) (*expense.Expense, error) {
+	exp := expense.NewExpenseWithDate(items, amount, paidBy, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC))
+	exp.SetKind(kind, paidTo)
+	if err := store.Save(exp); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+func checkKinds(store storage.Store) error {
+	if _, err := saveKind(store, "gạo", 100000, "linh", expense.KindExpense, ""); err != nil {
+		return err
+	}
+	refund, err := saveKind(store, "áo khoác", -30000, "linh", expense.KindRefund, "")
+	if err != nil {
+		return err
+	}
+	if _, err := saveKind(store, "lương", -50000, "toan", expense.KindIncome, ""); err != nil {
+		return err
+	}
+	transfer, err := saveKind(store, "trả tiền chợ", 40000, "linh", expense.KindTransfer, "toan")
+	if err != nil {
+		return err
+	}
+
+	got, err := store.GetByID(refund.ID())
+	if err != nil {
+		return err
+	}
+	if got["kind"] != "refund" || got["amount"] != int64(-30000) {
+		return fmt.Errorf("GetByID refund: kind=%v amount=%v, want refund at -30000", got["kind"], got["amount"])
+	}
+	got, err = store.GetByID(transfer.ID())
+	if err != nil {
+		return err
+	}
+	if got["kind"] != "transfer" || got["paidTo"] != "toan" {
+		return fmt.Errorf("GetByID transfer: kind=%v paidTo=%v, want transfer to toan", got["kind"], got["paidTo"])
+	}
+	all, err := store.GetAll()
+	if err != nil {
+		return err
+	}
+	if len(all) != 4 || all[1]["kind"] != "refund" || all[3]["paidTo"] != "toan" {
+		return fmt.Errorf("GetAll = %v, want the kinds and recipient kept", all)
+	}
+	active, err := store.FindActiveExpenses()
+	if err != nil {
+		return err
+	}
+	kinds := make(map[expense.Kind]int)
+	for _, exp := range active {
+		kinds[exp.Kind()]++
+	}
+	if len(active) != 4 || kinds[expense.KindRefund] != 1 || kinds[expense.KindTransfer] != 1 {
+		return fmt.Errorf("FindActiveExpenses kinds = %v", kinds)
+	}
+
+	// linh: 100000 - 30000 + 40000; toan: -50000 - 40000 received
+	summary, err := store.GetSummaryByPaidBy()
+	if err != nil {
+		return err
+	}
+	if summary["linh"] != 110000 || summary["toan"] != -90000 {
+		return fmt.Errorf("GetSummaryByPaidBy = %v, want linh=110000 and toan=-90000", summary)
+	}
+
+	kind, paidTo := expense.KindExpense, ""
+	if err := store.Update(transfer.ID(), 1, expense.Changes{Kind: &kind, PaidTo: &paidTo}); err != nil {
+		return fmt.Errorf("Update kind: %w", err)
+	}
+	got, err = store.GetByID(transfer.ID())
+	if err != nil {
+		return err
+	}
+	if got["kind"] != "expense" || got["paidTo"] != "" {
+		return fmt.Errorf("GetByID after Update: kind=%v paidTo=%v, want an expense with no recipient", got["kind"], got["paidTo"])
+	}
+	summary, err = store.GetSummaryByPaidBy()
+	if err != nil {
+		return err
+	}
+	if summary["linh"] != 110000 || summary["toan"] != -50000 {
+		return fmt.Errorf("GetSummaryByPaidBy after Update = %v, want linh=110000 and toan=-50000", summary)
+	}
+
+	exported, err := store.ExportExpenses()
+	if err != nil {
+		return err
+	}
+	if err := store.ClearAll(); err != nil {
+		return err
+	}
+	for _, exp := range exported {
+		if _, err := store.ImportExpense(exp); err != nil {
+			return fmt.Errorf("ImportExpense: %w", err)
+		}
+	}
+	imported, err := store.GetSummaryByPaidBy()
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(imported, summary) {
+		return fmt.Errorf("GetSummaryByPaidBy after import = %v, want %v", imported, summary)
+	}
+
+	// Archives from before kinds existed have none; they are expenses
+	id, err := store.ImportExpense(backup.Expense{
+		Items: "rau", Amount: 20000, PaidBy: "linh", PaidDate: time.Now(), Status: "active",
+	})
+	if err != nil {
+		return err
+	}
+	got, err = store.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if got["kind"] != "expense" {
+		return fmt.Errorf("GetByID of an expense imported without a kind: kind=%v, want expense", got["kind"])
+	}
+	return nil
+}
+
+func checkCurrencies(store storage.Store) error {
+	local := expense.NewExpenseWithDate("phở", 50000, "linh", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC))
+	if err := store.Save(local); err != nil {
+		return err
+	}
+	foreign := expense.NewExpenseWithDate("khách sạn", 317500, "linh", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC))
+	foreign.SetOriginal(expense.NewMoneyIn(1250, "USD"), "25400")
+	if err := store.Save(foreign); err != nil {
+		return err
+	}
+
+	got, err := store.GetByID(foreign.ID())
+	if err != nil {
+		return err
+	}
+	if got["amount"] != int64(317500) || got["currency"] != "USD" || got["originalAmount"] != int64(1250) || got["rate"] != "25400" {
+		return fmt.Errorf("GetByID = %v, want 317500 converted from 12.50 USD at 25400", got)
+	}
+	// An expense in the base currency keeps what was paid as its amount
+	got, err = store.GetByID(local.ID())
+	if err != nil {
+		return err
+	}
+	if got["originalAmount"] != int64(50000) || got["rate"] != "" {
+		return fmt.Errorf("GetByID of a base currency expense = %v, want originalAmount 50000 and no rate", got)
+	}
+	active, err := store.FindActiveExpenses()
+	if err != nil {
+		return err
+	}
+	for _, exp := range active {
+		if exp.Items() == "khách sạn" && !exp.Original().Equals(expense.NewMoneyIn(1250, "USD")) {
+			return fmt.Errorf("FindActiveExpenses original = %s, want 12.50 USD", exp.Original())
+		}
+	}
+	summary, err := store.GetSummaryByPaidBy()
+	if err != nil {
+		return err
+	}
+	if summary["linh"] != 367500 {
+		return fmt.Errorf("GetSummaryByPaidBy = %v, want linh=367500 in the base currency", summary)
+	}
+
+	currency, original, amount, rate := expense.Currency("EUR"), int64(1000), int64(270000), "27000"
+	changes := expense.Changes{Currency: &currency, OriginalAmount: &original, Amount: &amount, Rate: &rate}
+	if err := store.Update(foreign.ID(), 1, changes); err != nil {
+		return fmt.Errorf("Update currency: %w", err)
+	}
+	got, err = store.GetByID(foreign.ID())
+	if err != nil {
+		return err
+	}
+	if got["amount"] != int64(270000) || got["currency"] != "EUR" || got["originalAmount"] != int64(1000) || got["rate"] != "27000" {
+		return fmt.Errorf("GetByID after Update = %v, want 270000 converted from 10.00 EUR at 27000", got)
+	}
+
+	exported, err := store.ExportExpenses()
+	if err != nil {
+		return err
+	}
+	if err := store.ClearAll(); err != nil {
+		return err
+	}
+	for _, exp := range exported {
+		if _, err := store.ImportExpense(exp); err != nil {
+			return fmt.Errorf("ImportExpense: %w", err)
+		}
+	}
+	all, err := store.GetAll()
+	if err != nil {
+		return err
+	}
+	if len(all) != 2 || all[1]["currency"] != "EUR" || all[1]["originalAmount"] != int64(1000) || all[1]["rate"] != "27000" {
+		return fmt.Errorf("GetAll after import = %v, want the currency kept", all)
+	}
+	return nil
+}
+
+func checkTags(store storage.Store) error {
+	tagged := expense.NewExpenseWithDate("bánh chưng", 500000, "linh", time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC))
+	tagged.SetTags([]string{"Tết 2026", "quà"})
+	tagged.SetNotes("biếu ông bà")
+	if err := store.Save(tagged); err != nil {
+		return err
+	}
+	plain := expense.NewExpenseWithDate("phở", 50000, "linh", time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC))
+	if err := store.Save(plain); err != nil {
+		return err
+	}
+
+	got, err := store.GetByID(tagged.ID())
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(got["tags"], []string{"Tết 2026", "quà"}) || got["notes"] != "biếu ông bà" {
+		return fmt.Errorf("GetByID = %v, want tags [Tết 2026 quà] and notes", got)
+	}
+	// An untagged expense has an empty list, not a missing one
+	got, err = store.GetByID(plain.ID())
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(got["tags"], []string{}) || got["notes"] != "" {
+		return fmt.Errorf("GetByID of an untagged expense = %v, want no tags and no notes", got)
+	}
+	active, err := store.FindActiveExpenses()
+	if err != nil {
+		return err
+	}
+	for _, exp := range active {
+		if exp.Items() == "bánh chưng" && (!reflect.DeepEqual(exp.Tags(), []string{"Tết 2026", "quà"}) || exp.Notes() != "biếu ông bà") {
+			return fmt.Errorf("FindActiveExpenses tags = %v, notes = %q", exp.Tags(), exp.Notes())
+		}
+	}
+
+	tags, notes := []string{"trip Đà Lạt"}, ""
+	if err := store.Update(tagged.ID(), 1, expense.Changes{Tags: &tags, Notes: &notes}); err != nil {
+		return fmt.Errorf("Update tags: %w", err)
+	}
+	got, err = store.GetByID(tagged.ID())
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(got["tags"], tags) || got["notes"] != "" {
+		return fmt.Errorf("GetByID after Update = %v, want tags [trip Đà Lạt] and no notes", got)
+	}
+	notes = "vé xe"
+	if err := store.Update(plain.ID(), 1, expense.Changes{Notes: &notes}); err != nil {
+		return fmt.Errorf("Update notes: %w", err)
+	}
+
+	exported, err := store.ExportExpenses()
+	if err != nil {
+		return err
+	}
+	if err := store.ClearAll(); err != nil {
+		return err
+	}
+	for _, exp := range exported {
+		if _, err := store.ImportExpense(exp); err != nil {
+			return fmt.Errorf("ImportExpense: %w", err)
+		}
+	}
+	all, err := store.GetAll()
+	if err != nil {
+		return err
+	}
+	if len(all) != 2 || !reflect.DeepEqual(all[0]["tags"], tags) || all[1]["notes"] != "vé xe" {
+		return fmt.Errorf("GetAll after import = %v, want the tags and notes kept", all)
+	}
+	return nil
+}
+
+func checkDismissals(store storage.Store) error {
+	if keys, err := store.ListDismissedDuplicates(); err != nil || len(keys) != 0 {
+		return fmt.Errorf("ListDismissedDuplicates on an empty store = %v, %v", keys, err)
+	}
+	first, second := expense.DuplicatePairKey("2", "1"), expense.DuplicatePairKey("1", "3")
+	for _, key := range []string{first, second, first} {
+		if err := store.DismissDuplicate(key, "admin"); err != nil {
+			return fmt.Errorf("DismissDuplicate %s: %w", key, err)
+		}
+	}
+	keys, err := store.ListDismissedDuplicates()
+	if err != nil {
+		return err
+	}
+	if len(keys) != 2 || keys[0] != "1|2" || keys[1] != "1|3" {
+		return fmt.Errorf("ListDismissedDuplicates = %v, want [1|2 1|3]", keys)
+	}
+	return nil
+}
+
+func checkAttachments(store storage.Store) error {
+	paidDate := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	fridge, err := saveExpense(store, "tủ lạnh", 9000000, "linh", paidDate)
+	if err != nil {
+		return err
+	}
+	rent, err := saveExpense(store, "tiền nhà", 5000000, "toan", paidDate)
+	if err != nil {
+		return err
+	}
+
+	uploadedAt := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	save := func(expenseID, filename, contentType string, data []byte, offset time.Duration) (*attachment.Attachment, error) {
+		a := &attachment.Attachment{
+			ExpenseID:   expenseID,
+			Filename:    filename,
+			ContentType: contentType,
+			UploadedBy:  "linh",
+			UploadedAt:  uploadedAt.Add(offset),
+		}
+		if err := store.SaveAttachment(a, data); err != nil {
+			return nil, fmt.Errorf("SaveAttachment %s: %w", filename, err)
+		}
+		if a.ID == "" || a.Size != int64(len(data)) {
+			return nil, fmt.Errorf("SaveAttachment %s set ID %q and size %d", filename, a.ID, a.Size)
+		}
+		return a, nil
+	}
+	invoice := []byte("%PDF-1.4 invoice")
+	warranty, err := save(fridge.ID(), "bao-hanh.png", "image/png", []byte("\x89PNG\r\n\x1a\n warranty"), time.Minute)
+	if err != nil {
+		return err
+	}
+	bill, err := save(fridge.ID(), "hoa-don.pdf", "application/pdf", invoice, 0)
+	if err != nil {
+		return err
+	}
+	contract, err := save(rent.ID(), "hop-dong.pdf", "application/pdf", []byte("%PDF-1.4 contract"), 0)
+	if err != nil {
+		return err
+	}
+
+	got, err := store.GetAttachment(bill.ID)
+	if err != nil {
+		return fmt.Errorf("GetAttachment: %w", err)
+	}
+	if got.ExpenseID != fridge.ID() || got.Filename != "hoa-don.pdf" || got.ContentType != "application/pdf" ||
+		got.Size != int64(len(invoice)) || got.UploadedBy != "linh" || !got.UploadedAt.Equal(uploadedAt) {
+		return fmt.Errorf("GetAttachment = %+v; want what was saved", got)
+	}
+	if data, err := store.AttachmentData(bill.ID); err != nil || !bytes.Equal(data, invoice) {
+		return fmt.Errorf("AttachmentData = %q, %v; want the saved file", data, err)
+	}
+	list, err := store.ListAttachments(fridge.ID())
+	if err != nil {
+		return fmt.Errorf("ListAttachments: %w", err)
+	}
+	if len(list) != 2 || list[0].ID != bill.ID || list[1].ID != warranty.ID {
+		return fmt.Errorf("ListAttachments = %+v; want the invoice then the warranty", list)
+	}
+	if all, err := store.ListAllAttachments(); err != nil || len(all) != 3 {
+		return fmt.Errorf("ListAllAttachments = %d attachments, %v; want 3", len(all), err)
+	}
+
+	if err := store.DeleteAttachment(warranty.ID); err != nil {
+		return fmt.Errorf("DeleteAttachment: %w", err)
+	}
+	if _, err := store.GetAttachment(warranty.ID); !errors.Is(err, attachment.ErrNotFound) {
+		return fmt.Errorf("GetAttachment deleted: got error %v, want %v", err, attachment.ErrNotFound)
+	}
+	if _, err := store.AttachmentData(warranty.ID); !errors.Is(err, attachment.ErrNotFound) {
+		return fmt.Errorf("AttachmentData deleted: got error %v, want %v", err, attachment.ErrNotFound)
+	}
+	if err := expectErr(store.DeleteAttachment(warranty.ID), attachment.ErrNotFound, "DeleteAttachment twice"); err != nil {
+		return err
+	}
+
+	// Moving an expense to the trash keeps its files; purging removes them
+	if err := store.Delete(fridge.ID(), 1); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	if list, err := store.ListAttachments(fridge.ID()); err != nil || len(list) != 1 {
+		return fmt.Errorf("ListAttachments of a deleted expense = %d, %v; want 1", len(list), err)
+	}
+	if err := store.Purge(fridge.ID()); err != nil {
+		return fmt.Errorf("Purge: %w", err)
+	}
+	if list, err := store.ListAttachments(fridge.ID()); err != nil || len(list) != 0 {
+		return fmt.Errorf("ListAttachments of a purged expense = %d, %v; want none", len(list), err)
+	}
+	if _, err := store.AttachmentData(bill.ID); !errors.Is(err, attachment.ErrNotFound) {
+		return fmt.Errorf("AttachmentData of a purged expense: got error %v, want %v", err, attachment.ErrNotFound)
+	}
+	if _, err := store.GetAttachment(contract.ID); err != nil {
+		return fmt.Errorf("Purge removed another expense's attachment: %v", err)
+	}
+
+	if err := store.Delete(rent.ID(), 1); err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	if purged, err := store.PurgeDeleted(time.Time{}); err != nil || purged != 1 {
+		return fmt.Errorf("PurgeDeleted = %d, %v; want 1", purged, err)
+	}
+	if all, err := store.ListAllAttachments(); err != nil || len(all) != 0 {
+		return fmt.Errorf("ListAllAttachments after emptying the trash = %d, %v; want none", len(all), err)
+	}
+
+	kept, err := saveExpense(store, "máy giặt", 7000000, "linh", paidDate)
+	if err != nil {
+		return err
+	}
+	if _, err := save(kept.ID(), "hoa-don.pdf", "application/pdf", invoice, 0); err != nil {
+		return err
+	}
+	if err := store.ClearAll(); err != nil {
+		return fmt.Errorf("ClearAll: %w", err)
+	}
+	if all, err := store.ListAllAttachments(); err != nil || len(all) != 0 {
+		return fmt.Errorf("ListAllAttachments after ClearAll = %d, %v; want none", len(all), err)
+	}
+	return nil
+}
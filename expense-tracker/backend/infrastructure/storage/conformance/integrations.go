@@ -0,0 +1,290 @@
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"expense-tracker/domain/chat"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/idempotency"
+	"expense-tracker/domain/receipt"
+	"expense-tracker/domain/webhook"
+	"expense-tracker/infrastructure/storage"
+)
+
+// Integrations checks idempotency keys, webhooks, chat links and receipts
+var Integrations = Suite{"integrations", []Check{
+	{"idempotency", checkIdempotency},
+	{"webhooks", checkWebhooks},
+	{"chat links", checkChatLinks},
+	{"receipts", checkReceipts},
+}}
+
+func checkIdempotency(store storage.Store) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	claim := func(username, key, hash string, createdAt, staleBefore time.Time) (*idempotency.Record, error) {
+		return store.ClaimIdempotencyKey(idempotency.Record{
+			Username: username, Key: key, RequestHash: hash,
+			CreatedAt: createdAt, ExpiresAt: createdAt.Add(time.Hour),
+		}, staleBefore)
+	}
+
+	if existing, err := claim("linh", "k1", "h1", now, now.Add(-time.Minute)); err != nil || existing != nil {
+		return fmt.Errorf("first claim = %+v, %v; want it claimed", existing, err)
+	}
+	existing, err := claim("linh", "k1", "h1", now, now.Add(-time.Minute))
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.Completed || existing.RequestHash != "h1" {
+		return fmt.Errorf("second claim = %+v, want the pending record", existing)
+	}
+	if existing, err := claim("toan", "k1", "h2", now, now.Add(-time.Minute)); err != nil || existing != nil {
+		return fmt.Errorf("claim of the same key by another user = %+v, %v; want it claimed", existing, err)
+	}
+
+	response := []byte(`{"success":true}`)
+	if err := store.CompleteIdempotencyKey("linh", "k1", "e1", 200, response); err != nil {
+		return fmt.Errorf("CompleteIdempotencyKey: %w", err)
+	}
+	existing, err = claim("linh", "k1", "h1", now, now.Add(time.Minute))
+	if err != nil {
+		return err
+	}
+	if existing == nil || !existing.Completed || existing.ExpenseID != "e1" || existing.StatusCode != 200 || string(existing.Response) != string(response) {
+		return fmt.Errorf("claim after completing = %+v, want the completed record even when stale", existing)
+	}
+	if err := store.ReleaseIdempotencyKey("linh", "k1"); err != nil {
+		return err
+	}
+	if existing, err := claim("linh", "k1", "h1", now, now); err != nil || existing == nil {
+		return fmt.Errorf("ReleaseIdempotencyKey removed a completed record (%v)", err)
+	}
+
+	// toan's pending claim is abandoned: a later stale cutoff takes it over
+	if existing, err := claim("toan", "k1", "h3", now.Add(time.Minute), now.Add(time.Second)); err != nil || existing != nil {
+		return fmt.Errorf("claim over a stale pending record = %+v, %v; want it claimed", existing, err)
+	}
+	if err := store.ReleaseIdempotencyKey("toan", "k1"); err != nil {
+		return err
+	}
+	if existing, err := claim("toan", "k1", "h4", now, now.Add(-time.Minute)); err != nil || existing != nil {
+		return fmt.Errorf("claim after release = %+v, %v; want it claimed", existing, err)
+	}
+
+	// Past its expiry the completed record no longer counts
+	if existing, err := claim("linh", "k1", "h5", now.Add(2*time.Hour), now); err != nil || existing != nil {
+		return fmt.Errorf("claim after expiry = %+v, %v; want it claimed", existing, err)
+	}
+	return nil
+}
+
+func checkWebhooks(store storage.Store) error {
+	if list, err := store.ListWebhooks(); err != nil || len(list) != 0 {
+		return fmt.Errorf("ListWebhooks on an empty store = %v, %v", list, err)
+	}
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	firstID, err := store.CreateWebhook(webhook.Subscription{
+		URL:       "https://example.com/first",
+		Events:    []expense.EventType{expense.ExpenseCreated, expense.ExpenseDeleted},
+		Secret:    "first-secret",
+		CreatedBy: "admin",
+		CreatedAt: created,
+	})
+	if err != nil {
+		return fmt.Errorf("CreateWebhook: %w", err)
+	}
+	secondID, err := store.CreateWebhook(webhook.Subscription{
+		URL:       "https://example.com/second",
+		Events:    []expense.EventType{expense.ExpenseUpdated},
+		Secret:    "second-secret",
+		CreatedBy: "admin",
+		CreatedAt: created.Add(time.Minute),
+	})
+	if err != nil {
+		return fmt.Errorf("CreateWebhook: %w", err)
+	}
+	if firstID == "" || firstID == secondID {
+		return fmt.Errorf("CreateWebhook IDs = %q, %q; want distinct", firstID, secondID)
+	}
+
+	list, err := store.ListWebhooks()
+	if err != nil {
+		return err
+	}
+	if len(list) != 2 || list[0].ID != firstID || list[1].ID != secondID {
+		return fmt.Errorf("ListWebhooks = %+v, want first then second", list)
+	}
+	first := list[0]
+	if first.URL != "https://example.com/first" || first.Secret != "first-secret" || first.CreatedBy != "admin" ||
+		len(first.Events) != 2 || first.Events[0] != expense.ExpenseCreated || first.Events[1] != expense.ExpenseDeleted ||
+		!first.CreatedAt.Equal(created) {
+		return fmt.Errorf("stored webhook = %+v", first)
+	}
+	if found, err := store.GetWebhook(secondID); err != nil || found.Secret != "second-secret" {
+		return fmt.Errorf("GetWebhook = %+v, %v; want second-secret", found, err)
+	}
+	if _, err := store.RotateSecrets(); err != nil {
+		return fmt.Errorf("RotateSecrets: %w", err)
+	}
+	if found, err := store.GetWebhook(firstID); err != nil || found.Secret != "first-secret" {
+		return fmt.Errorf("GetWebhook after RotateSecrets = %+v, %v", found, err)
+	}
+
+	for attempt := 1; attempt <= webhook.DeliveryLogSize+5; attempt++ {
+		err := store.RecordDelivery(webhook.Delivery{
+			WebhookID:  firstID,
+			DeliveryID: "d1",
+			Event:      expense.ExpenseCreated,
+			Attempt:    attempt,
+			StatusCode: 500,
+			Time:       created.Add(time.Duration(attempt) * time.Second),
+		})
+		if err != nil {
+			return fmt.Errorf("RecordDelivery: %w", err)
+		}
+	}
+	if err := store.RecordDelivery(webhook.Delivery{WebhookID: secondID, DeliveryID: "d2", Event: webhook.EventPing, Attempt: 1, StatusCode: 204, Time: created}); err != nil {
+		return fmt.Errorf("RecordDelivery: %w", err)
+	}
+	deliveries, err := store.ListDeliveries(firstID)
+	if err != nil {
+		return err
+	}
+	if len(deliveries) != webhook.DeliveryLogSize || deliveries[0].Attempt != webhook.DeliveryLogSize+5 || deliveries[len(deliveries)-1].Attempt != 6 {
+		return fmt.Errorf("ListDeliveries kept %d, newest attempt %d; want the newest %d, newest first",
+			len(deliveries), deliveries[0].Attempt, webhook.DeliveryLogSize)
+	}
+	if d := deliveries[0]; d.WebhookID != firstID || d.DeliveryID != "d1" || d.Event != expense.ExpenseCreated || d.StatusCode != 500 || d.ID == "" {
+		return fmt.Errorf("stored delivery = %+v", d)
+	}
+
+	if err := store.DeleteWebhook(firstID); err != nil {
+		return fmt.Errorf("DeleteWebhook: %w", err)
+	}
+	if err := expectErr(store.DeleteWebhook(firstID), webhook.ErrNotFound, "DeleteWebhook twice"); err != nil {
+		return err
+	}
+	if _, err := store.GetWebhook(firstID); !errors.Is(err, webhook.ErrNotFound) {
+		return fmt.Errorf("GetWebhook after delete: got error %v, want %v", err, webhook.ErrNotFound)
+	}
+	if deliveries, err := store.ListDeliveries(firstID); err != nil || len(deliveries) != 0 {
+		return fmt.Errorf("ListDeliveries after delete = %d, %v; want none", len(deliveries), err)
+	}
+	if deliveries, err := store.ListDeliveries(secondID); err != nil || len(deliveries) != 1 || !deliveries[0].Succeeded() {
+		return fmt.Errorf("ListDeliveries of the other webhook = %+v, %v", deliveries, err)
+	}
+	return nil
+}
+
+func checkChatLinks(store storage.Store) error {
+	now := time.Now()
+	codes := []chat.LinkCode{
+		{Code: "VALIDCODE", Username: "linh", CreatedAt: now, ExpiresAt: now.Add(chat.LinkCodeTTL)},
+		{Code: "OLDCODE", Username: "linh", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute)},
+	}
+	for _, code := range codes {
+		if err := store.CreateLinkCode(code); err != nil {
+			return fmt.Errorf("CreateLinkCode %s: %w", code.Code, err)
+		}
+	}
+	if username, err := store.ConsumeLinkCode("VALIDCODE", now); err != nil || username != "linh" {
+		return fmt.Errorf("ConsumeLinkCode = %q, %v; want linh", username, err)
+	}
+	if _, err := store.ConsumeLinkCode("VALIDCODE", now); !errors.Is(err, chat.ErrLinkCodeInvalid) {
+		return fmt.Errorf("ConsumeLinkCode twice: got error %v, want %v", err, chat.ErrLinkCodeInvalid)
+	}
+	if _, err := store.ConsumeLinkCode("OLDCODE", now); !errors.Is(err, chat.ErrLinkCodeInvalid) {
+		return fmt.Errorf("ConsumeLinkCode expired: got error %v, want %v", err, chat.ErrLinkCodeInvalid)
+	}
+	if _, err := store.ConsumeLinkCode("NOSUCHCODE", now); !errors.Is(err, chat.ErrLinkCodeInvalid) {
+		return fmt.Errorf("ConsumeLinkCode unknown: got error %v, want %v", err, chat.ErrLinkCodeInvalid)
+	}
+
+	if _, err := store.FindChatLink(chat.ProviderTelegram, "42"); !errors.Is(err, chat.ErrNotLinked) {
+		return fmt.Errorf("FindChatLink before linking: got error %v, want %v", err, chat.ErrNotLinked)
+	}
+	linkedAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	links := []chat.Link{
+		{Provider: chat.ProviderTelegram, ChatUserID: "42", ChatName: "Linh", Username: "linh", LinkedAt: linkedAt},
+		{Provider: chat.ProviderTelegram, ChatUserID: "43", Username: "linh", LinkedAt: linkedAt.Add(time.Minute)},
+		// Relinking the same chat account moves it to another user
+		{Provider: chat.ProviderTelegram, ChatUserID: "42", ChatName: "Linh T", Username: "admin", LinkedAt: linkedAt.Add(2 * time.Minute)},
+	}
+	for _, link := range links {
+		if err := store.SaveChatLink(link); err != nil {
+			return fmt.Errorf("SaveChatLink: %w", err)
+		}
+	}
+	found, err := store.FindChatLink(chat.ProviderTelegram, "42")
+	if err != nil || found.Username != "admin" || found.ChatName != "Linh T" || !found.LinkedAt.Equal(linkedAt.Add(2*time.Minute)) {
+		return fmt.Errorf("FindChatLink after relinking = %+v, %v; want it on admin", found, err)
+	}
+	if listed, err := store.ListChatLinks("linh"); err != nil || len(listed) != 1 || listed[0].ChatUserID != "43" {
+		return fmt.Errorf("ListChatLinks = %+v, %v; want only chat user 43", listed, err)
+	}
+
+	if err := expectErr(store.DeleteChatLink("linh", chat.ProviderTelegram, "42"), chat.ErrNotLinked, "DeleteChatLink of another user's link"); err != nil {
+		return err
+	}
+	if err := store.DeleteChatLink("admin", chat.ProviderTelegram, "42"); err != nil {
+		return fmt.Errorf("DeleteChatLink: %w", err)
+	}
+	if _, err := store.FindChatLink(chat.ProviderTelegram, "42"); !errors.Is(err, chat.ErrNotLinked) {
+		return fmt.Errorf("FindChatLink after delete: got error %v, want %v", err, chat.ErrNotLinked)
+	}
+	return nil
+}
+
+func checkReceipts(store storage.Store) error {
+	image := []byte("\x89PNG\r\n\x1a\n receipt")
+	rec := &receipt.Receipt{
+		Filename:    "bill.png",
+		ContentType: "image/png",
+		Size:        int64(len(image)),
+		Merchant:    "Co.opmart",
+		Date:        "2024-03-01",
+		Total:       250000,
+		Items: []receipt.LineItem{
+			{Description: "Gạo", Quantity: "5", Unit: "kg", BaseQuantity: "5", BaseUnit: "kg", Amount: 150000},
+			{Description: "Sữa", Amount: 100000},
+		},
+		UploadedBy: "linh",
+		UploadedAt: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+	}
+	if err := store.SaveReceipt(rec, image); err != nil {
+		return fmt.Errorf("SaveReceipt: %w", err)
+	}
+	if rec.ID == "" {
+		return fmt.Errorf("SaveReceipt did not set the ID")
+	}
+	if err := store.LinkReceiptExpenses(rec.ID, []string{"e2", "e1"}); err != nil {
+		return fmt.Errorf("LinkReceiptExpenses: %w", err)
+	}
+
+	got, err := store.GetReceipt(rec.ID)
+	if err != nil {
+		return fmt.Errorf("GetReceipt: %w", err)
+	}
+	if got.Merchant != "Co.opmart" || got.Total != 250000 || len(got.Items) != 2 || got.Items[0].BaseUnit != "kg" ||
+		!reflect.DeepEqual(got.ExpenseIDs, []string{"e2", "e1"}) || !got.UploadedAt.Equal(rec.UploadedAt) {
+		return fmt.Errorf("GetReceipt = %+v; want what was saved, linked to e2 and e1", got)
+	}
+	if data, err := store.ReceiptImage(rec.ID); err != nil || !bytes.Equal(data, image) {
+		return fmt.Errorf("ReceiptImage = %q, %v; want the saved image", data, err)
+	}
+	if found, err := store.FindReceiptByExpense("e1"); err != nil || found.ID != rec.ID {
+		return fmt.Errorf("FindReceiptByExpense = %+v, %v; want receipt %s", found, err, rec.ID)
+	}
+
+	if _, err := store.FindReceiptByExpense("e3"); !errors.Is(err, receipt.ErrNotFound) {
+		return fmt.Errorf("FindReceiptByExpense unlinked: got error %v, want %v", err, receipt.ErrNotFound)
+	}
+	if _, err := store.GetReceipt("999999"); !errors.Is(err, receipt.ErrNotFound) {
+		return fmt.Errorf("GetReceipt unknown: got error %v, want %v", err, receipt.ErrNotFound)
+	}
+	return expectErr(store.LinkReceiptExpenses("999999", []string{"e1"}), receipt.ErrNotFound, "LinkReceiptExpenses unknown")
+}
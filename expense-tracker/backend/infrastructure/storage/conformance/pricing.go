@@ -0,0 +1,221 @@
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"expense-tracker/domain/catalogue"
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/price"
+	"expense-tracker/infrastructure/storage"
+)
+
+// Pricing checks exchange rates, unit prices and the item catalogue
+var Pricing = Suite{"pricing", []Check{
+	{"rates", checkRates},
+	{"prices", checkPrices},
+	{"catalogue", checkCatalogue},
+}}
+
+func checkRates(store storage.Store) error {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	saved := []exchange.Rate{
+		{Currency: "USD", Date: day(10), Rate: "25400", UpdatedBy: "admin", UpdatedAt: time.Now()},
+		{Currency: "EUR", Date: day(1), Rate: "27000.5", UpdatedBy: "admin", UpdatedAt: time.Now()},
+		{Currency: "USD", Date: day(1), Rate: "25000", UpdatedBy: "admin", UpdatedAt: time.Now()},
+	}
+	for _, rate := range saved {
+		if err := store.SaveRate(rate); err != nil {
+			return err
+		}
+	}
+	// Saving the same currency and day again replaces the rate
+	if err := store.SaveRate(exchange.Rate{Currency: "USD", Date: day(10), Rate: "25450", UpdatedBy: "linh", UpdatedAt: time.Now()}); err != nil {
+		return err
+	}
+
+	rates, err := store.ListRates()
+	if err != nil {
+		return err
+	}
+	var listed []string
+	for _, rate := range rates {
+		listed = append(listed, fmt.Sprintf("%s %s %s", rate.Currency, rate.Date.Format(exchange.DateLayout), rate.Rate))
+	}
+	want := []string{"EUR 2024-03-01 27000.5", "USD 2024-03-01 25000", "USD 2024-03-10 25450"}
+	if !reflect.DeepEqual(listed, want) {
+		return fmt.Errorf("ListRates = %v, want %v", listed, want)
+	}
+	if rates[2].UpdatedBy != "linh" {
+		return fmt.Errorf("ListRates updatedBy = %q, want linh", rates[2].UpdatedBy)
+	}
+	if found, err := exchange.Find(rates, "USD", day(12)); err != nil || found.Rate != "25450" {
+		return fmt.Errorf("Find = %v, %v, want the rate of 2024-03-10", found, err)
+	}
+
+	if err := store.DeleteRate("USD", day(1)); err != nil {
+		return err
+	}
+	if err := store.DeleteRate("USD", day(1)); !errors.Is(err, exchange.ErrNotFound) {
+		return fmt.Errorf("DeleteRate of a missing rate = %v, want ErrNotFound", err)
+	}
+	rates, err = store.ListRates()
+	if err != nil {
+		return err
+	}
+	if len(rates) != 2 {
+		return fmt.Errorf("ListRates after delete returned %d rates, want 2", len(rates))
+	}
+	// Rates describe the currencies rather than the expenses, so they
+	// survive ClearAll
+	if err := store.ClearAll(); err != nil {
+		return err
+	}
+	if rates, err := store.ListRates(); err != nil || len(rates) != 2 {
+		return fmt.Errorf("ListRates after ClearAll = %d rates, %v, want 2", len(rates), err)
+	}
+	return nil
+}
+
+func checkPrices(store storage.Store) error {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	point := func(expenseID, item string, d int, unitPrice int64) price.Point {
+		return price.Point{ExpenseID: expenseID, Item: item, Items: item, PaidDate: day(d), Amount: unitPrice * 2,
+			Quantity: "2", Unit: "kg", UnitPrice: unitPrice}
+	}
+	for _, p := range []price.Point{point("3", "gao", 10, 20000), point("10", "gao", 1, 18000), point("2", "thit", 5, 150000)} {
+		if err := store.SavePrice(p); err != nil {
+			return err
+		}
+	}
+	// Saving the same expense again replaces its point
+	if err := store.SavePrice(point("3", "gao", 12, 21000)); err != nil {
+		return err
+	}
+
+	listed := func(item string) ([]string, error) {
+		points, err := store.ListPrices(item)
+		if err != nil {
+			return nil, err
+		}
+		summary := []string{}
+		for _, p := range points {
+			summary = append(summary, fmt.Sprintf("%s %s %d", p.ExpenseID, p.PaidDate.Format(exchange.DateLayout), p.UnitPrice))
+		}
+		return summary, nil
+	}
+	got, err := listed("gao")
+	if err != nil {
+		return err
+	}
+	if want := []string{"10 2024-03-01 18000", "3 2024-03-12 21000"}; !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("ListPrices = %v, want %v", got, want)
+	}
+	points, err := store.ListPrices("thit")
+	if err != nil {
+		return err
+	}
+	if want := point("2", "thit", 5, 150000); len(points) != 1 || points[0] != want {
+		return fmt.Errorf("ListPrices = %+v, want %+v", points, want)
+	}
+
+	if err := store.DeletePrice("10"); err != nil {
+		return err
+	}
+	// Deleting a point that is not there is not an error
+	if err := store.DeletePrice("10"); err != nil {
+		return fmt.Errorf("DeletePrice of a missing point: %v", err)
+	}
+	if got, err := listed("gao"); err != nil || len(got) != 1 {
+		return fmt.Errorf("ListPrices after delete = %v, %v, want 1 point", got, err)
+	}
+
+	if err := store.ReplacePrices([]price.Point{point("7", "gao", 2, 19000)}); err != nil {
+		return err
+	}
+	if got, err := listed("gao"); err != nil || !reflect.DeepEqual(got, []string{"7 2024-03-02 19000"}) {
+		return fmt.Errorf("ListPrices after replace = %v, %v, want only expense 7", got, err)
+	}
+	if got, err := listed("thit"); err != nil || len(got) != 0 {
+		return fmt.Errorf("ListPrices after replace = %v, %v, want no points", got, err)
+	}
+	if err := store.ReplacePrices(nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func checkCatalogue(store storage.Store) error {
+	coffee := catalogue.Item{
+		Name:      "Cà phê",
+		Aliases:   []string{"cafe", "cà phê sữa, đá"},
+		Category:  "Đồ uống",
+		Unit:      "gói",
+		UpdatedBy: "admin",
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	id, err := store.CreateItem(coffee)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return errors.New("CreateItem returned no ID")
+	}
+	coffee.ID = id
+	if _, err := store.CreateItem(catalogue.Item{Name: "Bánh mì", Aliases: []string{}, UpdatedAt: time.Now()}); err != nil {
+		return err
+	}
+
+	got, err := store.GetItem(id)
+	if err != nil {
+		return err
+	}
+	if got.Name != coffee.Name || !reflect.DeepEqual(got.Aliases, coffee.Aliases) || got.Category != coffee.Category ||
+		got.Unit != coffee.Unit || got.UpdatedBy != coffee.UpdatedBy || !got.UpdatedAt.Equal(coffee.UpdatedAt) {
+		return fmt.Errorf("GetItem = %+v, want %+v", got, coffee)
+	}
+	if _, err := store.GetItem("999999"); !errors.Is(err, catalogue.ErrNotFound) {
+		return fmt.Errorf("GetItem of a missing item = %v, want ErrNotFound", err)
+	}
+
+	items, err := store.ListItems()
+	if err != nil {
+		return err
+	}
+	if len(items) != 2 || items[0].Name != "Bánh mì" || items[1].Name != "Cà phê" {
+		return fmt.Errorf("ListItems = %+v, want Bánh mì then Cà phê", items)
+	}
+	if items[0].Aliases == nil {
+		return errors.New("ListItems returned nil aliases, want an empty list")
+	}
+
+	coffee.Aliases = []string{"cafe"}
+	coffee.Category = ""
+	if err := store.UpdateItem(coffee); err != nil {
+		return err
+	}
+	if got, err := store.GetItem(id); err != nil || !reflect.DeepEqual(got.Aliases, []string{"cafe"}) || got.Category != "" {
+		return fmt.Errorf("GetItem after update = %+v, %v", got, err)
+	}
+	if err := expectErr(store.UpdateItem(catalogue.Item{ID: "999999", Name: "x"}), catalogue.ErrNotFound, "UpdateItem unknown"); err != nil {
+		return err
+	}
+
+	// The catalogue describes items rather than expenses, so it survives
+	// ClearAll
+	if err := store.ClearAll(); err != nil {
+		return err
+	}
+	if err := store.DeleteItem(id); err != nil {
+		return err
+	}
+	if err := expectErr(store.DeleteItem(id), catalogue.ErrNotFound, "DeleteItem twice"); err != nil {
+		return err
+	}
+	if items, err := store.ListItems(); err != nil || len(items) != 1 {
+		return fmt.Errorf("ListItems after delete = %d items, %v, want 1", len(items), err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"expense-tracker/domain/attachment"
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/backup"
+	"expense-tracker/domain/catalogue"
+	"expense-tracker/domain/chat"
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/idempotency"
+	"expense-tracker/domain/price"
+	"expense-tracker/domain/receipt"
+	"expense-tracker/domain/user"
+	"expense-tracker/domain/webhook"
+	"expense-tracker/infrastructure/memory"
+	"expense-tracker/infrastructure/mongodb"
+	"expense-tracker/infrastructure/secrets"
+	"expense-tracker/infrastructure/sessionstore"
+	"expense-tracker/infrastructure/sqlite"
+)
+
+// Backends accepted in STORAGE_BACKEND
+const (
+	BackendMongoDB = "mongodb"
+	BackendSQLite  = "sqlite"
+	BackendMemory  = "memory"
+)
+
+const defaultSQLitePath = "expense_tracker.db"
+
+// Store is everything the application persists: expenses, settings, users,
+// invites, sessions, idempotency keys, dismissed duplicates, webhooks,
+// chat links, receipts, attachments, exchange rates, unit prices, the item catalogue and the
+// audit log. mongodb, sqlite and memory all implement it.
+type Store interface {
+	expense.Repository
+	expense.DismissalRepository
+	audit.Repository
+	backup.Repository
+	idempotency.Repository
+	webhook.Repository
+	chat.Repository
+	receipt.Repository
+	attachment.Repository
+	exchange.Repository
+	price.Repository
+	catalogue.Repository
+	sessionstore.Backend
+
+	SaveAPIKey(apiKey string) error
+	GetAPIKey() (string, error)
+	RotateSecrets() (int, error)
+	GetRegistrationMode() (user.RegistrationMode, error)
+	SaveRegistrationMode(mode user.RegistrationMode) error
+
+	CreateUser(username, password string) error
+	GetUser(username string) (string, error)
+	FindUser(username string) (*user.UserDTO, error)
+	ListUsers() ([]user.UserDTO, error)
+	SetUserRole(username string, role user.Role) error
+	SetUserDisabled(username string, disabled bool) error
+	ResetPassword(username, password string) error
+	DeleteUser(username string) error
+	InitDefaultUsers() error
+
+	CreateInvite(createdBy string, ttl time.Duration) (*user.InviteDTO, error)
+	ListInvites() ([]user.InviteDTO, error)
+	ConsumeInvite(code, username string) error
+	ReleaseInvite(code string) error
+	DeleteInvite(code string) error
+
+	ListSessions(username string) ([]user.SessionDTO, error)
+	RevokeSession(username, id string) error
+	RevokeUserSessions(username, exceptID string) error
+
+	Close() error
+}
+
+var (
+	_ Store = (*mongodb.Repository)(nil)
+	_ Store = (*sqlite.Repository)(nil)
+	_ Store = (*memory.Repository)(nil)
+)
+
+// Backend returns the configured backend name, mongodb by default
+func Backend() string {
+	if backend := os.Getenv("STORAGE_BACKEND"); backend != "" {
+		return backend
+	}
+	return BackendMongoDB
+}
+
+// Open connects to the backend named by STORAGE_BACKEND. mongodb uses
+// MONGODB_URI, sqlite uses the file in SQLITE_PATH and memory keeps nothing
+// across restarts.
+func Open() (Store, error) {
+	switch backend := Backend(); backend {
+	case BackendMongoDB:
+		repo, err := mongodb.NewRepository()
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	case BackendSQLite, BackendMemory:
+		box, err := secrets.NewBoxFromEnv()
+		if err == secrets.ErrNoMasterKey {
+			log.Printf("[STORAGE] Warning: SECRETS_KEY not set, API keys cannot be saved from the settings page")
+		} else if err != nil {
+			return nil, err
+		}
+		if backend == BackendMemory {
+			log.Printf("[STORAGE] Using in-memory storage, data is lost on restart")
+			return memory.NewRepository(box), nil
+		}
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = defaultSQLitePath
+		}
+		repo, err := sqlite.NewRepository(path, box)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q (want %s, %s or %s)", backend, BackendMongoDB, BackendSQLite, BackendMemory)
+	}
+}
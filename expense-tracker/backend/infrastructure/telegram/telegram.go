@@ -0,0 +1,132 @@
+// Package telegram speaks the small part of the Telegram Bot API the chat
+// webhook needs: decoding updates and sending text replies.
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"expense-tracker/domain/chat"
+)
+
+// DefaultAPIURL is the real Bot API; TELEGRAM_API_URL points elsewhere,
+// e.g. at cmd/fakebot
+const DefaultAPIURL = "https://api.telegram.org"
+
+// SecretHeader carries the secret_token given to setWebhook, so that only
+// Telegram can post updates
+const SecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// Update is an incoming update; only text messages are used
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message,omitempty"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from,omitempty"`
+	Chat      Chat   `json:"chat"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text,omitempty"`
+}
+
+type User struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot,omitempty"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name,omitempty"`
+	Username  string `json:"username,omitempty"`
+}
+
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+// ChatMessage returns the update as a chat message, or false when it is not
+// a text message from a person
+func (u Update) ChatMessage() (chat.Message, bool) {
+	m := u.Message
+	if m == nil || m.From == nil || m.From.IsBot || strings.TrimSpace(m.Text) == "" {
+		return chat.Message{}, false
+	}
+	name := strings.TrimSpace(m.From.FirstName + " " + m.From.LastName)
+	if m.From.Username != "" {
+		name = "@" + m.From.Username
+	}
+	return chat.Message{
+		Provider:   chat.ProviderTelegram,
+		ChatID:     strconv.FormatInt(m.Chat.ID, 10),
+		SenderID:   strconv.FormatInt(m.From.ID, 10),
+		SenderName: name,
+		Text:       m.Text,
+	}, true
+}
+
+// Client sends messages through the Bot API
+type Client struct {
+	apiURL string
+	token  string
+	http   *http.Client
+}
+
+func NewClient(apiURL, token string) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &Client{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		token:  token,
+		http:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type sendMessageRequest struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+type apiResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description,omitempty"`
+}
+
+// SendMessage implements chat.Messenger
+func (c *Client) SendMessage(chatID, text string) error {
+	body, err := json.Marshal(sendMessageRequest{ChatID: chatID, Text: text})
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Post(c.apiURL+"/bot"+c.token+"/sendMessage", "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The URL holds the token; keep it out of logs
+		return fmt.Errorf("telegram sendMessage: %w", stripURL(err))
+	}
+	defer resp.Body.Close()
+
+	var result apiResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return fmt.Errorf("telegram sendMessage: status %d: %w", resp.StatusCode, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram sendMessage: status %d: %s", resp.StatusCode, result.Description)
+	}
+	return nil
+}
+
+func stripURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -1,18 +1,35 @@
 package http
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"time"
 	"expense-tracker/application/services"
+	"expense-tracker/domain/expense"
 	"github.com/gin-gonic/gin"
 )
 
 type AdminHandler struct {
-	service *services.ExpenseService
+	service        *services.ExpenseService
+	attachments    *services.AttachmentService
+	trashRetention time.Duration
 }
 
-func NewAdminHandler(service *services.ExpenseService) *AdminHandler {
-	return &AdminHandler{service: service}
+// NewAdminHandler shows trashRetention on the trash page; the purge itself
+// runs in the background
+func NewAdminHandler(service *services.ExpenseService, attachments *services.AttachmentService, trashRetention time.Duration) *AdminHandler {
+	return &AdminHandler{service: service, attachments: attachments, trashRetention: trashRetention}
+}
+
+// confirmed rejects a destructive request that does not carry confirm=true,
+// so that a stray call cannot remove data
+func confirmed(c *gin.Context) bool {
+	if c.Query("confirm") == "true" {
+		return true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Thao tác này không thể hoàn tác, cần xác nhận (confirm=true)"})
+	return false
 }
 
 func (h *AdminHandler) AdminPage(c *gin.Context) {
@@ -22,7 +39,14 @@ func (h *AdminHandler) AdminPage(c *gin.Context) {
 		return
 	}
 
+	attachments, err := h.attachments.ByExpense()
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": err.Error()})
+		return
+	}
+
 	// Convert DTOs to map for template compatibility
+	base := h.service.BaseCurrency()
 	var expensesMaps []map[string]interface{}
 	for _, exp := range expenses {
 		expenseMap := map[string]interface{}{
@@ -36,6 +60,16 @@ func (h *AdminHandler) AdminPage(c *gin.Context) {
 			"originalMessage": exp.OriginalMessage,
 			"paidDate":        exp.PaidDate,
 			"paidBy":          exp.PaidBy,
+			"kind":            string(exp.Kind),
+			"paidTo":          exp.PaidTo,
+			"tags":            exp.Tags,
+			"notes":           exp.Notes,
+			"version":         exp.Version,
+			"attachments":     attachments[exp.ID],
+		}
+		if exp.Currency != base {
+			expenseMap["original"] = expense.NewMoneyIn(exp.OriginalAmount, exp.Currency).String()
+			expenseMap["rate"] = exp.Rate
 		}
 		expensesMaps = append(expensesMaps, expenseMap)
 	}
@@ -62,6 +96,8 @@ func (h *AdminHandler) AdminPage(c *gin.Context) {
 		"total":      len(expenses),
 		"summary":    summary,
 		"grandTotal": grandTotal,
+		"base":       base,
+		"csrfToken":  CSRFToken(c),
 	})
 }
 
@@ -73,18 +109,61 @@ func (h *AdminHandler) DeletedPage(c *gin.Context) {
 	}
 
 	c.HTML(http.StatusOK, "deleted.html", gin.H{
-		"expenses": expenses,
-		"total":    len(expenses),
+		"expenses":      expenses,
+		"total":         len(expenses),
+		"retentionDays": int(h.trashRetention.Hours() / 24),
+		"csrfToken":     CSRFToken(c),
 	})
 }
 
+// PurgeExpense permanently removes one expense from the trash
+func (h *AdminHandler) PurgeExpense(c *gin.Context) {
+	if !confirmed(c) {
+		return
+	}
+
+	id := c.Param("id")
+	err := h.service.PurgeExpense(id, actorFromContext(c))
+	switch {
+	case errors.Is(err, expense.ErrExpenseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy chi phí"})
+	case errors.Is(err, expense.ErrExpenseNotDeleted):
+		c.JSON(http.StatusConflict, gin.H{"error": "Chỉ có thể xóa vĩnh viễn chi phí đã nằm trong thùng rác"})
+	case err != nil:
+		log.Printf("[ADMIN] Purge error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		log.Printf("[ADMIN] Purged expense %s", id)
+		c.JSON(http.StatusOK, gin.H{"message": "Đã xóa vĩnh viễn"})
+	}
+}
+
+// EmptyTrash permanently removes every deleted expense
+func (h *AdminHandler) EmptyTrash(c *gin.Context) {
+	if !confirmed(c) {
+		return
+	}
+
+	purged, err := h.service.EmptyTrash(actorFromContext(c))
+	if err != nil {
+		log.Printf("[ADMIN] Empty trash error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã dọn thùng rác", "purged": purged})
+}
+
 func (h *AdminHandler) DeleteExpense(c *gin.Context) {
 	id := c.Param("id")
 	log.Printf("[ADMIN] Delete request for ObjectID: %s", id)
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
 	
-	if err := h.service.DeleteExpense(id); err != nil {
+	if err := h.service.DeleteExpense(id, version, actorFromContext(c)); err != nil {
 		log.Printf("[ADMIN] Delete error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeChangeError(c, h.service, id, err)
 		return
 	}
 
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/attachment"
+	"expense-tracker/domain/expense"
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentHandler struct {
+	service *services.AttachmentService
+}
+
+func NewAttachmentHandler(service *services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{service: service}
+}
+
+func attachmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, attachment.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy tệp đính kèm"})
+	case errors.Is(err, expense.ErrExpenseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy chi phí"})
+	case errors.Is(err, attachment.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, attachment.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// UploadAttachment attaches the file in the "file" form field to an expense
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	// Room for the multipart headers around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, attachment.MaxSize+1<<20)
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			attachmentError(c, attachment.ErrTooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu tệp đính kèm (trường file)"})
+		return
+	}
+	if file.Size > attachment.MaxSize {
+		attachmentError(c, attachment.ErrTooLarge)
+		return
+	}
+	opened, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer opened.Close()
+	data, err := io.ReadAll(io.LimitReader(opened, attachment.MaxSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := imageContentType(data, file.Header.Get("Content-Type"))
+	a, err := h.service.Upload(c.Param("id"), data, file.Filename, contentType, actorFromContext(c))
+	if err != nil {
+		log.Printf("[ATTACHMENT] Upload of %q (%s, %d bytes) failed: %v", file.Filename, contentType, len(data), err)
+		attachmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": a, "message": "Đã đính kèm " + a.Filename})
+}
+
+func (h *AttachmentHandler) ListAttachments(c *gin.Context) {
+	list, err := h.service.List(c.Param("id"))
+	if err != nil {
+		attachmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// DownloadAttachment serves the file. Images are shown inline so that they
+// can be used as thumbnails; anything else is downloaded.
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	a, data, err := h.service.Download(c.Param("id"))
+	if err != nil {
+		attachmentError(c, err)
+		return
+	}
+
+	disposition := "attachment"
+	if a.IsImage() && c.Query("download") != "true" {
+		disposition = "inline"
+	}
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}); header != "" {
+		disposition = header
+	}
+	c.Header("Content-Disposition", disposition)
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, a.ContentType, data)
+}
+
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	if err := h.service.Delete(c.Param("id"), actorFromContext(c)); err != nil {
+		attachmentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã xóa tệp đính kèm"})
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/audit"
+	"github.com/gin-gonic/gin"
+)
+
+// auditRow carries an entry with its snapshots rendered for the HTML page
+type auditRow struct {
+	audit.Entry
+	BeforeJSON string
+	AfterJSON  string
+}
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// auditFilter reads actor, action, target, from, to (YYYY-MM-DD, inclusive)
+// and limit from the query string
+func auditFilter(c *gin.Context) audit.Filter {
+	filter := audit.Filter{
+		Actor:    c.Query("actor"),
+		Action:   c.Query("action"),
+		TargetID: c.Query("target"),
+	}
+	if from, err := time.ParseInLocation("2006-01-02", c.Query("from"), time.Local); err == nil {
+		filter.From = from
+	}
+	if to, err := time.ParseInLocation("2006-01-02", c.Query("to"), time.Local); err == nil {
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+	return filter
+}
+
+func (h *AuditHandler) AuditPage(c *gin.Context) {
+	entries, err := h.service.Search(auditFilter(c))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": err.Error()})
+		return
+	}
+
+	rows := make([]auditRow, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, auditRow{Entry: entry, BeforeJSON: snapshotJSON(entry.Before), AfterJSON: snapshotJSON(entry.After)})
+	}
+
+	c.HTML(http.StatusOK, "audit.html", gin.H{
+		"entries":   rows,
+		"actor":     c.Query("actor"),
+		"action":    c.Query("action"),
+		"target":    c.Query("target"),
+		"from":      c.Query("from"),
+		"to":        c.Query("to"),
+		"limit":     c.Query("limit"),
+		"csrfToken": CSRFToken(c),
+	})
+}
+
+func (h *AuditHandler) ListAudit(c *gin.Context) {
+	entries, err := h.service.Search(auditFilter(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+func snapshotJSON(snapshot map[string]interface{}) string {
+	if len(snapshot) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
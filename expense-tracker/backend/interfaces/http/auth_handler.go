@@ -1,9 +1,13 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"log"
 
+	"expense-tracker/application/services"
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/user"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
@@ -11,10 +15,15 @@ import (
 type UserRepository interface {
 	CreateUser(username, password string) error
 	GetUser(username string) (string, error)
+	FindUser(username string) (*user.UserDTO, error)
+	GetRegistrationMode() (user.RegistrationMode, error)
+	ConsumeInvite(code, username string) error
+	ReleaseInvite(code string) error
 }
 
 type AuthHandler struct {
 	userRepo UserRepository
+	auditLog *services.AuditService
 }
 
 type LoginRequest struct {
@@ -23,12 +32,13 @@ type LoginRequest struct {
 }
 
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	InviteCode string `json:"inviteCode"`
 }
 
-func NewAuthHandler(userRepo UserRepository) *AuthHandler {
-	return &AuthHandler{userRepo: userRepo}
+func NewAuthHandler(userRepo UserRepository, auditLog *services.AuditService) *AuthHandler {
+	return &AuthHandler{userRepo: userRepo, auditLog: auditLog}
 }
 
 func (h *AuthHandler) LoginPage(c *gin.Context) {
@@ -55,8 +65,15 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	
 	// Check credentials from database
 	password, err := h.userRepo.GetUser(req.Username)
+	if errors.Is(err, user.ErrUserDisabled) {
+		log.Printf("[AUTH] Login attempt for disabled user: %s", req.Username)
+		h.auditLog.Record(audit.Actor{Username: req.Username, IP: c.ClientIP()}, audit.ActionLoginFailed, req.Username, nil, map[string]interface{}{"reason": "disabled"})
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return
+	}
 	if err != nil || password != req.Password {
 		log.Printf("[AUTH] Failed login attempt: %s", req.Username)
+		h.auditLog.Record(audit.Actor{Username: req.Username, IP: c.ClientIP()}, audit.ActionLoginFailed, req.Username, nil, map[string]interface{}{"reason": "invalid credentials"})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
 	}
@@ -65,11 +82,15 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	session := sessions.Default(c)
 	session.Set("user_id", req.Username)
 	session.Set("username", req.Username)
+	session.Delete(csrfSessionKey)
 	if err := session.Save(); err != nil {
 		log.Printf("[AUTH] Session save error: %v", err)
 	}
+	// Issue a fresh CSRF token for the authenticated session
+	CSRFToken(c)
 
 	log.Printf("[AUTH] User logged in successfully: %s", req.Username)
+	h.auditLog.Record(actorFromContext(c), audit.ActionLogin, req.Username, nil, nil)
 	c.JSON(http.StatusOK, gin.H{"message": "Login successful"})
 }
 
@@ -84,25 +105,64 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	log.Printf("[AUTH] Register attempt: %s", req.Username)
-	
+
+	mode, err := h.userRepo.GetRegistrationMode()
+	if err != nil {
+		log.Printf("[AUTH] Failed to read registration mode: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Registration unavailable"})
+		return
+	}
+
+	switch mode {
+	case user.RegistrationClosed:
+		log.Printf("[AUTH] Registration closed, rejected: %s", req.Username)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Registration is closed"})
+		return
+	case user.RegistrationInvite:
+		if req.InviteCode == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invite code required"})
+			return
+		}
+		if err := h.userRepo.ConsumeInvite(req.InviteCode, req.Username); err != nil {
+			log.Printf("[AUTH] Invalid invite code for %s: %v", req.Username, err)
+			c.JSON(http.StatusForbidden, gin.H{"error": user.ErrInviteInvalid.Error()})
+			return
+		}
+	}
+
 	// Create user in database
-	err := h.userRepo.CreateUser(req.Username, req.Password)
+	err = h.userRepo.CreateUser(req.Username, req.Password)
 	if err != nil {
+		if mode == user.RegistrationInvite {
+			if releaseErr := h.userRepo.ReleaseInvite(req.InviteCode); releaseErr != nil {
+				log.Printf("[AUTH] Failed to release invite code: %v", releaseErr)
+			}
+		}
 		log.Printf("[AUTH] User already exists: %s", req.Username)
 		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
 		return
 	}
 
 	log.Printf("[AUTH] User registered successfully: %s", req.Username)
+	after := map[string]interface{}{"username": req.Username, "registrationMode": string(mode)}
+	if mode == user.RegistrationInvite {
+		after["inviteCode"] = req.InviteCode
+	}
+	h.auditLog.Record(audit.Actor{Username: req.Username, IP: c.ClientIP()}, audit.ActionRegister, req.Username, nil, after)
 	c.JSON(http.StatusCreated, gin.H{"message": "Registration successful"})
 }
 
 func (h *AuthHandler) Logout(c *gin.Context) {session := sessions.Default(c)
 	username := session.Get("username")
+	if username != nil {
+		h.auditLog.Record(actorFromContext(c), audit.ActionLogout, sessionUsername(c), nil, nil)
+	}
 	session.Clear()
+	// A negative MaxAge removes the server-side session and the cookie
+	session.Options(sessions.Options{Path: "/", MaxAge: -1})
 	session.Save()
 	log.Printf("[AUTH] User logged out: %s", username)
-	c.Redirect(http.StatusTemporaryRedirect, "/")
+	c.Redirect(http.StatusSeeOther, "/")
 }
 
 func AuthRequired() gin.HandlerFunc {
@@ -123,4 +183,38 @@ func AuthRequired() gin.HandlerFunc {
 		}
 		c.Next()
 	}
+}
+
+// AdminRequired rejects requests from users whose current role is not admin.
+// The role is read from the database so that role changes apply immediately.
+func (h *AuthHandler) AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == "OPTIONS" {
+			c.Next()
+			return
+		}
+
+		username := sessionUsername(c)
+		account, err := h.userRepo.FindUser(username)
+		if err != nil || account.Disabled || account.Role != user.RoleAdmin {
+			log.Printf("[AUTH] Admin access denied for %q to %s", username, c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// actorFromContext identifies the logged-in user for the audit log
+func actorFromContext(c *gin.Context) audit.Actor {
+	return audit.Actor{Username: sessionUsername(c), IP: c.ClientIP()}
+}
+
+// sessionUsername returns the logged-in username, or "" for anonymous requests
+func sessionUsername(c *gin.Context) string {
+	if username, ok := sessions.Default(c).Get("username").(string); ok {
+		return username
+	}
+	return ""
 }
\ No newline at end of file
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/backup"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRestoreSize bounds an uploaded archive, attachments included
+const maxRestoreSize = 256 << 20
+
+type BackupHandler struct {
+	service *services.BackupService
+	prices  *services.PriceService
+}
+
+// NewBackupHandler rebuilds the unit prices of prices after a restore or a
+// clear, which change expenses without raising events
+func NewBackupHandler(service *services.BackupService, prices *services.PriceService) *BackupHandler {
+	return &BackupHandler{service: service, prices: prices}
+}
+
+func (h *BackupHandler) rebuildPrices() {
+	if _, err := h.prices.Rebuild(); err != nil {
+		log.Printf("[BACKUP] Failed to rebuild unit prices: %v", err)
+	}
+}
+
+func (h *BackupHandler) BackupPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "backup.html", gin.H{
+		"csrfToken": CSRFToken(c),
+	})
+}
+
+// DownloadBackup sends the archive as a file. It is built in memory first so
+// that a failure part way through becomes an error response rather than a
+// truncated download.
+func (h *BackupHandler) DownloadBackup(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := h.service.WriteBackup(&buf, actorFromContext(c)); err != nil {
+		log.Printf("[BACKUP] Backup error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := "expense-tracker-" + time.Now().Format("20060102-150405") + ".jsonl"
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/x-ndjson", buf.Bytes())
+}
+
+// Restore reads an archive from the "file" form field, or from the raw body,
+// and applies it. mode is merge (default) or replace; dryRun=true only
+// reports what would change.
+func (h *BackupHandler) Restore(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRestoreSize)
+	mode, err := backup.ParseMode(c.DefaultQuery("mode", c.DefaultPostForm("mode", string(backup.ModeMerge))))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dryRun", c.PostForm("dryRun")))
+
+	var body io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		opened, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer opened.Close()
+		body = opened
+	}
+
+	archive, err := backup.Read(body)
+	if err != nil {
+		log.Printf("[BACKUP] Rejected archive: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.Restore(archive, mode, dryRun, actorFromContext(c))
+	if !dryRun {
+		// Even a failed restore may have written some expenses
+		h.rebuildPrices()
+	}
+	if err != nil {
+		log.Printf("[BACKUP] Restore error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": report})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ClearAll permanently removes every expense after writing a backup to the
+// server's backup directory
+func (h *BackupHandler) ClearAll(c *gin.Context) {
+	if !confirmed(c) {
+		return
+	}
+
+	path, err := h.service.ClearAllExpenses(actorFromContext(c))
+	if err != nil {
+		log.Printf("[BACKUP] Clear all error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.rebuildPrices()
+	c.JSON(http.StatusOK, gin.H{"message": "Đã xóa toàn bộ chi phí", "backup": filepath.Base(path)})
+}
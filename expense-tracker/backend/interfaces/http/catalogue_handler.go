@@ -0,0 +1,114 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/catalogue"
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogueHandler struct {
+	service *services.CatalogueService
+}
+
+func NewCatalogueHandler(service *services.CatalogueService) *CatalogueHandler {
+	return &CatalogueHandler{service: service}
+}
+
+type SaveItemRequest struct {
+	Name     string   `json:"name" binding:"required"`
+	Aliases  []string `json:"aliases"`
+	Category string   `json:"category"`
+	Unit     string   `json:"unit"`
+}
+
+type MergeItemsRequest struct {
+	Names []string `json:"names"`
+}
+
+func (r SaveItemRequest) item(id string) catalogue.Item {
+	return catalogue.Item{ID: id, Name: r.Name, Aliases: r.Aliases, Category: r.Category, Unit: r.Unit}
+}
+
+func (h *CatalogueHandler) CataloguePage(c *gin.Context) {
+	c.HTML(http.StatusOK, "catalogue.html", gin.H{
+		"csrfToken": CSRFToken(c),
+	})
+}
+
+func (h *CatalogueHandler) ListItems(c *gin.Context) {
+	items, err := h.service.ListItems()
+	if !h.writeError(c, err) {
+		c.JSON(http.StatusOK, gin.H{"data": items})
+	}
+}
+
+func (h *CatalogueHandler) CreateItem(c *gin.Context) {
+	h.saveItem(c, "")
+}
+
+func (h *CatalogueHandler) UpdateItem(c *gin.Context) {
+	h.saveItem(c, c.Param("id"))
+}
+
+func (h *CatalogueHandler) saveItem(c *gin.Context, id string) {
+	var req SaveItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	item, err := h.service.SaveItem(req.item(id), actorFromContext(c))
+	if !h.writeError(c, err) {
+		c.JSON(http.StatusOK, gin.H{"message": "Đã lưu " + item.Name, "data": item})
+	}
+}
+
+func (h *CatalogueHandler) DeleteItem(c *gin.Context) {
+	if !h.writeError(c, h.service.DeleteItem(c.Param("id"), actorFromContext(c))) {
+		c.JSON(http.StatusOK, gin.H{"message": "Đã xóa mặt hàng"})
+	}
+}
+
+// ItemNames lists how expenses write their items, with the catalogue item
+// each would be merged into
+func (h *CatalogueHandler) ItemNames(c *gin.Context) {
+	names, err := h.service.ItemNames()
+	if !h.writeError(c, err) {
+		c.JSON(http.StatusOK, gin.H{"data": names})
+	}
+}
+
+// Merge adds the names in the body as aliases of the item and rewrites the
+// expenses written with any of its names to it
+func (h *CatalogueHandler) Merge(c *gin.Context) {
+	var req MergeItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := h.service.Merge(c.Param("id"), req.Names, actorFromContext(c))
+	if !h.writeError(c, err) {
+		c.JSON(http.StatusOK, gin.H{"message": "Đã gộp vào " + result.Item.Name, "data": result})
+	}
+}
+
+// writeError answers for err and reports whether there was one
+func (h *CatalogueHandler) writeError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, catalogue.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy mặt hàng"})
+	case errors.Is(err, catalogue.ErrInvalidName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, catalogue.ErrNameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("[ADMIN] Catalogue error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/chat"
+	"expense-tracker/infrastructure/telegram"
+	"github.com/gin-gonic/gin"
+)
+
+// telegramWebhookPath receives updates from Telegram; it is authenticated
+// by the secret token header instead of a session
+const telegramWebhookPath = "/api/chat/telegram"
+
+type ChatHandler struct {
+	service *services.ChatService
+	// secret is the secret_token given to setWebhook; empty when the bot
+	// is not configured
+	secret string
+}
+
+func NewChatHandler(service *services.ChatService, secret string) *ChatHandler {
+	return &ChatHandler{service: service, secret: secret}
+}
+
+func (h *ChatHandler) enabled(c *gin.Context) bool {
+	if h.secret == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chưa cấu hình bot Telegram"})
+		return false
+	}
+	return true
+}
+
+// TelegramWebhook takes an update from Telegram. It answers 200 even when
+// the message could not be recorded, as the reply explains that and
+// Telegram would otherwise send the update again.
+func (h *ChatHandler) TelegramWebhook(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader(telegram.SecretHeader)), []byte(h.secret)) != 1 {
+		log.Printf("[CHAT] Rejected Telegram update from %s: bad secret token", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid secret token"})
+		return
+	}
+
+	var update telegram.Update
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg, ok := update.ChatMessage(); ok {
+		h.service.HandleMessage(msg)
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// CreateLinkCode gives the logged-in user a code to send to the bot
+func (h *ChatHandler) CreateLinkCode(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	code, err := h.service.NewLinkCode(sessionUsername(c))
+	if err != nil {
+		log.Printf("[CHAT] Link code error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    code,
+		"message": "Gửi /link " + code.Code + " cho bot Telegram trong vòng 10 phút",
+	})
+}
+
+// ListLinks returns the chat accounts linked to the logged-in user, and
+// whether the bot is configured at all
+func (h *ChatHandler) ListLinks(c *gin.Context) {
+	links := []chat.Link{}
+	if h.secret != "" {
+		found, err := h.service.Links(sessionUsername(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		links = append(links, found...)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": links, "enabled": h.secret != ""})
+}
+
+func (h *ChatHandler) Unlink(c *gin.Context) {
+	err := h.service.Unlink(c.Param("provider"), c.Param("chatUserId"), actorFromContext(c))
+	switch {
+	case errors.Is(err, chat.ErrNotLinked):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy liên kết"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Đã hủy liên kết"})
+	}
+}
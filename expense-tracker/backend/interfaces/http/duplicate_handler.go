@@ -0,0 +1,108 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/expense"
+	"github.com/gin-gonic/gin"
+)
+
+type DuplicateHandler struct {
+	service *services.DuplicateService
+}
+
+func NewDuplicateHandler(service *services.DuplicateService) *DuplicateHandler {
+	return &DuplicateHandler{service: service}
+}
+
+// MergeRequest names the expense to keep and the duplicate to move to the
+// trash, each with the version the client last saw
+type MergeRequest struct {
+	KeepID      string `json:"keepId" binding:"required"`
+	KeepVersion int64  `json:"keepVersion" binding:"required"`
+	DropID      string `json:"dropId" binding:"required"`
+	DropVersion int64  `json:"dropVersion" binding:"required"`
+}
+
+type DismissRequest struct {
+	FirstID  string `json:"firstId" binding:"required"`
+	SecondID string `json:"secondId" binding:"required"`
+}
+
+func (h *DuplicateHandler) DuplicatesPage(c *gin.Context) {
+	pairs, err := h.service.FindDuplicatePairs()
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": err.Error()})
+		return
+	}
+
+	c.HTML(http.StatusOK, "duplicates.html", gin.H{
+		"pairs":      pairs,
+		"total":      len(pairs),
+		"windowDays": int(expense.DuplicateWindow.Hours() / 24),
+		"csrfToken":  CSRFToken(c),
+	})
+}
+
+func (h *DuplicateHandler) ListDuplicates(c *gin.Context) {
+	pairs, err := h.service.FindDuplicatePairs()
+	if err != nil {
+		log.Printf("[ADMIN] Duplicate search error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": pairs})
+}
+
+// Merge keeps one expense of a pair and moves the other to the trash
+func (h *DuplicateHandler) Merge(c *gin.Context) {
+	var req MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.KeepID == req.DropID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Không thể gộp một chi phí với chính nó"})
+		return
+	}
+
+	err := h.service.Merge(req.KeepID, req.KeepVersion, req.DropID, req.DropVersion, actorFromContext(c))
+	switch {
+	case errors.Is(err, expense.ErrVersionConflict):
+		// Either side may have changed; send both so the page can refresh
+		kept, _ := h.service.Current(req.KeepID)
+		dropped, _ := h.service.Current(req.DropID)
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Chi phí đã được người khác thay đổi, hãy tải lại",
+			"current": gin.H{"keep": kept, "drop": dropped},
+		})
+	case errors.Is(err, expense.ErrExpenseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy chi phí"})
+	case errors.Is(err, expense.ErrExpenseDeleted):
+		c.JSON(http.StatusConflict, gin.H{"error": "Chi phí đã nằm trong thùng rác"})
+	case err != nil:
+		log.Printf("[ADMIN] Merge error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Đã gộp, bản trùng được chuyển vào thùng rác"})
+	}
+}
+
+// Dismiss marks a pair as separate purchases
+func (h *DuplicateHandler) Dismiss(c *gin.Context) {
+	var req DismissRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Dismiss(req.FirstID, req.SecondID, actorFromContext(c)); err != nil {
+		log.Printf("[ADMIN] Dismiss error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Đã đánh dấu không trùng"})
+}
@@ -1,17 +1,33 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"expense-tracker/application/services"
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/idempotency"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
+// idempotencyHeader lets a client retry POST /api/expense without creating
+// the expense twice
+const idempotencyHeader = "Idempotency-Key"
+
 type ExpenseHandler struct {
-	service *services.ExpenseService
+	service     *services.ExpenseService
+	idempotency *services.IdempotencyService
+	duplicates  *services.DuplicateService
+	prices      *services.PriceService
 }
 
 type ExpenseRequest struct {
@@ -19,8 +35,36 @@ type ExpenseRequest struct {
 	UserID  string `json:"userId" binding:"required"`
 }
 
-func NewExpenseHandler(service *services.ExpenseService) *ExpenseHandler {
-	return &ExpenseHandler{service: service}
+// UpdateExpenseRequest carries the version the client last saw and the
+// fields to change; omitted fields keep their value
+type UpdateExpenseRequest struct {
+	Version      int64   `json:"version" binding:"required"`
+	Items        *string `json:"items"`
+	Amount       *int64  `json:"amount"`
+	Currency     *string `json:"currency"`
+	Quantity     *string `json:"quantity"`
+	Unit         *string `json:"unit"`
+	BaseQuantity *string `json:"baseQuantity"`
+	BaseUnit     *string `json:"baseUnit"`
+	PaidDate     *string `json:"paidDate"`
+	PaidBy       *string `json:"paidBy"`
+	Kind         *string `json:"kind"`
+	PaidTo       *string `json:"paidTo"`
+
+	Tags  *[]string `json:"tags"`
+	Notes *string   `json:"notes"`
+}
+
+func NewExpenseHandler(service *services.ExpenseService, idempotency *services.IdempotencyService, duplicates *services.DuplicateService, prices *services.PriceService) *ExpenseHandler {
+	return &ExpenseHandler{service: service, idempotency: idempotency, duplicates: duplicates, prices: prices}
+}
+
+// priceWarning says how far above the usual price an expense was bought
+func priceWarning(alert *services.PriceAlert, base expense.Currency) string {
+	return fmt.Sprintf("Đơn giá %s/%s cao gấp %s lần giá thường gặp (%s/%s)",
+		expense.NewMoneyIn(alert.UnitPrice, base), alert.Unit,
+		strings.Replace(fmt.Sprintf("%.1f", float64(alert.Ratio)/100), ".", ",", 1),
+		expense.NewMoneyIn(alert.Median, base), alert.Unit)
 }
 
 func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
@@ -34,36 +78,139 @@ func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
 		return
 	}
 	
+	// Without a kind it is read from the message; a transfer also needs
+	// paidTo, the member who received the money. tags are added to the
+	// hashtags of the message.
 	var req struct {
-		Message string `json:"message" binding:"required"`
+		Message string   `json:"message" binding:"required"`
+		Kind    string   `json:"kind"`
+		PaidTo  string   `json:"paidTo"`
+		Tags    []string `json:"tags"`
+		Notes   string   `json:"notes"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("[ERROR] Invalid request: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	kind := expense.DetectKind(req.Message)
+	if req.Kind != "" {
+		parsed, err := expense.ParseKind(req.Kind)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		kind = parsed
+	}
+
+	// With an Idempotency-Key, a repeat of a request that already ran gets
+	// the stored response back without parsing or saving again
+	key := c.GetHeader(idempotencyHeader)
+	requestBody := req.Message
+	if req.Kind != "" || req.PaidTo != "" {
+		requestBody += "\x00" + req.Kind + "\x00" + req.PaidTo
+	}
+	if len(req.Tags) > 0 || req.Notes != "" {
+		requestBody += "\x00" + strings.Join(req.Tags, "\x01") + "\x00" + req.Notes
+	}
+	if key != "" {
+		record, err := h.idempotency.Begin(username.(string), key, idempotency.HashRequest(requestBody))
+		switch {
+		case errors.Is(err, idempotency.ErrInvalidKey):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, idempotency.ErrKeyReused):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, idempotency.ErrInProgress):
+			c.Header("Retry-After", "5")
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			log.Printf("[ERROR] Idempotency key lookup failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		case record != nil:
+			log.Printf("[SUCCESS] Replayed expense %s for idempotency key %s", record.ExpenseID, key)
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.StatusCode, "application/json; charset=utf-8", record.Response)
+			return
+		}
+	}
 
 	log.Printf("[INFO] Processing expense: user=%s, message=%s", username, req.Message)
 	
-	parsedData, err := h.service.CreateExpenseFromMessageWithDetails(req.Message, username.(string))
+	parsedData, err := h.service.CreateTransactionFromMessage(req.Message, kind, req.PaidTo, req.Tags, req.Notes, audit.Actor{Username: username.(string), IP: c.ClientIP()})
+	if isTransactionError(err) {
+		if key != "" {
+			h.idempotency.Abandon(username.(string), key)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
+		if key != "" {
+			h.idempotency.Abandon(username.(string), key)
+		}
 		log.Printf("[ERROR] Failed to create expense: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("[SUCCESS] Expense created in %v", time.Since(start))
-	c.JSON(http.StatusOK, gin.H{
+	body := gin.H{
 		"success": true,
 		"parsed": parsedData,
-	})
+	}
+	// The expense is saved either way; a likely duplicate only gets a
+	// warning so that the user can remove it
+	expenseID, _ := parsedData["id"].(string)
+	if duplicates, err := h.duplicates.FindDuplicatesOf(expenseID); err != nil {
+		log.Printf("[ERROR] Duplicate check failed for expense %s: %v", expenseID, err)
+	} else if len(duplicates) > 0 {
+		log.Printf("[INFO] Expense %s looks like a duplicate of %d expense(s)", expenseID, len(duplicates))
+		body["warning"] = "Có thể đã được ghi trước đó, kiểm tra lại để tránh ghi trùng"
+		body["duplicates"] = duplicates
+	}
+	if alert, err := h.prices.CheckPrice(expenseID); err != nil {
+		log.Printf("[ERROR] Price check failed for expense %s: %v", expenseID, err)
+	} else if alert != nil {
+		warning := priceWarning(alert, h.service.BaseCurrency())
+		if previous, ok := body["warning"].(string); ok {
+			warning = previous + ". " + warning
+		}
+		body["warning"] = warning
+		body["priceAlert"] = alert
+	}
+
+	response, err := json.Marshal(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if key != "" {
+		if err := h.idempotency.Finish(username.(string), key, expenseID, http.StatusOK, response); err != nil {
+			// The expense is saved; a retry would now create it again
+			log.Printf("[ERROR] Failed to store idempotency key %s: %v", key, err)
+		}
+	}
+
+	log.Printf("[SUCCESS] Expense created in %v", time.Since(start))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", response)
 }
 
+// GetExpenses lists the active expenses. With ?tag=, which may be repeated,
+// only those carrying every tag given.
 func (h *ExpenseHandler) GetExpenses(c *gin.Context) {
 	start := time.Now()
 	log.Printf("[REQUEST] GET /api/expenses from %s", c.ClientIP())
 	
-	expenses, err := h.service.GetAllExpenses()
+	var expenses []expense.ExpenseDTO
+	var err error
+	if tags := c.QueryArray("tag"); len(tags) > 0 {
+		expenses, err = h.service.GetExpensesByTags(tags)
+	} else {
+		expenses, err = h.service.GetAllExpenses()
+	}
 	if err != nil {
 		log.Printf("[ERROR] Failed to get expenses: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -72,4 +219,148 @@ func (h *ExpenseHandler) GetExpenses(c *gin.Context) {
 
 	log.Printf("[SUCCESS] Retrieved %d expenses in %v", len(expenses), time.Since(start))
 	c.JSON(http.StatusOK, gin.H{"data": expenses})
-}
\ No newline at end of file
+}
+
+// GetTagReport totals the active expenses of each tag, in the base currency
+func (h *ExpenseHandler) GetTagReport(c *gin.Context) {
+	report, err := h.service.GetTagReport()
+	if err != nil {
+		log.Printf("[ERROR] Failed to build tag report: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report, "currency": h.service.BaseCurrency()})
+}
+
+// versionParam reads the required version query parameter, answering 400
+// when it is missing or not a positive number
+func versionParam(c *gin.Context) (int64, bool) {
+	version, err := strconv.ParseInt(c.Query("version"), 10, 64)
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu phiên bản (version) của chi phí"})
+		return 0, false
+	}
+	return version, true
+}
+
+// isTransactionError tells whether err breaks the rules of expense.Kind,
+// names a currency that cannot be converted or carries tags or notes out of
+// bounds, which is the client's mistake
+func isTransactionError(err error) bool {
+	return errors.Is(err, expense.ErrInvalidKind) || errors.Is(err, expense.ErrInvalidAmount) ||
+		errors.Is(err, expense.ErrTransferPaidTo) || errors.Is(err, expense.ErrPaidToNotTransfer) ||
+		errors.Is(err, expense.ErrUnknownCurrency) || errors.Is(err, exchange.ErrNoRate) ||
+		errors.Is(err, expense.ErrInvalidQuantity) || errors.Is(err, expense.ErrInvalidTags) ||
+		errors.Is(err, expense.ErrNotesLength)
+}
+
+// writeChangeError answers a failed update, delete or restore. A version
+// conflict carries the current state so the client can show it and retry.
+func writeChangeError(c *gin.Context, service *services.ExpenseService, id string, err error) {
+	switch {
+	case isTransactionError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, expense.ErrExpenseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy chi phí"})
+	case errors.Is(err, expense.ErrVersionConflict):
+		current, getErr := service.GetExpense(id)
+		if getErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": getErr.Error()})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Chi phí đã được người khác thay đổi, hãy tải lại", "current": current})
+	case errors.Is(err, expense.ErrExpenseDeleted):
+		c.JSON(http.StatusConflict, gin.H{"error": "Chi phí đang nằm trong thùng rác"})
+	case errors.Is(err, expense.ErrExpenseNotDeleted):
+		c.JSON(http.StatusConflict, gin.H{"error": "Chi phí không nằm trong thùng rác"})
+	default:
+		log.Printf("[ERROR] Failed to change expense %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// UpdateExpense changes the fields sent in the body of an active expense
+func (h *ExpenseHandler) UpdateExpense(c *gin.Context) {
+	id := c.Param("id")
+	log.Printf("[REQUEST] PUT /api/expense/%s from %s", id, c.ClientIP())
+
+	var req UpdateExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changes := expense.Changes{
+		Items:        req.Items,
+		Amount:       req.Amount,
+		Quantity:     req.Quantity,
+		Unit:         req.Unit,
+		BaseQuantity: req.BaseQuantity,
+		BaseUnit:     req.BaseUnit,
+		PaidBy:       req.PaidBy,
+		PaidTo:       req.PaidTo,
+		Tags:         req.Tags,
+		Notes:        req.Notes,
+	}
+	if req.Kind != nil {
+		kind, err := expense.ParseKind(*req.Kind)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		changes.Kind = &kind
+	}
+	if req.Currency != nil {
+		currency, err := expense.ParseCurrency(*req.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		changes.Currency = &currency
+	}
+	if req.PaidDate != nil {
+		paidDate, err := time.Parse("2006-01-02", *req.PaidDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Ngày trả phải có dạng YYYY-MM-DD"})
+			return
+		}
+		changes.PaidDate = &paidDate
+	}
+	if err := changes.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateExpense(id, req.Version, changes, actorFromContext(c)); err != nil {
+		writeChangeError(c, h.service, id, err)
+		return
+	}
+	h.writeCurrent(c, id, "Đã cập nhật")
+}
+
+// RestoreExpense takes an expense out of the trash
+func (h *ExpenseHandler) RestoreExpense(c *gin.Context) {
+	id := c.Param("id")
+	version, ok := versionParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RestoreExpense(id, version, actorFromContext(c)); err != nil {
+		writeChangeError(c, h.service, id, err)
+		return
+	}
+	log.Printf("[SUCCESS] Restored expense %s", id)
+	h.writeCurrent(c, id, "Đã khôi phục")
+}
+
+// writeCurrent answers a successful change with the expense as stored, so
+// the client has the new version
+func (h *ExpenseHandler) writeCurrent(c *gin.Context, id, message string) {
+	current, err := h.service.GetExpense(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": current})
+}
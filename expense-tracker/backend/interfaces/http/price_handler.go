@@ -0,0 +1,35 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/price"
+	"github.com/gin-gonic/gin"
+)
+
+type PriceHandler struct {
+	service *services.PriceService
+}
+
+func NewPriceHandler(service *services.PriceService) *PriceHandler {
+	return &PriceHandler{service: service}
+}
+
+// GetPrices returns what an item has cost per base unit over time, with the
+// lowest, highest, average and median unit price of each unit
+func (h *PriceHandler) GetPrices(c *gin.Context) {
+	history, err := h.service.History(c.Param("item"))
+	if errors.Is(err, price.ErrNoPrices) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chưa có đơn giá nào của mặt hàng này"})
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to get prices of %q: %v", c.Param("item"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/exchange"
+	"expense-tracker/domain/expense"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRateFileSize bounds an imported rate file, which is a few bytes a line
+const maxRateFileSize = 1 << 20
+
+type RateHandler struct {
+	service *services.ExchangeService
+}
+
+func NewRateHandler(service *services.ExchangeService) *RateHandler {
+	return &RateHandler{service: service}
+}
+
+type SaveRateRequest struct {
+	Currency string `json:"currency" binding:"required"`
+	Date     string `json:"date" binding:"required"`
+	Rate     string `json:"rate" binding:"required"`
+}
+
+func (h *RateHandler) RatesPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "rates.html", gin.H{
+		"base":      h.service.Base(),
+		"csrfToken": CSRFToken(c),
+	})
+}
+
+func (h *RateHandler) ListRates(c *gin.Context) {
+	rates, err := h.service.ListRates()
+	if err != nil {
+		log.Printf("[ADMIN] List rates error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rates, "base": h.service.Base()})
+}
+
+// SaveRate sets the rate of a currency from a day on, replacing the one of
+// that day if there is one
+func (h *RateHandler) SaveRate(c *gin.Context) {
+	var req SaveRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currency, err := expense.ParseCurrency(req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := exchange.ParseDate(req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ngày phải có dạng YYYY-MM-DD"})
+		return
+	}
+
+	rate := exchange.Rate{Currency: currency, Date: date, Rate: req.Rate}
+	if !h.writeError(c, h.service.SaveRate(rate, actorFromContext(c))) {
+		c.JSON(http.StatusOK, gin.H{"message": "Đã lưu tỷ giá " + string(currency)})
+	}
+}
+
+func (h *RateHandler) DeleteRate(c *gin.Context) {
+	currency, err := expense.ParseCurrency(c.Param("currency"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := exchange.ParseDate(c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ngày phải có dạng YYYY-MM-DD"})
+		return
+	}
+	if !h.writeError(c, h.service.DeleteRate(currency, date, actorFromContext(c))) {
+		c.JSON(http.StatusOK, gin.H{"message": "Đã xóa tỷ giá"})
+	}
+}
+
+// ImportRates saves the rates of the CSV file in the "file" form field, one
+// "currency,date,rate" per line
+func (h *RateHandler) ImportRates(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRateFileSize)
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu tệp tỷ giá (trường file)"})
+		return
+	}
+	opened, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer opened.Close()
+
+	count, err := h.service.ImportRates(opened, actorFromContext(c))
+	if !h.writeError(c, err) {
+		c.JSON(http.StatusOK, gin.H{"message": "Đã nhập tỷ giá", "count": count})
+	}
+}
+
+// writeError answers for err and reports whether there was one
+func (h *RateHandler) writeError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, exchange.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy tỷ giá"})
+	case errors.Is(err, exchange.ErrBaseCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tiền tệ gốc " + string(h.service.Base()) + " không cần tỷ giá"})
+	case errors.Is(err, exchange.ErrInvalidRate), errors.Is(err, exchange.ErrInvalidDate),
+		errors.Is(err, exchange.ErrInvalidFile), errors.Is(err, expense.ErrUnknownCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("[ADMIN] Rate error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/receipt"
+	"expense-tracker/infrastructure/ai"
+	"github.com/gin-gonic/gin"
+)
+
+type ReceiptHandler struct {
+	service *services.ReceiptService
+}
+
+func NewReceiptHandler(service *services.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{service: service}
+}
+
+func receiptError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, receipt.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy hóa đơn"})
+	case errors.Is(err, receipt.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, receipt.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, receipt.ErrUnreadable):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Không đọc được hóa đơn, hãy chụp lại rõ hơn"})
+	case errors.Is(err, ai.ErrNoClient):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Chưa cấu hình Gemini API key để đọc hóa đơn"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// imageContentType sniffs the uploaded bytes rather than trusting the
+// browser. HEIC is not recognised by the sniffer, so the declared type is
+// used for it.
+func imageContentType(data []byte, declared string) string {
+	sniffed := http.DetectContentType(data)
+	if sniffed == "application/octet-stream" && declared == "image/heic" {
+		return declared
+	}
+	return sniffed
+}
+
+// UploadReceipt takes a receipt photo in the "image" form field and records
+// its line items as expenses of the logged-in user
+func (h *ReceiptHandler) UploadReceipt(c *gin.Context) {
+	// Room for the multipart headers around the image
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, receipt.MaxImageSize+1<<20)
+	file, err := c.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			receiptError(c, receipt.ErrTooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu ảnh hóa đơn (trường image)"})
+		return
+	}
+	if file.Size > receipt.MaxImageSize {
+		receiptError(c, receipt.ErrTooLarge)
+		return
+	}
+	opened, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer opened.Close()
+	image, err := io.ReadAll(io.LimitReader(opened, receipt.MaxImageSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := imageContentType(image, file.Header.Get("Content-Type"))
+	upload, err := h.service.Upload(image, file.Filename, contentType, actorFromContext(c))
+	if err != nil {
+		log.Printf("[RECEIPT] Upload of %q (%s, %d bytes) failed: %v", file.Filename, contentType, len(image), err)
+		if upload != nil {
+			// Some lines were recorded before the failure
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": upload})
+			return
+		}
+		receiptError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    upload,
+		"message": "Đã ghi " + strconv.Itoa(len(upload.Expenses)) + " chi phí từ hóa đơn",
+	})
+}
+
+func (h *ReceiptHandler) GetReceipt(c *gin.Context) {
+	rec, err := h.service.GetReceipt(c.Param("id"))
+	if err != nil {
+		receiptError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rec})
+}
+
+func (h *ReceiptHandler) ReceiptImage(c *gin.Context) {
+	rec, image, err := h.service.Image(c.Param("id"))
+	if err != nil {
+		receiptError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, rec.ContentType, image)
+}
+
+// ExpenseReceipt returns the receipt an expense was recorded from
+func (h *ReceiptHandler) ExpenseReceipt(c *gin.Context) {
+	rec, err := h.service.ForExpense(c.Param("id"))
+	if err != nil {
+		receiptError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rec})
+}
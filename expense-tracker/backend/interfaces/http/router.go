@@ -4,13 +4,12 @@ import (
 	"html/template"
 	"log"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
+	"expense-tracker/infrastructure/sessionstore"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 )
 
@@ -44,7 +43,7 @@ func getStatusText(code int) string {
 	return "INFO"
 }
 
-func NewRouter(expenseHandler *ExpenseHandler, adminHandler *AdminHandler, authHandler *AuthHandler, settingsHandler *SettingsHandler) *gin.Engine {
+func NewRouter(store sessions.Store, expenseHandler *ExpenseHandler, adminHandler *AdminHandler, authHandler *AuthHandler, settingsHandler *SettingsHandler, userHandler *UserHandler, sessionHandler *SessionHandler, auditHandler *AuditHandler, backupHandler *BackupHandler, duplicateHandler *DuplicateHandler, streamHandler *StreamHandler, webhookHandler *WebhookHandler, chatHandler *ChatHandler, receiptHandler *ReceiptHandler, attachmentHandler *AttachmentHandler, rateHandler *RateHandler, priceHandler *PriceHandler, catalogueHandler *CatalogueHandler) *gin.Engine {
 	r := gin.Default()
 	
 	// Add template functions
@@ -57,12 +56,12 @@ func NewRouter(expenseHandler *ExpenseHandler, adminHandler *AdminHandler, authH
 	// Load HTML templates
 	r.LoadHTMLGlob("templates/*")
 	
-	// Sessions
-	sessionSecret := os.Getenv("SESSION_SECRET")
-	if sessionSecret == "" {
-		sessionSecret = "default-secret-key-change-in-production"
-	}
-	store := cookie.NewStore([]byte(sessionSecret))
+	// Sessions, which note the client address gin resolves: X-Forwarded-For
+	// only counts when it comes from a trusted proxy
+	r.Use(func(c *gin.Context) {
+		c.Request = sessionstore.WithClientIP(c.Request, c.ClientIP())
+		c.Next()
+	})
 	r.Use(sessions.Sessions("expense-session", store))
 	
 	r.Use(LoggerMiddleware())
@@ -73,11 +72,12 @@ func NewRouter(expenseHandler *ExpenseHandler, adminHandler *AdminHandler, authH
 				   strings.HasSuffix(origin, ":3000")
 		},
 		AllowMethods:     []string{"GET", "POST", "DELETE", "OPTIONS", "PUT", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", csrfHeader, idempotencyHeader},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
+	r.Use(CSRFProtection())
 
 	// Health check (no auth required)
 	r.GET("/health", func(c *gin.Context) {
@@ -109,7 +109,6 @@ func NewRouter(expenseHandler *ExpenseHandler, adminHandler *AdminHandler, authH
 	// Auth routes (no auth required)
 	r.POST("/auth/login", authHandler.Login)
 	r.POST("/auth/register", authHandler.Register)
-	r.GET("/auth/logout", authHandler.Logout)
 	r.POST("/auth/logout", authHandler.Logout)
 	r.OPTIONS("/auth/login", func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", c.GetHeader("Origin"))
@@ -139,13 +138,32 @@ func NewRouter(expenseHandler *ExpenseHandler, adminHandler *AdminHandler, authH
 		protected.GET("/settings", settingsHandler.ShowSettings)
 		protected.POST("/settings", settingsHandler.SaveSettings)
 		protected.POST("/settings/test", settingsHandler.TestAPI)
+
+		// Session management for the logged-in user
+		protected.GET("/sessions", sessionHandler.SessionsPage)
 	}
 
+	// Admin-only pages
+	adminOnly := r.Group("/admin")
+	adminOnly.Use(AuthRequired(), authHandler.AdminRequired())
+	{
+		adminOnly.GET("/users", userHandler.UsersPage)
+		adminOnly.GET("/audit", auditHandler.AuditPage)
+		adminOnly.GET("/backup", backupHandler.BackupPage)
+		adminOnly.GET("/duplicates", duplicateHandler.DuplicatesPage)
+		adminOnly.GET("/webhooks", webhookHandler.WebhooksPage)
+		adminOnly.GET("/rates", rateHandler.RatesPage)
+		adminOnly.GET("/catalogue", catalogueHandler.CataloguePage)
+	}
+
+	// Chat bot updates (authenticated by the bot's secret token)
+	r.POST(telegramWebhookPath, chatHandler.TelegramWebhook)
+
 	// Add OPTIONS handler for all API routes
 	r.OPTIONS("/api/*path", func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", c.GetHeader("Origin"))
 		c.Header("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS, PUT, PATCH")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Origin, Accept, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Origin, Accept, Authorization, "+csrfHeader+", "+idempotencyHeader)
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Status(204)
 	})
@@ -155,6 +173,77 @@ func NewRouter(expenseHandler *ExpenseHandler, adminHandler *AdminHandler, authH
 	{
 		api.POST("/expense", expenseHandler.CreateExpense)
 		api.GET("/expenses", expenseHandler.GetExpenses)
+		api.PUT("/expense/:id", expenseHandler.UpdateExpense)
+		api.POST("/expense/:id/restore", expenseHandler.RestoreExpense)
+		api.POST("/expense/receipt", receiptHandler.UploadReceipt)
+		api.GET("/expense/:id/receipt", receiptHandler.ExpenseReceipt)
+		api.GET("/receipts/:id", receiptHandler.GetReceipt)
+		api.GET("/receipts/:id/image", receiptHandler.ReceiptImage)
+		api.POST("/expense/:id/attachments", attachmentHandler.UploadAttachment)
+		api.GET("/expense/:id/attachments", attachmentHandler.ListAttachments)
+		api.GET("/attachments/:id", attachmentHandler.DownloadAttachment)
+		api.DELETE("/attachments/:id", attachmentHandler.DeleteAttachment)
+		api.GET("/stream", streamHandler.Stream)
+		api.GET("/prices/:item", priceHandler.GetPrices)
+		api.GET("/tags", expenseHandler.GetTagReport)
+
+		api.POST("/chat/link-code", chatHandler.CreateLinkCode)
+		api.GET("/chat/links", chatHandler.ListLinks)
+		api.DELETE("/chat/links/:provider/:chatUserId", chatHandler.Unlink)
+
+		api.GET("/sessions", sessionHandler.ListSessions)
+		api.DELETE("/sessions/:id", sessionHandler.RevokeSession)
+		api.POST("/sessions/revoke-others", sessionHandler.RevokeOtherSessions)
+	}
+
+	// Admin user management API
+	adminAPI := r.Group("/api/admin")
+	adminAPI.Use(AuthRequired(), authHandler.AdminRequired())
+	{
+		adminAPI.GET("/users", userHandler.ListUsers)
+		adminAPI.PUT("/users/:username/role", userHandler.ChangeRole)
+		adminAPI.POST("/users/:username/disable", userHandler.DisableUser)
+		adminAPI.POST("/users/:username/enable", userHandler.EnableUser)
+		adminAPI.POST("/users/:username/reset-password", userHandler.ResetPassword)
+		adminAPI.DELETE("/users/:username", userHandler.DeleteUser)
+
+		adminAPI.GET("/registration-mode", userHandler.GetRegistrationMode)
+		adminAPI.PUT("/registration-mode", userHandler.SetRegistrationMode)
+
+		adminAPI.GET("/invites", userHandler.ListInvites)
+		adminAPI.POST("/invites", userHandler.CreateInvite)
+		adminAPI.DELETE("/invites/:code", userHandler.DeleteInvite)
+
+		adminAPI.GET("/audit", auditHandler.ListAudit)
+
+		adminAPI.GET("/backup", backupHandler.DownloadBackup)
+		adminAPI.POST("/restore", backupHandler.Restore)
+		adminAPI.POST("/expenses/clear-all", backupHandler.ClearAll)
+
+		adminAPI.DELETE("/trash/:id", adminHandler.PurgeExpense)
+		adminAPI.POST("/trash/empty", adminHandler.EmptyTrash)
+
+		adminAPI.GET("/duplicates", duplicateHandler.ListDuplicates)
+		adminAPI.POST("/duplicates/merge", duplicateHandler.Merge)
+		adminAPI.POST("/duplicates/dismiss", duplicateHandler.Dismiss)
+
+		adminAPI.GET("/webhooks", webhookHandler.ListWebhooks)
+		adminAPI.POST("/webhooks", webhookHandler.CreateWebhook)
+		adminAPI.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		adminAPI.GET("/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+		adminAPI.POST("/webhooks/:id/ping", webhookHandler.Ping)
+
+		adminAPI.GET("/rates", rateHandler.ListRates)
+		adminAPI.PUT("/rates", rateHandler.SaveRate)
+		adminAPI.POST("/rates/import", rateHandler.ImportRates)
+		adminAPI.DELETE("/rates/:currency/:date", rateHandler.DeleteRate)
+
+		adminAPI.GET("/catalogue", catalogueHandler.ListItems)
+		adminAPI.POST("/catalogue", catalogueHandler.CreateItem)
+		adminAPI.GET("/catalogue/names", catalogueHandler.ItemNames)
+		adminAPI.PUT("/catalogue/:id", catalogueHandler.UpdateItem)
+		adminAPI.DELETE("/catalogue/:id", catalogueHandler.DeleteItem)
+		adminAPI.POST("/catalogue/:id/merge", catalogueHandler.Merge)
 	}
 
 	return r
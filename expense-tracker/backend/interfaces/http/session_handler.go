@@ -0,0 +1,95 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/user"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionRepository interface {
+	ListSessions(username string) ([]user.SessionDTO, error)
+	RevokeSession(username, id string) error
+	RevokeUserSessions(username, exceptID string) error
+}
+
+type SessionHandler struct {
+	repo     SessionRepository
+	auditLog *services.AuditService
+}
+
+func NewSessionHandler(repo SessionRepository, auditLog *services.AuditService) *SessionHandler {
+	return &SessionHandler{repo: repo, auditLog: auditLog}
+}
+
+func (h *SessionHandler) currentSessions(c *gin.Context) ([]user.SessionDTO, error) {
+	list, err := h.repo.ListSessions(sessionUsername(c))
+	if err != nil {
+		return nil, err
+	}
+
+	currentID := sessions.Default(c).ID()
+	for i := range list {
+		list[i].Current = list[i].ID == currentID
+	}
+	return list, nil
+}
+
+func (h *SessionHandler) SessionsPage(c *gin.Context) {
+	list, err := h.currentSessions(c)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": err.Error()})
+		return
+	}
+
+	c.HTML(http.StatusOK, "sessions.html", gin.H{
+		"sessions":  list,
+		"username":  sessionUsername(c),
+		"csrfToken": CSRFToken(c),
+	})
+}
+
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	list, err := h.currentSessions(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	username := sessionUsername(c)
+	id := c.Param("id")
+
+	if err := h.repo.RevokeSession(username, id); err != nil {
+		if errors.Is(err, user.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("[SESSION] %s revoked session %s", username, id)
+	h.auditLog.Record(actorFromContext(c), audit.ActionSessionRevoke, id, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	username := sessionUsername(c)
+
+	if err := h.repo.RevokeUserSessions(username, sessions.Default(c).ID()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("[SESSION] %s revoked all other sessions", username)
+	h.auditLog.Record(actorFromContext(c), audit.ActionSessionRevokeOthers, username, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked"})
+}
@@ -5,7 +5,12 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"time"
 
+	"expense-tracker/application/services"
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/expense"
+	"expense-tracker/infrastructure/secrets"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/genai"
 )
@@ -16,26 +21,30 @@ type SettingsRepository interface {
 }
 
 type SettingsHandler struct {
-	repo SettingsRepository
+	repo     SettingsRepository
+	auditLog *services.AuditService
+	events   expense.EventPublisher
 }
 
-func NewSettingsHandler(repo SettingsRepository) *SettingsHandler {
-	return &SettingsHandler{repo: repo}
+func NewSettingsHandler(repo SettingsRepository, auditLog *services.AuditService, events expense.EventPublisher) *SettingsHandler {
+	return &SettingsHandler{repo: repo, auditLog: auditLog, events: events}
 }
 
 type SettingsData struct {
-	Message    string
-	Success    bool
-	HasAPIKey  bool
-	CurrentKey string
+	Message        string
+	Success        bool
+	HasAPIKey      bool
+	KeyFingerprint string
+	CSRFToken      string
 }
 
 func (h *SettingsHandler) ShowSettings(c *gin.Context) {
 	apiKey, _ := h.repo.GetAPIKey()
 
 	data := SettingsData{
-		HasAPIKey:  apiKey != "",
-		CurrentKey: apiKey,
+		HasAPIKey:      apiKey != "",
+		KeyFingerprint: secrets.Fingerprint(apiKey),
+		CSRFToken:      CSRFToken(c),
 	}
 
 	tmpl, err := template.ParseFiles("templates/settings.html")
@@ -56,12 +65,26 @@ func (h *SettingsHandler) SaveSettings(c *gin.Context) {
 		return
 	}
 
+	previousKey, _ := h.repo.GetAPIKey()
+
 	// Save to MongoDB
 	if err := h.repo.SaveAPIKey(apiKey); err != nil {
 		h.renderSettings(c, "❌ Lỗi lưu: "+err.Error(), false)
 		return
 	}
 
+	// Only fingerprints go into the audit log, never the key itself
+	actor := actorFromContext(c)
+	h.auditLog.Record(actor, audit.ActionSettingsAPIKey, "gemini_api_key",
+		map[string]interface{}{"fingerprint": secrets.Fingerprint(previousKey)},
+		map[string]interface{}{"fingerprint": secrets.Fingerprint(apiKey)})
+	h.events.Publish(expense.Event{
+		Type:    expense.SettingsChanged,
+		Setting: "gemini_api_key",
+		Actor:   actor.Username,
+		Time:    time.Now(),
+	})
+
 	h.renderSettings(c, "✅ Đã lưu API key thành công! Không cần restart server.", true)
 }
 
@@ -75,6 +98,16 @@ func (h *SettingsHandler) TestAPI(c *gin.Context) {
 		return
 	}
 
+	// Test the stored key when none is typed in
+	if req.APIKey == "" {
+		storedKey, err := h.repo.GetAPIKey()
+		if err != nil || storedKey == "" {
+			c.JSON(http.StatusOK, gin.H{"success": false, "error": "No API key configured"})
+			return
+		}
+		req.APIKey = storedKey
+	}
+
 	// Test Gemini API
 	ctx := context.Background()
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
@@ -109,10 +142,11 @@ func (h *SettingsHandler) renderSettings(c *gin.Context, message string, success
 	apiKey, _ := h.repo.GetAPIKey()
 
 	data := SettingsData{
-		Message:    message,
-		Success:    success,
-		HasAPIKey:  apiKey != "",
-		CurrentKey: apiKey,
+		Message:        message,
+		Success:        success,
+		HasAPIKey:      apiKey != "",
+		KeyFingerprint: secrets.Fingerprint(apiKey),
+		CSRFToken:      CSRFToken(c),
 	}
 
 	tmpl, err := template.ParseFiles("templates/settings.html")
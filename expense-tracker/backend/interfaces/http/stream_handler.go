@@ -0,0 +1,112 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/user"
+	"expense-tracker/infrastructure/eventbus"
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeat keeps proxies from closing an idle stream
+const streamHeartbeat = 25 * time.Second
+
+type StreamRepository interface {
+	FindUser(username string) (*user.UserDTO, error)
+}
+
+type StreamHandler struct {
+	bus   *eventbus.Bus
+	users StreamRepository
+}
+
+func NewStreamHandler(bus *eventbus.Bus, users StreamRepository) *StreamHandler {
+	return &StreamHandler{bus: bus, users: users}
+}
+
+// Stream sends expense events as Server-Sent Events. Admins get every
+// event, or only those for ?paidBy=; other users get the events for the
+// expenses they paid. A reconnect with Last-Event-ID gets what it missed,
+// or a "resync" event when that is no longer known and it should reload.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	username := sessionUsername(c)
+	account, err := h.users.FindUser(username)
+	if err != nil || account.Disabled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tài khoản không hợp lệ"})
+		return
+	}
+	paidBy := username
+	if account.Role == user.RoleAdmin {
+		paidBy = c.Query("paidBy")
+	}
+	visible := func(event expense.Event) bool {
+		return paidBy == "" || event.PaidBy == paidBy
+	}
+
+	lastID, _ := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	sub, backlog, missed := h.bus.Subscribe(lastID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Tells nginx not to buffer the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+
+	log.Printf("[STREAM] %s connected (scope %q, last event %d)", username, paidBy, lastID)
+	defer log.Printf("[STREAM] %s disconnected", username)
+
+	if missed {
+		writeResync(c)
+	}
+	for _, envelope := range backlog {
+		if visible(envelope.Event) {
+			writeEvent(c, envelope)
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case envelope, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind
+				writeResync(c)
+				c.Writer.Flush()
+				return
+			}
+			if visible(envelope.Event) {
+				writeEvent(c, envelope)
+				c.Writer.Flush()
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, envelope eventbus.Envelope) {
+	data, err := json.Marshal(envelope.Event)
+	if err != nil {
+		log.Printf("[STREAM] Encode error: %v", err)
+		return
+	}
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", envelope.ID, envelope.Event.Type, data)
+}
+
+func writeResync(c *gin.Context) {
+	fmt.Fprint(c.Writer, "event: resync\ndata: {}\n\n")
+}
@@ -0,0 +1,339 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/audit"
+	"expense-tracker/domain/user"
+	"github.com/gin-gonic/gin"
+)
+
+type UserAdminRepository interface {
+	FindUser(username string) (*user.UserDTO, error)
+	ListUsers() ([]user.UserDTO, error)
+	SetUserRole(username string, role user.Role) error
+	SetUserDisabled(username string, disabled bool) error
+	ResetPassword(username, password string) error
+	DeleteUser(username string) error
+	GetRegistrationMode() (user.RegistrationMode, error)
+	SaveRegistrationMode(mode user.RegistrationMode) error
+	CreateInvite(createdBy string, ttl time.Duration) (*user.InviteDTO, error)
+	ListInvites() ([]user.InviteDTO, error)
+	DeleteInvite(code string) error
+	RevokeUserSessions(username, exceptID string) error
+}
+
+type UserHandler struct {
+	repo     UserAdminRepository
+	auditLog *services.AuditService
+}
+
+func NewUserHandler(repo UserAdminRepository, auditLog *services.AuditService) *UserHandler {
+	return &UserHandler{repo: repo, auditLog: auditLog}
+}
+
+func (h *UserHandler) UsersPage(c *gin.Context) {
+	users, err := h.repo.ListUsers()
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": err.Error()})
+		return
+	}
+
+	invites, err := h.repo.ListInvites()
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": err.Error()})
+		return
+	}
+
+	mode, err := h.repo.GetRegistrationMode()
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{"error": err.Error()})
+		return
+	}
+
+	c.HTML(http.StatusOK, "users.html", gin.H{
+		"users":       users,
+		"invites":     invites,
+		"mode":        string(mode),
+		"currentUser": sessionUsername(c),
+		"now":         time.Now(),
+		"csrfToken":   CSRFToken(c),
+	})
+}
+
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	users, err := h.repo.ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": users})
+}
+
+func (h *UserHandler) ChangeRole(c *gin.Context) {
+	username := c.Param("username")
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	role, err := user.ParseRole(req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if username == sessionUsername(c) && role != user.RoleAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot remove your own admin role"})
+		return
+	}
+
+	before, err := h.repo.FindUser(username)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	if err := h.repo.SetUserRole(username, role); err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	h.auditLog.Record(actorFromContext(c), audit.ActionUserRole, username,
+		map[string]interface{}{"role": string(before.Role)},
+		map[string]interface{}{"role": string(role)})
+
+	log.Printf("[USERS] %s changed role of %s to %s", sessionUsername(c), username, role)
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated"})
+}
+
+func (h *UserHandler) DisableUser(c *gin.Context) {
+	h.setDisabled(c, true)
+}
+
+func (h *UserHandler) EnableUser(c *gin.Context) {
+	h.setDisabled(c, false)
+}
+
+func (h *UserHandler) setDisabled(c *gin.Context, disabled bool) {
+	username := c.Param("username")
+
+	if disabled && username == sessionUsername(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot disable your own account"})
+		return
+	}
+
+	before, err := h.repo.FindUser(username)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	if err := h.repo.SetUserDisabled(username, disabled); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	if disabled {
+		h.revokeSessions(username)
+	}
+
+	action := audit.ActionUserEnable
+	if disabled {
+		action = audit.ActionUserDisable
+	}
+	h.auditLog.Record(actorFromContext(c), action, username,
+		map[string]interface{}{"disabled": before.Disabled},
+		map[string]interface{}{"disabled": disabled})
+
+	log.Printf("[USERS] %s set disabled=%t for %s", sessionUsername(c), disabled, username)
+	c.JSON(http.StatusOK, gin.H{"message": "User updated"})
+}
+
+// ResetPassword sets the given password, or generates a temporary one when
+// the request body leaves it empty. The new password is returned only once.
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	username := c.Param("username")
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	password := req.Password
+	if password == "" {
+		generated, err := generatePassword()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		password = generated
+	}
+
+	if err := h.repo.ResetPassword(username, password); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	if username != sessionUsername(c) {
+		h.revokeSessions(username)
+	}
+
+	h.auditLog.Record(actorFromContext(c), audit.ActionUserResetPassword, username, nil,
+		map[string]interface{}{"generated": req.Password == ""})
+
+	log.Printf("[USERS] %s reset password for %s", sessionUsername(c), username)
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset", "password": password})
+}
+
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	username := c.Param("username")
+
+	if username == sessionUsername(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot delete your own account"})
+		return
+	}
+
+	before, err := h.repo.FindUser(username)
+	if err != nil {
+		respondUserError(c, err)
+		return
+	}
+
+	if err := h.repo.DeleteUser(username); err != nil {
+		respondUserError(c, err)
+		return
+	}
+	h.revokeSessions(username)
+
+	h.auditLog.Record(actorFromContext(c), audit.ActionUserDelete, username, userSnapshot(*before), nil)
+
+	log.Printf("[USERS] %s deleted user %s", sessionUsername(c), username)
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
+}
+
+func (h *UserHandler) GetRegistrationMode(c *gin.Context) {
+	mode, err := h.repo.GetRegistrationMode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mode": mode})
+}
+
+func (h *UserHandler) SetRegistrationMode(c *gin.Context) {
+	var req struct {
+		Mode string `json:"mode" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	mode, err := user.ParseRegistrationMode(req.Mode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous, _ := h.repo.GetRegistrationMode()
+
+	if err := h.repo.SaveRegistrationMode(mode); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.auditLog.Record(actorFromContext(c), audit.ActionRegistrationMode, "registration_mode",
+		map[string]interface{}{"mode": string(previous)},
+		map[string]interface{}{"mode": string(mode)})
+
+	log.Printf("[USERS] %s set registration mode to %s", sessionUsername(c), mode)
+	c.JSON(http.StatusOK, gin.H{"mode": mode})
+}
+
+func (h *UserHandler) ListInvites(c *gin.Context) {
+	invites, err := h.repo.ListInvites()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": invites})
+}
+
+func (h *UserHandler) CreateInvite(c *gin.Context) {
+	var req struct {
+		TTLHours int `json:"ttlHours"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	ttl := user.DefaultInviteTTL
+	if req.TTLHours > 0 {
+		ttl = time.Duration(req.TTLHours) * time.Hour
+	}
+
+	invite, err := h.repo.CreateInvite(sessionUsername(c), ttl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("[USERS] %s created invite expiring %s", invite.CreatedBy, invite.ExpiresAt.Format(time.RFC3339))
+	h.auditLog.Record(actorFromContext(c), audit.ActionInviteCreate, invite.Code, nil,
+		map[string]interface{}{"expiresAt": invite.ExpiresAt})
+	c.JSON(http.StatusCreated, gin.H{"data": invite})
+}
+
+func (h *UserHandler) DeleteInvite(c *gin.Context) {
+	code := c.Param("code")
+	if err := h.repo.DeleteInvite(code); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	h.auditLog.Record(actorFromContext(c), audit.ActionInviteDelete, code, nil, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted successfully"})
+}
+
+func (h *UserHandler) revokeSessions(username string) {
+	if err := h.repo.RevokeUserSessions(username, ""); err != nil {
+		log.Printf("[USERS] Failed to revoke sessions of %s: %v", username, err)
+	}
+}
+
+func userSnapshot(u user.UserDTO) map[string]interface{} {
+	return map[string]interface{}{
+		"username":  u.Username,
+		"role":      string(u.Role),
+		"disabled":  u.Disabled,
+		"createdAt": u.CreatedAt,
+	}
+}
+
+func respondUserError(c *gin.Context, err error) {
+	if errors.Is(err, user.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func generatePassword() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"expense-tracker/application/services"
+	"expense-tracker/domain/expense"
+	"expense-tracker/domain/webhook"
+	"expense-tracker/infrastructure/secrets"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	service *services.WebhookService
+}
+
+func NewWebhookHandler(service *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+type CreateWebhookRequest struct {
+	URL    string              `json:"url" binding:"required"`
+	Events []expense.EventType `json:"events" binding:"required"`
+}
+
+func (h *WebhookHandler) WebhooksPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "webhooks.html", gin.H{
+		"events":    webhook.Events,
+		"csrfToken": CSRFToken(c),
+	})
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	list, err := h.service.List()
+	if err != nil {
+		log.Printf("[ADMIN] List webhooks error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if list == nil {
+		list = []webhook.Subscription{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list, "events": webhook.Events})
+}
+
+// CreateWebhook answers with the new webhook and its signing secret, which
+// is not shown again
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.service.Create(req.URL, req.Events, actorFromContext(c))
+	switch {
+	case errors.Is(err, webhook.ErrInvalidURL):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "URL phải bắt đầu bằng http:// hoặc https://"})
+	case errors.Is(err, webhook.ErrNoEvents), errors.Is(err, webhook.ErrUnknownEvent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hãy chọn ít nhất một sự kiện hợp lệ"})
+	case errors.Is(err, secrets.ErrNoMasterKey):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Chưa cấu hình SECRETS_KEY nên không thể lưu secret của webhook"})
+	case err != nil:
+		log.Printf("[ADMIN] Create webhook error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusCreated, gin.H{
+			"message": "Đã thêm webhook, hãy lưu lại secret vì nó sẽ không hiện lại",
+			"data":    created,
+			"secret":  created.Secret,
+		})
+	}
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	err := h.service.Delete(c.Param("id"), actorFromContext(c))
+	if !h.writeError(c, err) {
+		c.JSON(http.StatusOK, gin.H{"message": "Đã xóa webhook"})
+	}
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	deliveries, err := h.service.Deliveries(c.Param("id"))
+	if h.writeError(c, err) {
+		return
+	}
+	if deliveries == nil {
+		deliveries = []webhook.Delivery{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": deliveries})
+}
+
+// Ping sends a test event and reports the receiver's answer
+func (h *WebhookHandler) Ping(c *gin.Context) {
+	delivery, err := h.service.Ping(c.Param("id"), actorFromContext(c))
+	if h.writeError(c, err) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": delivery, "success": delivery.Succeeded()})
+}
+
+// writeError answers for err and reports whether there was one
+func (h *WebhookHandler) writeError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, webhook.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy webhook"})
+	default:
+		log.Printf("[ADMIN] Webhook error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return true
+}
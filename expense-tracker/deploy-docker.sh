@@ -19,10 +19,10 @@ if [ -z "$GEMINI_API_KEY" ]; then
     exit 1
 fi
 
-# Generate random secret if not set
-if [ -z "$RANDOM_SECRET" ]; then
-    export RANDOM_SECRET=$(date +%s | sha256sum | head -c 32)
-    echo "Generated random secret: $RANDOM_SECRET"
+# Generate a session secret if not set; docker-compose.prod.yml requires one
+if [ -z "$SESSION_SECRET" ]; then
+    export SESSION_SECRET=$(openssl rand -hex 32)
+    echo "Generated a random SESSION_SECRET (sessions will not survive the next deploy unless you set it in .env.prod)"
 fi
 
 # Pull latest code
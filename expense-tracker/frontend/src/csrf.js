@@ -0,0 +1,6 @@
+// Double-submit CSRF protection: the backend sets a readable XSRF-TOKEN
+// cookie, and every state-changing request must echo it in X-CSRF-Token.
+export function csrfHeaders() {
+  const match = document.cookie.match(/(?:^|;\s*)XSRF-TOKEN=([^;]*)/);
+  return match ? { 'X-CSRF-Token': decodeURIComponent(match[1]) } : {};
+}